@@ -122,6 +122,17 @@ func (assertor *{{.TypeName}}) {{.Name}}(validators ...arg.ValueValidator) error
 		validators...,
 	)
 }
+`
+
+	ARGV_FILE_ASSERTION_TEMPLATE = `
+func (assertor *{{.TypeName}}) {{.Name}}(validators ...func(v *multipart.FileHeader, name string) error) error {
+	for _, validate := range validators {
+		if err := validate(assertor.argv.{{.Name}}, {{printf "%q" .Tag}}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
 `
 
 	ARGV_IP_ASSERTION_TEMPLATE = `
@@ -148,6 +159,7 @@ var (
 		DECIMAL_PTR_ASSERTION_TYPE: ARGV_DECIMAL_PTR_ASSERTION_TEMPLATE,
 		VALUE_ASSERTION_TYPE:       ARGV_VALUE_ASSERTION_TEMPLATE,
 		IP_ASSERTION_TYPE:          ARGV_IP_ASSERTION_TEMPLATE,
+		FILE_ASSERTION_TYPE:        ARGV_FILE_ASSERTION_TEMPLATE,
 	}
 
 	AssertorTypeTemplate   *template.Template
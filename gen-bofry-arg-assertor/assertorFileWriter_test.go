@@ -169,3 +169,67 @@ func (assertor *ProtagonistArgvAssertor) Token(validators ...arg.NumberPtrValida
 		t.Errorf("unit test failed. output doesn't match expected.")
 	}
 }
+
+func TestAssertorFileWriter_WriteFileAssertion(t *testing.T) {
+	file := &AssertorFile{
+		PackageName: "test",
+		Imports: []*ImportDirective{
+			&ImportDirective{
+				Path: MULTIPART_PACKAGE_PATH,
+			},
+		},
+		Types: []*AssertorType{
+			&AssertorType{
+				Name:           "UploadArgvAssertor",
+				SourceTypeName: "UploadArgv",
+				Assertions: []*AssertorValueAssertion{
+					&AssertorValueAssertion{
+						TypeName:          "UploadArgvAssertor",
+						Name:              "File",
+						Tag:               "file",
+						Type:              "file",
+						ArgvFieldType:     "multipart.FileHeader",
+						ArgvFieldTypeStar: "*",
+					},
+				},
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+
+	writer := NewAssertorFileWriter()
+	writer.Write(&buf, file)
+
+	expectedOutput := []byte(`package test
+
+import (
+	arg "github.com/Bofry/arg"
+	"mime/multipart"
+)
+
+type UploadArgvAssertor struct {
+	argv  *UploadArgv
+}
+
+func (argv *UploadArgv) Assertor() *UploadArgvAssertor {
+	return &UploadArgvAssertor{
+		argv: argv,
+	}
+}
+
+func (assertor *UploadArgvAssertor) File(validators ...func(v *multipart.FileHeader, name string) error) error {
+	for _, validate := range validators {
+		if err := validate(assertor.argv.File, "file"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+`)
+
+	if !reflect.DeepEqual(expectedOutput, buf.Bytes()) {
+		t.Logf("%v", string(buf.Bytes()))
+		t.Errorf("unit test failed. output doesn't match expected.")
+	}
+}
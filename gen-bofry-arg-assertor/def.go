@@ -14,6 +14,9 @@ const (
 	DECIMAL_PTR_ASSERTION_TYPE = "*decimal"
 	VALUE_ASSERTION_TYPE       = "value"
 	IP_ASSERTION_TYPE          = "ip"
+	FILE_ASSERTION_TYPE        = "file"
+
+	MULTIPART_PACKAGE_PATH = "mime/multipart"
 )
 
 type (
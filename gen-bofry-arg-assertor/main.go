@@ -259,6 +259,11 @@ func findAssertionType(ident *ast.Ident, info *types.Info, ptr bool) string {
 			return DECIMAL_ASSERTION_TYPE
 		case "net.IP":
 			return IP_ASSERTION_TYPE
+		case "mime/multipart.FileHeader":
+			if ptr {
+				return FILE_ASSERTION_TYPE
+			}
+			return VALUE_ASSERTION_TYPE
 		case "string":
 			if ptr {
 				return STRING_PTR_ASSERTION_TYPE
@@ -354,9 +359,28 @@ func fillAssertorFile(ref *AssertorFile, f *ast.File, info *types.Info) error {
 	ref.PackageName = f.Name.Name
 	ref.Types = assertorTypes
 
+	// import mime/multipart for file assertions
+	for _, t := range assertorTypes {
+		if hasAssertionType(t, FILE_ASSERTION_TYPE) {
+			ref.Imports = append(ref.Imports, &ImportDirective{
+				Path: MULTIPART_PACKAGE_PATH,
+			})
+			break
+		}
+	}
+
 	return nil
 }
 
+func hasAssertionType(t *AssertorType, assertionType string) bool {
+	for _, assertion := range t.Assertions {
+		if assertion.Type == assertionType {
+			return true
+		}
+	}
+	return false
+}
+
 func fillAssertorType(ref *AssertorType, structType *ast.StructType, info *types.Info, tagnames []string) error {
 	var (
 		assertions []*AssertorValueAssertion
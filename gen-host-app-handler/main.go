@@ -29,12 +29,14 @@ var (
 	workdir    string
 	moduleName string
 	moduleDir  string
+	tidy       bool
 
 	BOFRY_HOST_APP_IDENT string = "app"
 )
 
 func init() {
 	flag.StringVar(&gofile, "file", "", "input file")
+	flag.BoolVar(&tidy, "tidy", true, "run 'go mod tidy' after generating")
 }
 
 func main() {
@@ -107,9 +109,11 @@ func main() {
 		}
 	}
 
-	if err := execCmd("go", "mod", "tidy"); err != nil {
-		throw(err.Error())
-		exit(1)
+	if tidy {
+		if err := execCmd("go", "mod", "tidy"); err != nil {
+			throw(err.Error())
+			exit(1)
+		}
 	}
 	exit(0)
 
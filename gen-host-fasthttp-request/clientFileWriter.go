@@ -0,0 +1,266 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"io"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	CLIENT_FILE_NAME        string = "client"
+	CLIENT_ROUTES_FILE_NAME string = "routes"
+	CLIENT_PACKAGE_NAME     string = "client"
+
+	MULTIPART_PACKAGE_PATH  string = "mime/multipart"
+	MULTIPART_FILE_TYPENAME string = "FileHeader"
+	CLIENT_FILE_TYPENAME    string = "File"
+)
+
+var _ FileWriter = new(ClientFileWriter)
+
+type ClientFileWriter struct {
+	PackageName string
+	Imports     []string
+	Routes      []*ClientRoute
+
+	ClientFile       io.Writer
+	ClientRoutesFile io.Writer
+}
+
+// ClientRoute describes the client method calling the request method of
+// route, e.g: GetOrder calls OrderRequest.Get with OrderGetArgv.
+type ClientRoute struct {
+	MethodName string
+	Method     string
+	HttpMethod string
+	Url        string
+	HasBody    bool
+	ArgvName   string
+	// the argv type declaration copied from the generated argv file.
+	ArgvDecl string
+}
+
+func (w *ClientFileWriter) Write() error {
+	var err error
+
+	err = writeClientFile(w.ClientFile, TEMPLATE_NAME_CLIENT_FILE, w)
+	if err != nil {
+		return err
+	}
+
+	err = writeClientFile(w.ClientRoutesFile, TEMPLATE_NAME_CLIENT_ROUTES_FILE, w)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func writeClientFile(w io.Writer, templateName string, data interface{}) error {
+	var buf bytes.Buffer
+
+	err := ClientFileTemplate.ExecuteTemplate(&buf, templateName, data)
+	if err != nil {
+		return err
+	}
+
+	content, err := format.Source(buf.Bytes())
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(content)
+	return err
+}
+
+// resolveClientRoutes resolves the client methods of the request methods
+// implemented by routes, and the imports their argv types require. The
+// hijacked routes are ignored.
+func resolveClientRoutes(routes []*Route, handlerDir string) ([]*ClientRoute, []string, error) {
+	var (
+		clientRoutes []*ClientRoute
+		importMap    = make(map[string]bool)
+		visited      = make(map[string]bool)
+	)
+
+	for _, route := range routes {
+		if len(route.Hijack) > 0 || len(route.Url) == 0 {
+			continue
+		}
+
+		var (
+			requestPrefix = strings.TrimSuffix(route.RequestName, REQUEST_TYPE_SUFFIX)
+			clientPrefix  = requestPrefix
+		)
+		if len(route.PackageName) > 0 {
+			clientPrefix = getPathParamFieldName(route.PackageName) + requestPrefix
+		}
+		// duplicated request?
+		if visited[clientPrefix] {
+			continue
+		}
+		visited[clientPrefix] = true
+
+		for _, method := range route.Methods {
+			var (
+				methodName = getRequestMethodName(method)
+				argvName   = requestPrefix + methodName + "Argv"
+				argvFile   = path.Join(handlerDir, route.PackageName, layout.ArgsDir, layout.FileName(normalizeFileName(requestPrefix)+methodName+"Argv")+".go")
+			)
+
+			clientRoute := &ClientRoute{
+				MethodName: methodName + clientPrefix,
+				Method:     methodName,
+				HttpMethod: method,
+				Url:        route.Url,
+				HasBody:    requestMethodTable[method],
+				ArgvName:   clientPrefix + methodName + "Argv",
+			}
+
+			decl, imports, err := copyArgvTypeDecl(argvFile, argvName, clientRoute.ArgvName)
+			if err != nil {
+				return nil, nil, err
+			}
+			clientRoute.ArgvDecl = decl
+			for _, v := range imports {
+				importMap[v] = true
+			}
+
+			clientRoutes = append(clientRoutes, clientRoute)
+		}
+	}
+
+	imports := make([]string, 0, len(importMap))
+	for k := range importMap {
+		imports = append(imports, k)
+	}
+	sort.Strings(imports)
+	return clientRoutes, imports, nil
+}
+
+// copyArgvTypeDecl copies the fields of argv type argvName in gofile as type
+// typename. The *multipart.FileHeader fields are replaced with *File of the
+// client package. It returns the type declaration and the imports the fields
+// require.
+func copyArgvTypeDecl(gofile string, argvName string, typename string) (string, []string, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, gofile, nil, 0)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var structType *ast.StructType
+	for _, node := range f.Decls {
+		genDecl, ok := node.(*ast.GenDecl)
+		if !ok || genDecl.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range genDecl.Specs {
+			if typeSpec, ok := spec.(*ast.TypeSpec); ok && typeSpec.Name.Name == argvName {
+				structType, _ = typeSpec.Type.(*ast.StructType)
+			}
+		}
+	}
+	if structType == nil {
+		return "", nil, fmt.Errorf("cannot find argv type '%s' in '%s'", argvName, gofile)
+	}
+
+	// resolve the import paths by package name
+	importPathMap := make(map[string]string)
+	for _, spec := range f.Imports {
+		importPath, err := strconv.Unquote(spec.Path.Value)
+		if err != nil {
+			continue
+		}
+		name := path.Base(importPath)
+		if spec.Name != nil {
+			name = spec.Name.Name
+		}
+		importPathMap[name] = importPath
+	}
+
+	var (
+		fields  = make([]*ast.Field, 0, len(structType.Fields.List))
+		imports []string
+	)
+	for _, field := range structType.Fields.List {
+		fieldType := ast.Expr(field.Type)
+
+		// replace *multipart.FileHeader with *File
+		if sel, ok := unwrapStarExpr(fieldType).(*ast.SelectorExpr); ok {
+			if ident, ok := sel.X.(*ast.Ident); ok &&
+				importPathMap[ident.Name] == MULTIPART_PACKAGE_PATH &&
+				sel.Sel.Name == MULTIPART_FILE_TYPENAME {
+				fieldType = &ast.StarExpr{X: ast.NewIdent(CLIENT_FILE_TYPENAME)}
+			}
+		}
+
+		ast.Inspect(fieldType, func(n ast.Node) bool {
+			if sel, ok := n.(*ast.SelectorExpr); ok {
+				if ident, ok := sel.X.(*ast.Ident); ok {
+					if importPath, ok := importPathMap[ident.Name]; ok {
+						imports = append(imports, formatImportSpec(ident.Name, importPath))
+					}
+				}
+			}
+			return true
+		})
+
+		fields = append(fields, &ast.Field{
+			Names: field.Names,
+			Type:  fieldType,
+			Tag:   field.Tag,
+		})
+	}
+
+	decl := &ast.GenDecl{
+		Tok: token.TYPE,
+		Specs: []ast.Spec{
+			&ast.TypeSpec{
+				Name: ast.NewIdent(typename),
+				Type: &ast.StructType{
+					Fields: &ast.FieldList{List: fields},
+				},
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	err = printer.Fprint(&buf, token.NewFileSet(), decl)
+	if err != nil {
+		return "", nil, err
+	}
+	return buf.String(), imports, nil
+}
+
+func unwrapStarExpr(expr ast.Expr) ast.Expr {
+	if star, ok := expr.(*ast.StarExpr); ok {
+		return star.X
+	}
+	return expr
+}
+
+func formatImportSpec(name, importPath string) string {
+	if path.Base(importPath) == name {
+		return strconv.Quote(importPath)
+	}
+	return name + " " + strconv.Quote(importPath)
+}
+
+// getClientPackageName resolves the client package name from the output
+// directory, e.g: ./sdk/orderclient to orderclient.
+func getClientPackageName(dir string) string {
+	name := strings.ToLower(filepath.Base(dir))
+	if !token.IsIdentifier(name) {
+		return CLIENT_PACKAGE_NAME
+	}
+	return name
+}
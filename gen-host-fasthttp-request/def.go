@@ -1,80 +1,384 @@
 package main
 
 import (
-	"html/template"
 	"strings"
+	"text/template"
 )
 
 var (
-	TEMPLATE_NAME_REQUEST_FILE           string = "RequestFile"
-	TEMPLATE_NAME_REQUEST_GET_ARGV_FILE  string = "RequestGetArgvFile"
-	TEMPLATE_NAME_REQUEST_POST_ARGV_FILE string = "RequestPostArgvFile"
-	TEMPLATE_NAME_WEBSOCKET_APP_FILE     string = "WebsocketAppFile"
+	TEMPLATE_NAME_REQUEST_FILE             string = "RequestFile"
+	TEMPLATE_NAME_REQUEST_TEST_FILE        string = "RequestTestFile"
+	TEMPLATE_NAME_REQUEST_QUERY_ARGV_FILE  string = "RequestQueryArgvFile"
+	TEMPLATE_NAME_REQUEST_BODY_ARGV_FILE   string = "RequestBodyArgvFile"
+	TEMPLATE_NAME_MULTIPART_ARGV_FILE      string = "MultipartArgvFile"
+	TEMPLATE_NAME_MULTIPART_FILE           string = "MultipartFile"
+	TEMPLATE_NAME_REQUEST_REPLY_FILE       string = "RequestReplyFile"
+	TEMPLATE_NAME_WEBSOCKET_APP_FILE       string = "WebsocketAppFile"
+	TEMPLATE_NAME_EVENT_SOURCE_APP_FILE    string = "EventSourceAppFile"
+	TEMPLATE_NAME_EVENT_SOURCE_BROKER_FILE string = "EventSourceBrokerFile"
+	TEMPLATE_NAME_CLIENT_FILE              string = "ClientFile"
+	TEMPLATE_NAME_CLIENT_ROUTES_FILE       string = "ClientRoutesFile"
+	TEMPLATE_NAME_PROTO_ARGV_FILE          string = "ProtoArgvFile"
 
-	HTTP_REQUEST_GET_ARGV_FILE_TEMPLATE string = strings.ReplaceAll(`package args
+	HTTP_REQUEST_QUERY_ARGV_FILE_TEMPLATE string = strings.ReplaceAll(`package {{.Layout.ArgsPackageName}}
 
 import (
+{{- if .HasIntPathParams}}
+	"fmt"
+	"strconv"
+{{end}}
 	"github.com/Bofry/arg"
 	"github.com/Bofry/httparg"
 )
 
 var (
-	_ httparg.Validatable = new({{.RequestPrefix}}GetArgv)
+	_ httparg.Validatable = new({{.RequestPrefix}}{{.Method}}Argv)
 )
 
 //go:generate gen-bofry-arg-assertor
-type {{.RequestPrefix}}GetArgv struct /* tag=query */ {
+type {{.RequestPrefix}}{{.Method}}Argv struct /* tag=query */ {
+{{- range .PathParams}}
+	{{.FieldName}} {{.FieldType}} ”path:"{{.Name}}" ^:"path"”
+{{- end}}
 	Nonce *string ”query:"nonce"”
 }
 
 // Validate implements httparg.Validatable.
-func (argv *{{.RequestPrefix}}GetArgv) Validate() error {
+func (argv *{{.RequestPrefix}}{{.Method}}Argv) Validate() error {
 	v := argv.Assertor()
 
 	err := arg.Assert(
+{{- range .PathParams}}
+		v.{{.FieldName}}({{if .IsInt}}arg.Ints.NonNegativeInteger{{else}}arg.StringPtr.NonEmpty{{end}}),
+{{- end}}
 		v.Nonce(arg.StringPtr.NonEmpty),
 	)
 	return err
 }
+{{- if .PathParams}}
+
+// BindPath binds the path parameters resolved by value, e.g: fasthttp.RequestCtx.UserValue.
+func (argv *{{.RequestPrefix}}{{.Method}}Argv) BindPath(value func(key interface{}) interface{}) error {
+{{- range .PathParams}}
+	if v, ok := value("{{.Name}}").(string); ok {
+{{- if .IsInt}}
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid path parameter '{{.Name}}': %v", err)
+		}
+		argv.{{.FieldName}} = n
+{{- else}}
+		argv.{{.FieldName}} = &v
+{{- end}}
+	}
+{{- end}}
+	return nil
+}
+{{- end}}
 `, "”", "`")
 
-	HTTP_REQUEST_POST_ARGV_FILE_TEMPLATE string = strings.ReplaceAll(`package args
+	HTTP_REQUEST_BODY_ARGV_FILE_TEMPLATE string = strings.ReplaceAll(`package {{.Layout.ArgsPackageName}}
 
 import (
+{{- if .HasIntPathParams}}
+	"fmt"
+	"strconv"
+{{end}}
 	"github.com/Bofry/arg"
 	"github.com/Bofry/httparg"
 )
 
 var (
-	_ httparg.Validatable = new({{.RequestPrefix}}PostArgv)
+	_ httparg.Validatable = new({{.RequestPrefix}}{{.Method}}Argv)
 )
 
 //go:generate gen-bofry-arg-assertor
-type {{.RequestPrefix}}PostArgv struct /* tag=json */ {
+type {{.RequestPrefix}}{{.Method}}Argv struct /* tag=json */ {
+{{- range .PathParams}}
+	{{.FieldName}} {{.FieldType}} ”path:"{{.Name}}" ^:"path"”
+{{- end}}
 	Nonce *string ”query:"nonce"   ^:"query"”
 	Text  string  ”json:"*text"”
 }
 
 // Validate implements httparg.Validatable.
-func (argv *{{.RequestPrefix}}PostArgv) Validate() error {
+func (argv *{{.RequestPrefix}}{{.Method}}Argv) Validate() error {
+	v := argv.Assertor()
+
+	err := arg.Assert(
+{{- range .PathParams}}
+		v.{{.FieldName}}({{if .IsInt}}arg.Ints.NonNegativeInteger{{else}}arg.StringPtr.NonEmpty{{end}}),
+{{- end}}
+		v.Nonce(arg.StringPtr.NonEmpty),
+		v.Text(arg.Strings.NonEmpty),
+	)
+	return err
+}
+{{- if .PathParams}}
+
+// BindPath binds the path parameters resolved by value, e.g: fasthttp.RequestCtx.UserValue.
+func (argv *{{.RequestPrefix}}{{.Method}}Argv) BindPath(value func(key interface{}) interface{}) error {
+{{- range .PathParams}}
+	if v, ok := value("{{.Name}}").(string); ok {
+{{- if .IsInt}}
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid path parameter '{{.Name}}': %v", err)
+		}
+		argv.{{.FieldName}} = n
+{{- else}}
+		argv.{{.FieldName}} = &v
+{{- end}}
+	}
+{{- end}}
+	return nil
+}
+{{- end}}
+`, "”", "`")
+
+	HTTP_REQUEST_MULTIPART_ARGV_FILE_TEMPLATE string = strings.ReplaceAll(`package {{.Layout.ArgsPackageName}}
+
+import (
+{{- if .HasIntPathParams}}
+	"fmt"
+{{- end}}
+	"mime/multipart"
+{{- if .HasIntPathParams}}
+	"strconv"
+{{- end}}
+
+	"github.com/Bofry/arg"
+	"github.com/Bofry/httparg"
+)
+
+var (
+	_ httparg.Validatable = new({{.RequestPrefix}}{{.Method}}Argv)
+)
+
+//go:generate gen-bofry-arg-assertor
+type {{.RequestPrefix}}{{.Method}}Argv struct /* tag=form */ {
+{{- range .PathParams}}
+	{{.FieldName}} {{.FieldType}} ”path:"{{.Name}}" ^:"path"”
+{{- end}}
+	Nonce *string ”query:"nonce" ^:"query"”
+	Text  string ”form:"text"”
+	File  *multipart.FileHeader ”form:"file"”
+}
+
+// Validate implements httparg.Validatable.
+func (argv *{{.RequestPrefix}}{{.Method}}Argv) Validate() error {
 	v := argv.Assertor()
 
 	err := arg.Assert(
+{{- range .PathParams}}
+		v.{{.FieldName}}({{if .IsInt}}arg.Ints.NonNegativeInteger{{else}}arg.StringPtr.NonEmpty{{end}}),
+{{- end}}
 		v.Nonce(arg.StringPtr.NonEmpty),
 		v.Text(arg.Strings.NonEmpty),
+		v.File(
+			FileRequired,
+			FileMaxSize(MULTIPART_MAX_FILE_SIZE),
+			FileContentTypes(MULTIPART_CONTENT_TYPES...),
+		),
 	)
 	return err
 }
+
+// BindForm binds the values and files of the multipart form.
+func (argv *{{.RequestPrefix}}{{.Method}}Argv) BindForm(form *multipart.Form) {
+	if values := form.Value["text"]; len(values) > 0 {
+		argv.Text = values[0]
+	}
+	if files := form.File["file"]; len(files) > 0 {
+		argv.File = files[0]
+	}
+}
+{{- if .PathParams}}
+
+// BindPath binds the path parameters resolved by value, e.g: fasthttp.RequestCtx.UserValue.
+func (argv *{{.RequestPrefix}}{{.Method}}Argv) BindPath(value func(key interface{}) interface{}) error {
+{{- range .PathParams}}
+	if v, ok := value("{{.Name}}").(string); ok {
+{{- if .IsInt}}
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid path parameter '{{.Name}}': %v", err)
+		}
+		argv.{{.FieldName}} = n
+{{- else}}
+		argv.{{.FieldName}} = &v
+{{- end}}
+	}
+{{- end}}
+	return nil
+}
+{{- end}}
+`, "”", "`")
+
+	HTTP_REQUEST_MULTIPART_FILE_TEMPLATE string = `package {{.Layout.ArgsPackageName}}
+
+import (
+	"fmt"
+	"mime/multipart"
+	"strings"
+
+	"github.com/Bofry/arg"
+)
+
+// The limits of the multipart requests, change them for your needs.
+// fasthttp reads the whole request body before calling the handler, so
+// Host.Init() sets fasthttp.Server.MaxRequestBodySize to
+// MULTIPART_MAX_REQUEST_SIZE to stop the oversized uploads from being buffered.
+var (
+	MULTIPART_MAX_REQUEST_SIZE int64 = 32 << 20
+	MULTIPART_MAX_FILE_SIZE    int64 = 10 << 20
+	MULTIPART_CONTENT_TYPES          = []string{
+		"image/png",
+		"image/jpeg",
+		"application/pdf",
+	}
+)
+
+// FileRequired validates the file is uploaded.
+func FileRequired(v *multipart.FileHeader, name string) error {
+	if v == nil {
+		return &arg.InvalidArgumentError{
+			Name:   name,
+			Reason: "file is required",
+		}
+	}
+	return nil
+}
+
+// FileMaxSize validates the size of file does not exceed size bytes.
+func FileMaxSize(size int64) func(v *multipart.FileHeader, name string) error {
+	return func(v *multipart.FileHeader, name string) error {
+		if v != nil && v.Size > size {
+			return &arg.InvalidArgumentError{
+				Name:   name,
+				Reason: fmt.Sprintf("file size should not exceed %d bytes", size),
+			}
+		}
+		return nil
+	}
+}
+
+// FileContentTypes validates the content type of file is one of contentTypes.
+func FileContentTypes(contentTypes ...string) func(v *multipart.FileHeader, name string) error {
+	return func(v *multipart.FileHeader, name string) error {
+		if v == nil {
+			return nil
+		}
+
+		contentType := v.Header.Get("Content-Type")
+		if i := strings.IndexByte(contentType, ';'); i >= 0 {
+			contentType = contentType[:i]
+		}
+		contentType = strings.TrimSpace(contentType)
+
+		for _, t := range contentTypes {
+			if strings.EqualFold(t, contentType) {
+				return nil
+			}
+		}
+		return &arg.InvalidArgumentError{
+			Name:   name,
+			Reason: fmt.Sprintf("unsupported content type '%s'", contentType),
+		}
+	}
+}
+`
+
+	PROTO_ARGV_FILE_TEMPLATE string = strings.ReplaceAll(`package {{.Layout.ArgsPackageName}}
+
+import (
+{{- if .Proto.HasRawMessageFields}}
+	"encoding/json"
+{{- end}}
+{{- if .HasIntPathParams}}
+	"fmt"
+	"strconv"
+{{- end}}
+{{- if or .Proto.HasRawMessageFields .HasIntPathParams}}
+{{end}}
+{{- if .HasProtoAssertions}}
+	"github.com/Bofry/arg"
+{{- end}}
+	"github.com/Bofry/httparg"
+)
+
+var (
+	_ httparg.Validatable = new({{.RequestPrefix}}{{.Method}}Argv)
+)
+
+// {{.RequestPrefix}}{{.Method}}Argv is generated from {{.Proto.Message}} of rpc {{.Proto.Rpc}}.
+//
+//go:generate gen-bofry-arg-assertor
+type {{.RequestPrefix}}{{.Method}}Argv struct /* tag={{if .HasBody}}json{{else}}query{{end}} */ {
+{{- range .PathParams}}
+	{{.FieldName}} {{.FieldType}} ”path:"{{.Name}}" ^:"path"”
+{{- end}}
+{{- range .Proto.Fields}}
+	{{.Name}} {{.Type}} ”{{.Tag}}”
+{{- end}}
+}
+
+// Validate implements httparg.Validatable.
+func (argv *{{.RequestPrefix}}{{.Method}}Argv) Validate() error {
+{{- if .HasProtoAssertions}}
+	v := argv.Assertor()
+
+	err := arg.Assert(
+{{- range .PathParams}}
+		v.{{.FieldName}}({{if .IsInt}}arg.Ints.NonNegativeInteger{{else}}arg.StringPtr.NonEmpty{{end}}),
+{{- end}}
+{{- range .Proto.Fields}}
+{{- if .Validators}}
+		v.{{.Name}}({{range $i, $v := .Validators}}{{if $i}}, {{end}}{{$v}}{{end}}),
+{{- end}}
+{{- end}}
+	)
+	return err
+{{- else}}
+	return nil
+{{- end}}
+}
+{{- if .PathParams}}
+
+// BindPath binds the path parameters resolved by value, e.g: fasthttp.RequestCtx.UserValue.
+func (argv *{{.RequestPrefix}}{{.Method}}Argv) BindPath(value func(key interface{}) interface{}) error {
+{{- range .PathParams}}
+	if v, ok := value("{{.Name}}").(string); ok {
+{{- if .IsInt}}
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid path parameter '{{.Name}}': %v", err)
+		}
+		argv.{{.FieldName}} = n
+{{- else}}
+		argv.{{.FieldName}} = &v
+{{- end}}
+	}
+{{- end}}
+	return nil
+}
+{{- end}}
 `, "”", "`")
 
 	HTTP_REQUEST_FILE_TEMPLATE string = `package {{.RequestPackageName}}
 
 import (
 	"log"
-	"{{.AppModuleName}}/handler{{if .IsSubRequestPackage}}/{{.RequestPackageName}}{{end}}/args"
+	"{{.AppModuleName}}/{{.RequestPackagePath}}/{{.Layout.ArgsDir}}"
+{{- if .HasJsonReplyMethods}}
+	"{{.AppModuleName}}/{{.RequestPackagePath}}/{{.Layout.ReplyDir}}"
+{{- end}}
 	. "{{.AppModuleName}}/internal"
 
 	"github.com/Bofry/host-fasthttp/response"
+{{- if .HasFailureMethods}}
+	"github.com/Bofry/host-fasthttp/response/failure"
+{{- end}}
 	"github.com/Bofry/host-fasthttp/tracing"
 	"github.com/Bofry/httparg"
 	"github.com/valyala/fasthttp"
@@ -88,41 +392,152 @@ func (r *{{.RequestName}}) Init() {
 	r.ServiceProvider.ConfigureLogger(log.Default())
 }
 
-func (r *{{.RequestName}}) Get(ctx *fasthttp.RequestCtx) {
+{{- range .Methods}}
+
+func (r *{{$.RequestName}}) {{.Method}}(ctx *fasthttp.RequestCtx) {
 	sp := tracing.SpanFromRequestCtx(ctx)
 	_ = sp
 
-	argv := args.{{.RequestPrefix}}GetArgv{}
+	argv := {{$.Layout.ArgsPackageName}}.{{$.RequestPrefix}}{{.Method}}Argv{}
+{{if $.PathParams}}
+	if err := argv.BindPath(ctx.UserValue); err != nil {
+{{- if .IsJsonReply}}
+		failure.ThrowFailureMessage(failure.INVALID_ARGUMENT, err.Error())
+{{- else}}
+		ctx.Error(err.Error(), fasthttp.StatusBadRequest)
+		return
+{{- end}}
+	}
+{{end}}
+{{- if .IsMultipart}}
+	// check the bytes read rather than Content-Length, which is -1 for the
+	// chunked requests.
+	if int64(len(ctx.Request.Body())) > {{$.Layout.ArgsPackageName}}.MULTIPART_MAX_REQUEST_SIZE {
+		ctx.Error(fasthttp.StatusMessage(fasthttp.StatusRequestEntityTooLarge), fasthttp.StatusRequestEntityTooLarge)
+		return
+	}
+
+	form, err := ctx.MultipartForm()
+	if err != nil {
+{{- if .IsJsonReply}}
+		failure.ThrowFailureMessage(failure.INVALID_ARGUMENT, err.Error())
+{{- else}}
+		ctx.Error(err.Error(), fasthttp.StatusBadRequest)
+		return
+{{- end}}
+	}
+	defer ctx.Request.RemoveMultipartFormFiles()
+
+	argv.BindForm(form)
 
 	httparg.Args(&argv).
 		ProcessQueryString(ctx.QueryArgs().String()).
 		Validate()
-
-	response.Text.Success(ctx, "OK")
-}
-
-func (r *{{.RequestName}}) Post(ctx *fasthttp.RequestCtx) {
-	sp := tracing.SpanFromRequestCtx(ctx)
-	_ = sp
-
-	argv := args.{{.RequestPrefix}}PostArgv{}
-
+{{- else}}
 	httparg.Args(&argv).
 		ProcessQueryString(ctx.QueryArgs().String()).
+		{{- if .HasBody}}
 		ProcessContent(ctx.PostBody(), string(ctx.Request.Header.ContentType())).
+		{{- end}}
 		Validate()
+{{- end}}
+{{- if .IsJsonReply}}
+
+	result := {{$.Layout.ReplyPackageName}}.{{$.RequestPrefix}}{{.Method}}Reply{
+		Message: "OK",
+	}
+	response.Json.Success(ctx, &result)
+{{- else}}
 
 	response.Text.Success(ctx, "OK")
+{{- end}}
 }
+{{- end}}
 `
 
+	HTTP_REQUEST_REPLY_FILE_TEMPLATE string = strings.ReplaceAll(`package {{.Layout.ReplyPackageName}}
+
+type {{.RequestPrefix}}{{.Method}}Reply struct {
+	Message string ”json:"message"”
+}
+`, "”", "`")
+
+	HTTP_REQUEST_TEST_FILE_TEMPLATE string = strings.ReplaceAll(`package {{.RequestPackageName}}
+
+import (
+{{- if .HasMultipartMethods}}
+	"bytes"
+	"mime/multipart"
+	"net/textproto"
+{{- end}}
+{{- if .HasJsonReplyMethods}}
+	"strings"
+{{- end}}
+	"testing"
+	. "{{.AppModuleName}}/internal"
+
+	"github.com/valyala/fasthttp"
+)
+{{- range .Methods}}
+
+func Test{{$.RequestName}}_{{.Method}}(t *testing.T) {
+	r := &{{$.RequestName}}{
+		ServiceProvider: &ServiceProvider{},
+	}
+	r.Init()
+
+	ctx := new(fasthttp.RequestCtx)
+	ctx.Request.Header.SetMethod(fasthttp.Method{{.Method}})
+	ctx.Request.SetRequestURI("{{$.TestRequestUri}}{{.TestQueryString}}")
+	{{- range $.PathParams}}
+	ctx.SetUserValue("{{.Name}}", "{{.SampleValue}}")
+	{{- end}}
+	{{- if .IsMultipart}}
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	form.WriteField("text", "hello")
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Disposition", ”form-data; name="file"; filename="hello.png"”)
+	header.Set("Content-Type", "image/png")
+	part, err := form.CreatePart(header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	part.Write([]byte("hello"))
+	form.Close()
+	ctx.Request.Header.SetContentType(form.FormDataContentType())
+	ctx.Request.SetBody(body.Bytes())
+	{{- else if .HasBody}}
+	ctx.Request.Header.SetContentType("application/json")
+	ctx.Request.SetBodyString({{.TestBody}})
+	{{- end}}
+
+	r.{{.Method}}(ctx)
+
+	if code := ctx.Response.StatusCode(); code != fasthttp.StatusOK {
+		t.Errorf("status code expect %d, got %d", fasthttp.StatusOK, code)
+	}
+	{{- if .IsJsonReply}}
+	if contentType := string(ctx.Response.Header.ContentType()); !strings.HasPrefix(contentType, "application/json") {
+		t.Errorf("content type expect %q, got %q", "application/json", contentType)
+	}
+	{{- else}}
+	if body := string(ctx.Response.Body()); body != "OK" {
+		t.Errorf("body expect %q, got %q", "OK", body)
+	}
+	{{- end}}
+}
+{{- end}}
+`, "”", "`")
+
 	WEBSOCKET_REQUEST_FILE_TEMPLATE string = `package {{.RequestPackageName}}
 
 import (
 	"context"
 	"log"
-	"{{.AppModuleName}}/handler/args"
-	"{{.AppModuleName}}/handler/websocket/{{.WebsocketAppModuleName}}"
+	"{{.AppModuleName}}/{{.RequestPackagePath}}/{{.Layout.ArgsDir}}"
+	"{{.AppModuleName}}/{{.RequestPackagePath}}/{{.Layout.WebsocketDir}}/{{.WebsocketAppModuleName}}"
 	. "{{.AppModuleName}}/internal"
 
 	"github.com/Bofry/host-fasthttp/app/websocket"
@@ -160,8 +575,13 @@ func (r *{{.RequestName}}) Get(ctx *fasthttp.RequestCtx) {
 	sp := tracing.SpanFromRequestCtx(ctx)
 	_ = sp
 
-	argv := args.{{.RequestPrefix}}GetArgv{}
-
+	argv := {{$.Layout.ArgsPackageName}}.{{.RequestPrefix}}GetArgv{}
+{{if .PathParams}}
+	if err := argv.BindPath(ctx.UserValue); err != nil {
+		ctx.Error(err.Error(), fasthttp.StatusBadRequest)
+		return
+	}
+{{end}}
 	httparg.Args(&argv).
 		ProcessQueryString(ctx.QueryArgs().String()).
 		Validate()
@@ -179,25 +599,39 @@ func (r *{{.RequestName}}) Get(ctx *fasthttp.RequestCtx) {
 	WEBSOCKET_APP_FILE_TEMPLATE string = strings.ReplaceAll(`package {{.WebsocketAppModuleName}}
 
 import (
+{{- if .IsPrefix4Protocol}}
 	"bytes"
+{{- else if .IsJsonEnvelopeProtocol}}
+	"encoding/json"
+{{- end}}
 	"log"
 	"{{.AppModuleName}}/internal"
 
 	"github.com/Bofry/host/app"
+{{- if .IsMsgpackProtocol}}
+	"github.com/vmihailenco/msgpack/v5"
+{{- end}}
 )
 
 //go:generate gen-host-app-handler
 var Module = struct {
 	/* put your MessageHandler below */
+{{- range .Messages}}
+	{{$.PadMessageName .Name}} app.MessageHandler ”protocol:"{{.Protocol}}"”
+{{- else}}
 	Echo app.MessageHandler ”protocol:"????"”
+{{- end}}
 
 	/* put your EventHandler below */
+{{- if not .Messages}}
 	EchoEvent app.EventHandler ”channel:"????"”
+{{- end}}
 
 	*App
 	app.ModuleOptionCollection
 }{
 	ModuleOptionCollection: app.ModuleOptions(
+{{- if .IsPrefix4Protocol}}
 		app.WithProtocolResolver(func(format app.MessageFormat, payload []byte) (string, []byte) {
 			/* processing your protocol resolving below */
 			if len(payload) > 5 && payload[4] == '\n' {
@@ -216,8 +650,50 @@ var Module = struct {
 					body,
 				}, nil)
 		}),
+{{- else}}
+		app.WithProtocolResolver(func(format app.MessageFormat, payload []byte) (string, []byte) {
+			/* processing your protocol resolving below */
+			var envelope messageEnvelope
+			if err := {{if .IsMsgpackProtocol}}msgpack{{else}}json{{end}}.Unmarshal(payload, &envelope); err != nil {
+				return "", payload
+			}
+			return envelope.Type, envelope.Data
+		}),
+		app.WithProtocolEmitter(func(format app.MessageFormat, protocol string, body []byte) []byte {
+			/* processing your protocol emitting below */
+			if len(protocol) == 0 {
+				return body
+			}
+			payload, err := {{if .IsMsgpackProtocol}}msgpack{{else}}json{{end}}.Marshal(&messageEnvelope{
+				Type: protocol,
+				Data: body,
+			})
+			if err != nil {
+				return body
+			}
+			return payload
+		}),
+{{- end}}
 	),
 }
+{{- if .IsJsonEnvelopeProtocol}}
+
+// messageEnvelope is the message of json-envelope protocol, the type routes
+// the data to the MessageHandler, e.g: {"type":"chat","data":{"text":"hello"}}.
+type messageEnvelope struct {
+	Type string          ”json:"type"”
+	Data json.RawMessage ”json:"data"”
+}
+{{- else if .IsMsgpackProtocol}}
+
+// messageEnvelope is the message of msgpack protocol, the type routes the
+// data to the MessageHandler, e.g: {"type": "chat", "data": {"text": "hello"}}
+// in MessagePack.
+type messageEnvelope struct {
+	Type string             ”msgpack:"type"”
+	Data msgpack.RawMessage ”msgpack:"data"”
+}
+{{- end}}
 
 type App struct {
 	ServiceProvider *internal.ServiceProvider
@@ -236,11 +712,608 @@ func (ap *App) DefaultEventHandler(ctx *app.Context, event *app.Event) error {
 	return nil
 }
 `, "”", "`")
+	SSE_REQUEST_FILE_TEMPLATE string = `package {{.RequestPackageName}}
+
+import (
+	"bufio"
+	"context"
+	"log"
+	"{{.AppModuleName}}/{{.RequestPackagePath}}/{{.Layout.ArgsDir}}"
+	"{{.AppModuleName}}/{{.RequestPackagePath}}/{{.Layout.SseDir}}/{{.EventSourceAppModuleName}}"
+	. "{{.AppModuleName}}/internal"
+
+	"github.com/Bofry/host-fasthttp/tracing"
+	"github.com/Bofry/host/app"
+	"github.com/Bofry/httparg"
+	"github.com/valyala/fasthttp"
+)
+
+type {{.RequestName}} struct {
+	ServiceProvider *ServiceProvider
+	Config          *Config
+
+	EventSourceApp *app.Application
+}
+
+func (r *{{.RequestName}}) Init() {
+	r.ServiceProvider.ConfigureLogger(log.Default())
+
+	// setup EventSourceApp
+	{
+		r.EventSourceApp = app.Init(&{{.EventSourceAppModuleName}}.Module,
+			app.BindServiceProvider(r.ServiceProvider),
+			app.BindConfig(r.Config),
+			app.BindEventClient(app.MultiEventClient{
+				// register channel and EventClient map herre
+			}),
+		)
+		r.EventSourceApp.Start(context.Background())
+	}
+}
+
+func (r *{{.RequestName}}) Get(ctx *fasthttp.RequestCtx) {
+	sp := tracing.SpanFromRequestCtx(ctx)
+	_ = sp
+
+	argv := {{$.Layout.ArgsPackageName}}.{{.RequestPrefix}}GetArgv{}
+{{if .PathParams}}
+	if err := argv.BindPath(ctx.UserValue); err != nil {
+		ctx.Error(err.Error(), fasthttp.StatusBadRequest)
+		return
+	}
+{{end}}
+	httparg.Args(&argv).
+		ProcessQueryString(ctx.QueryArgs().String()).
+		Validate()
+
+	// the clients requesting the same path parameters share the stream, and
+	// resume the events after Last-Event-ID when reconnected
+	stream := {{.EventSourceAppModuleName}}.StreamKey({{range $i, $p := .PathParams}}{{if $i}}, {{end}}ctx.UserValue("{{$p.Name}}"){{end}})
+	subscriber := {{.EventSourceAppModuleName}}.Broker.Subscribe(stream, string(ctx.Request.Header.Peek("Last-Event-ID")))
+
+	ctx.SetContentType("text/event-stream")
+	ctx.Response.Header.Set("Cache-Control", "no-cache")
+	ctx.Response.Header.Set("Connection", "keep-alive")
+	ctx.Response.Header.Set("X-Accel-Buffering", "no")
+	ctx.SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer {{.EventSourceAppModuleName}}.Broker.Unsubscribe(subscriber)
+
+		{{.EventSourceAppModuleName}}.Serve(w, subscriber)
+	})
+}
+`
+
+	EVENT_SOURCE_APP_FILE_TEMPLATE string = strings.ReplaceAll(`package {{.EventSourceAppModuleName}}
+
+import (
+	"log"
+	"{{.AppModuleName}}/internal"
+
+	"github.com/Bofry/host/app"
+)
+
+//go:generate gen-host-app-handler
+var Module = struct {
+	/* put your EventHandler below */
+
+	*App
+	app.ModuleOptionCollection
+}{
+	ModuleOptionCollection: app.ModuleOptions(),
+}
+
+type App struct {
+	ServiceProvider *internal.ServiceProvider
+	Config          *internal.Config
+}
+
+func (ap *App) Init() {
+	ap.ServiceProvider.ConfigureLogger(log.Default())
+}
+
+func (ap *App) DefaultEventHandler(ctx *app.Context, event *app.Event) error {
+	/* publish the event to the clients of the stream below, e.g:
+	Broker.Publish(StreamKey("room1"), "message", []byte("hello"))
+	*/
+	return nil
+}
+`, "”", "`")
+
+	EVENT_SOURCE_BROKER_FILE_TEMPLATE string = `package {{.EventSourceAppModuleName}}
+
+import (
+	"bufio"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	EVENT_HISTORY_SIZE     = 100
+	SUBSCRIBER_BUFFER_SIZE = 16
+	KEEP_ALIVE_INTERVAL    = 15 * time.Second
+	RETRY_INTERVAL         = 3 * time.Second
+)
+
+// Broker publishes the events to the connected clients.
+var Broker = NewEventBroker(EVENT_HISTORY_SIZE)
+
+// StreamKey returns the stream of the path parameters values.
+func StreamKey(values ...interface{}) string {
+	keys := make([]string, len(values))
+	for i, v := range values {
+		keys[i] = fmt.Sprint(v)
+	}
+	return strings.Join(keys, "/")
+}
+
+type Event struct {
+	ID   string
+	Name string
+	Data []byte
+}
+
+// WriteTo writes the event in text/event-stream format.
+func (e *Event) WriteTo(w *bufio.Writer) {
+	if len(e.ID) > 0 {
+		fmt.Fprintf(w, "id: %s\n", e.ID)
+	}
+	if len(e.Name) > 0 {
+		fmt.Fprintf(w, "event: %s\n", e.Name)
+	}
+	for _, line := range strings.Split(string(e.Data), "\n") {
+		fmt.Fprintf(w, "data: %s\n", line)
+	}
+	w.WriteString("\n")
+}
+
+type Subscriber struct {
+	stream string
+	events chan *Event
+}
+
+func (s *Subscriber) Events() <-chan *Event {
+	return s.events
+}
+
+type eventStream struct {
+	sequence    uint64
+	history     []*Event
+	subscribers map[*Subscriber]bool
+}
+
+type EventBroker struct {
+	mutex       sync.Mutex
+	historySize int
+	streams     map[string]*eventStream
+}
+
+func NewEventBroker(historySize int) *EventBroker {
+	return &EventBroker{
+		historySize: historySize,
+		streams:     make(map[string]*eventStream),
+	}
+}
+
+// Publish sends the event to all subscribers of stream. The slow subscriber
+// is unsubscribed, so that the client reconnects and resumes the missed
+// events by Last-Event-ID.
+func (b *EventBroker) Publish(stream string, name string, data []byte) *Event {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	st := b.getStream(stream)
+	st.sequence++
+	event := &Event{
+		ID:   strconv.FormatUint(st.sequence, 10),
+		Name: name,
+		Data: data,
+	}
+
+	st.history = append(st.history, event)
+	if len(st.history) > b.historySize {
+		st.history = st.history[len(st.history)-b.historySize:]
+	}
+
+	for s := range st.subscribers {
+		select {
+		case s.events <- event:
+		default:
+			delete(st.subscribers, s)
+			close(s.events)
+		}
+	}
+	return event
+}
+
+// Subscribe registers a subscriber of stream and replays the events after
+// lastEventID.
+func (b *EventBroker) Subscribe(stream string, lastEventID string) *Subscriber {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	st := b.getStream(stream)
+
+	var missed []*Event
+	if id, err := strconv.ParseUint(lastEventID, 10, 64); err == nil {
+		for _, event := range st.history {
+			if seq, _ := strconv.ParseUint(event.ID, 10, 64); seq > id {
+				missed = append(missed, event)
+			}
+		}
+	}
+
+	s := &Subscriber{
+		stream: stream,
+		events: make(chan *Event, len(missed)+SUBSCRIBER_BUFFER_SIZE),
+	}
+	for _, event := range missed {
+		s.events <- event
+	}
+	st.subscribers[s] = true
+	return s
+}
+
+// Unsubscribe removes the subscriber when the client disconnected.
+func (b *EventBroker) Unsubscribe(s *Subscriber) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	st, ok := b.streams[s.stream]
+	if ok && st.subscribers[s] {
+		delete(st.subscribers, s)
+		close(s.events)
+	}
+}
+
+func (b *EventBroker) getStream(stream string) *eventStream {
+	st, ok := b.streams[stream]
+	if !ok {
+		st = &eventStream{
+			subscribers: make(map[*Subscriber]bool),
+		}
+		b.streams[stream] = st
+	}
+	return st
+}
+
+// Serve writes the events of subscriber to w until the client disconnected.
+func Serve(w *bufio.Writer, s *Subscriber) {
+	ticker := time.NewTicker(KEEP_ALIVE_INTERVAL)
+	defer ticker.Stop()
+
+	fmt.Fprintf(w, "retry: %d\n\n", RETRY_INTERVAL.Milliseconds())
+	if err := w.Flush(); err != nil {
+		return
+	}
+
+	for {
+		select {
+		case event, ok := <-s.Events():
+			if !ok {
+				return
+			}
+			event.WriteTo(w)
+		case <-ticker.C:
+			// keep the connection alive through the proxies
+			w.WriteString(": keep-alive\n\n")
+		}
+
+		// the client disconnected when flushing failed
+		if err := w.Flush(); err != nil {
+			return
+		}
+	}
+}
+`
+
+	CLIENT_FILE_TEMPLATE string = strings.ReplaceAll(`// Code generated by gen-host-fasthttp-request. DO NOT EDIT.
+
+package {{.PackageName}}
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
 )
 
 var (
-	HttpRequestFileTemplate      *template.Template
-	WebsocketRequestFileTemplate *template.Template
+	pathParamRegexp = regexp.MustCompile(”\{([^{}:]*)(:[^{}]*)?\}”)
+	quoteEscaper    = strings.NewReplacer("\\", "\\\\", ”"”, "\\\"")
+)
+
+// Client calls the routes of the host-fasthttp service.
+type Client struct {
+	baseUrl    string
+	httpClient *http.Client
+	header     http.Header
+	timeout    time.Duration
+}
+
+type ClientOption func(c *Client)
+
+// WithHttpClient specifies the http.Client sending the requests.
+func WithHttpClient(httpClient *http.Client) ClientOption {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithTimeout specifies the timeout of each request.
+func WithTimeout(timeout time.Duration) ClientOption {
+	return func(c *Client) {
+		c.timeout = timeout
+	}
+}
+
+// WithHeader specifies the header sent with each request.
+func WithHeader(key, value string) ClientOption {
+	return func(c *Client) {
+		c.header.Add(key, value)
+	}
+}
+
+func NewClient(baseUrl string, opts ...ClientOption) *Client {
+	c := &Client{
+		baseUrl:    strings.TrimSuffix(baseUrl, "/"),
+		httpClient: http.DefaultClient,
+		header:     make(http.Header),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// File is the file uploaded by the multipart request.
+type File struct {
+	Filename    string
+	ContentType string
+	Content     io.Reader
+}
+
+type Response struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+}
+
+// ResponseError is returned when the status code of response is not 2xx.
+type ResponseError struct {
+	StatusCode int
+	Body       []byte
+}
+
+func (e *ResponseError) Error() string {
+	return fmt.Sprintf("unexpected status code %d: %s", e.StatusCode, bytes.TrimSpace(e.Body))
+}
+
+func (c *Client) do(ctx context.Context, method string, pattern string, argv interface{}, hasBody bool) (*Response, error) {
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+
+	req, err := newRequest(ctx, method, c.baseUrl+pattern, argv, hasBody)
+	if err != nil {
+		return nil, err
+	}
+	for key, values := range c.header {
+		for _, v := range values {
+			req.Header.Add(key, v)
+		}
+	}
+	// propagate the tracing context, e.g: traceparent
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, &ResponseError{
+			StatusCode: resp.StatusCode,
+			Body:       body,
+		}
+	}
+	return &Response{
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header,
+		Body:       body,
+	}, nil
+}
+
+type formField struct {
+	name  string
+	value string
+	file  *File
+}
+
+// newRequest encodes the argv fields by their tags, e.g: path, query, json
+// and form.
+func newRequest(ctx context.Context, method string, pattern string, argv interface{}, hasBody bool) (*http.Request, error) {
+	var (
+		pathParams = make(map[string]string)
+		query      = make(url.Values)
+		jsonBody   = make(map[string]interface{})
+		formFields []formField
+	)
+
+	rv := reflect.Indirect(reflect.ValueOf(argv))
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		var (
+			field = rt.Field(i)
+			value = rv.Field(i)
+		)
+		if value.Kind() == reflect.Ptr && value.IsNil() {
+			continue
+		}
+
+		if name, ok := lookupTagName(field.Tag, "path"); ok {
+			pathParams[name] = formatValue(value)
+		} else if name, ok := lookupTagName(field.Tag, "query"); ok {
+			query.Set(name, formatValue(value))
+		} else if name, ok := lookupTagName(field.Tag, "json"); ok {
+			jsonBody[name] = value.Interface()
+		} else if name, ok := lookupTagName(field.Tag, "form"); ok {
+			if file, ok := value.Interface().(*File); ok {
+				formFields = append(formFields, formField{name: name, file: file})
+			} else {
+				formFields = append(formFields, formField{name: name, value: formatValue(value)})
+			}
+		}
+	}
+
+	var missing []string
+	path := pathParamRegexp.ReplaceAllStringFunc(pattern, func(s string) string {
+		name := pathParamRegexp.FindStringSubmatch(s)[1]
+		value, ok := pathParams[name]
+		if !ok {
+			missing = append(missing, name)
+		}
+		return url.PathEscape(value)
+	})
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("missing path parameter %s of '%s'", strings.Join(missing, ", "), pattern)
+	}
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+
+	var (
+		body        io.Reader
+		contentType string
+	)
+	if hasBody {
+		if len(formFields) > 0 {
+			var buf bytes.Buffer
+			form := multipart.NewWriter(&buf)
+			for _, field := range formFields {
+				if err := writeFormField(form, field); err != nil {
+					return nil, err
+				}
+			}
+			if err := form.Close(); err != nil {
+				return nil, err
+			}
+			body, contentType = &buf, form.FormDataContentType()
+		} else {
+			content, err := json.Marshal(jsonBody)
+			if err != nil {
+				return nil, err
+			}
+			body, contentType = bytes.NewReader(content), "application/json"
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, path, body)
+	if err != nil {
+		return nil, err
+	}
+	if len(contentType) > 0 {
+		req.Header.Set("Content-Type", contentType)
+	}
+	return req, nil
+}
+
+func writeFormField(form *multipart.Writer, field formField) error {
+	if field.file == nil {
+		return form.WriteField(field.name, field.value)
+	}
+
+	contentType := field.file.ContentType
+	if len(contentType) == 0 {
+		contentType = "application/octet-stream"
+	}
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Disposition", fmt.Sprintf(”form-data; name="%s"; filename="%s"”,
+		quoteEscaper.Replace(field.name), quoteEscaper.Replace(field.file.Filename)))
+	header.Set("Content-Type", contentType)
+
+	part, err := form.CreatePart(header)
+	if err != nil {
+		return err
+	}
+	if field.file.Content != nil {
+		_, err = io.Copy(part, field.file.Content)
+	}
+	return err
+}
+
+// lookupTagName resolves the argument name of tag, e.g: json:"*text" to text.
+func lookupTagName(tag reflect.StructTag, key string) (string, bool) {
+	name, ok := tag.Lookup(key)
+	if !ok {
+		return "", false
+	}
+	name = strings.TrimPrefix(name, "*")
+	if pos := strings.IndexByte(name, ','); pos >= 0 {
+		name = name[:pos]
+	}
+	return name, len(name) > 0 && name != "-"
+}
+
+func formatValue(value reflect.Value) string {
+	return fmt.Sprint(reflect.Indirect(value).Interface())
+}
+`, "”", "`")
+
+	CLIENT_ROUTES_FILE_TEMPLATE string = `// Code generated by gen-host-fasthttp-request. DO NOT EDIT.
+
+package {{.PackageName}}
+
+import (
+	"context"
+	"net/http"
+{{- range .Imports}}
+	{{.}}
+{{- end}}
+)
+{{- range .Routes}}
+
+{{.ArgvDecl}}
+
+// {{.MethodName}} calls {{.HttpMethod}} {{.Url}}.
+func (c *Client) {{.MethodName}}(ctx context.Context, argv *{{.ArgvName}}) (*Response, error) {
+	return c.do(ctx, http.Method{{.Method}}, {{printf "%q" .Url}}, argv, {{.HasBody}})
+}
+{{- end}}
+`
+)
+
+var (
+	// NOTE: use text/template since the generated files are go source,
+	// html/template escapes the operators, quotes and urls, e.g: '<-' and '+'.
+	HttpRequestFileTemplate       *template.Template
+	WebsocketRequestFileTemplate  *template.Template
+	SseRequestFileTemplate        *template.Template
+	EventSourceBrokerFileTemplate *template.Template
+	MultipartFileTemplate         *template.Template
+	ClientFileTemplate            *template.Template
+	ProtoArgvFileTemplate         *template.Template
 )
 
 type (
@@ -255,11 +1328,23 @@ func init() {
 		if err != nil {
 			panic(err)
 		}
-		tmpl, err = tmpl.New(TEMPLATE_NAME_REQUEST_GET_ARGV_FILE).Parse(HTTP_REQUEST_GET_ARGV_FILE_TEMPLATE)
+		tmpl, err = tmpl.New(TEMPLATE_NAME_REQUEST_TEST_FILE).Parse(HTTP_REQUEST_TEST_FILE_TEMPLATE)
+		if err != nil {
+			panic(err)
+		}
+		tmpl, err = tmpl.New(TEMPLATE_NAME_REQUEST_QUERY_ARGV_FILE).Parse(HTTP_REQUEST_QUERY_ARGV_FILE_TEMPLATE)
+		if err != nil {
+			panic(err)
+		}
+		tmpl, err = tmpl.New(TEMPLATE_NAME_REQUEST_BODY_ARGV_FILE).Parse(HTTP_REQUEST_BODY_ARGV_FILE_TEMPLATE)
 		if err != nil {
 			panic(err)
 		}
-		tmpl, err = tmpl.New(TEMPLATE_NAME_REQUEST_POST_ARGV_FILE).Parse(HTTP_REQUEST_POST_ARGV_FILE_TEMPLATE)
+		tmpl, err = tmpl.New(TEMPLATE_NAME_MULTIPART_ARGV_FILE).Parse(HTTP_REQUEST_MULTIPART_ARGV_FILE_TEMPLATE)
+		if err != nil {
+			panic(err)
+		}
+		tmpl, err = tmpl.New(TEMPLATE_NAME_REQUEST_REPLY_FILE).Parse(HTTP_REQUEST_REPLY_FILE_TEMPLATE)
 		if err != nil {
 			panic(err)
 		}
@@ -271,7 +1356,7 @@ func init() {
 		if err != nil {
 			panic(err)
 		}
-		tmpl, err = tmpl.New(TEMPLATE_NAME_REQUEST_GET_ARGV_FILE).Parse(HTTP_REQUEST_GET_ARGV_FILE_TEMPLATE)
+		tmpl, err = tmpl.New(TEMPLATE_NAME_REQUEST_QUERY_ARGV_FILE).Parse(HTTP_REQUEST_QUERY_ARGV_FILE_TEMPLATE)
 		if err != nil {
 			panic(err)
 		}
@@ -281,4 +1366,56 @@ func init() {
 		}
 		WebsocketRequestFileTemplate = tmpl
 	}
+
+	{
+		tmpl, err := template.New(TEMPLATE_NAME_REQUEST_FILE).Parse(SSE_REQUEST_FILE_TEMPLATE)
+		if err != nil {
+			panic(err)
+		}
+		tmpl, err = tmpl.New(TEMPLATE_NAME_REQUEST_QUERY_ARGV_FILE).Parse(HTTP_REQUEST_QUERY_ARGV_FILE_TEMPLATE)
+		if err != nil {
+			panic(err)
+		}
+		tmpl, err = tmpl.New(TEMPLATE_NAME_EVENT_SOURCE_APP_FILE).Parse(EVENT_SOURCE_APP_FILE_TEMPLATE)
+		if err != nil {
+			panic(err)
+		}
+		SseRequestFileTemplate = tmpl
+	}
+
+	{
+		tmpl, err := template.New(TEMPLATE_NAME_EVENT_SOURCE_BROKER_FILE).Parse(EVENT_SOURCE_BROKER_FILE_TEMPLATE)
+		if err != nil {
+			panic(err)
+		}
+		EventSourceBrokerFileTemplate = tmpl
+	}
+
+	{
+		tmpl, err := template.New(TEMPLATE_NAME_MULTIPART_FILE).Parse(HTTP_REQUEST_MULTIPART_FILE_TEMPLATE)
+		if err != nil {
+			panic(err)
+		}
+		MultipartFileTemplate = tmpl
+	}
+
+	{
+		tmpl, err := template.New(TEMPLATE_NAME_CLIENT_FILE).Parse(CLIENT_FILE_TEMPLATE)
+		if err != nil {
+			panic(err)
+		}
+		tmpl, err = tmpl.New(TEMPLATE_NAME_CLIENT_ROUTES_FILE).Parse(CLIENT_ROUTES_FILE_TEMPLATE)
+		if err != nil {
+			panic(err)
+		}
+		ClientFileTemplate = tmpl
+	}
+
+	{
+		tmpl, err := template.New(TEMPLATE_NAME_PROTO_ARGV_FILE).Parse(PROTO_ARGV_FILE_TEMPLATE)
+		if err != nil {
+			panic(err)
+		}
+		ProtoArgvFileTemplate = tmpl
+	}
 }
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// GeneratedFileSet collects the files generating for a request. The files are
+// generated in memory and committed together, so the request failed to
+// generate leaves no partial files.
+type GeneratedFileSet struct {
+	files []*generatedFile
+}
+
+type generatedFile struct {
+	filename string
+	// replace the existing file, e.g: the merged request file.
+	overwrite bool
+	content   bytes.Buffer
+}
+
+// Create adds the new file dir/name.go and returns its writer. It returns
+// os.ErrExist if the file exists.
+func (s *GeneratedFileSet) Create(dir, name string) (io.Writer, error) {
+	filename := filepath.Join(dir, name+".go")
+
+	_, err := os.Stat(filename)
+	if err == nil {
+		return nil, os.ErrExist
+	}
+	if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	file := &generatedFile{
+		filename: filename,
+	}
+	s.files = append(s.files, file)
+	return &file.content, nil
+}
+
+// Overwrite adds the content replacing the existing file.
+func (s *GeneratedFileSet) Overwrite(filename string, content []byte) {
+	file := &generatedFile{
+		filename:  filename,
+		overwrite: true,
+	}
+	file.content.Write(content)
+	s.files = append(s.files, file)
+}
+
+func (s *GeneratedFileSet) Len() int {
+	return len(s.files)
+}
+
+// Commit writes the files in the order of filenames, the overwriting files
+// are written after the new files. The new files written are removed if any
+// file failed to write.
+func (s *GeneratedFileSet) Commit() error {
+	sort.SliceStable(s.files, func(i, j int) bool {
+		a, b := s.files[i], s.files[j]
+		if a.overwrite != b.overwrite {
+			return !a.overwrite
+		}
+		return a.filename < b.filename
+	})
+
+	var written []string
+	for _, file := range s.files {
+		var err error
+		if file.overwrite {
+			err = writeFileAtomic(file.filename, file.content.Bytes())
+		} else {
+			err = createFileAtomic(file.filename, file.content.Bytes())
+			if os.IsExist(err) {
+				// the file created by others after planned
+				err = fmt.Errorf("cannot create '%s' cause %v", file.filename, os.ErrExist)
+			}
+		}
+		if err != nil {
+			s.rollback(written)
+			return err
+		}
+		if !file.overwrite {
+			written = append(written, file.filename)
+		}
+	}
+	return nil
+}
+
+func (s *GeneratedFileSet) rollback(filenames []string) {
+	for _, filename := range filenames {
+		os.Remove(filename)
+	}
+}
+
+// writeFileAtomic writes the content to the temp file in the same directory
+// and renames it to filename, so the file is either unchanged or completely
+// written. The missing directories are created.
+func writeFileAtomic(filename string, content []byte) error {
+	tmp, err := writeTempFile(filename, content)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp)
+
+	return os.Rename(tmp, filename)
+}
+
+// createFileAtomic is like writeFileAtomic but links the temp file to
+// filename, so it returns os.ErrExist instead of replacing the file created
+// by others.
+func createFileAtomic(filename string, content []byte) error {
+	tmp, err := writeTempFile(filename, content)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp)
+
+	return os.Link(tmp, filename)
+}
+
+// writeTempFile writes the content to the temp file in the directory of
+// filename and returns the temp filename. The missing directories are
+// created.
+func writeTempFile(filename string, content []byte) (string, error) {
+	dir := filepath.Dir(filename)
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return "", err
+	}
+
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(filename)+".*.tmp")
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return "", err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return "", err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		os.Remove(tmp.Name())
+		return "", err
+	}
+	return tmp.Name(), nil
+}
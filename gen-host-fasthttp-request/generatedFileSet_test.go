@@ -0,0 +1,91 @@
+package main
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"testing"
+)
+
+func TestGeneratedFileSet_Commit(t *testing.T) {
+	tmp := t.TempDir()
+
+	assert(t,
+		os.WriteFile(path.Join(tmp, "orderRequest.go"), []byte("package handler\n"), 0644),
+	)
+
+	files := new(GeneratedFileSet)
+	if _, err := files.Create(tmp, "orderRequest"); !os.IsExist(err) {
+		t.Errorf("should get os.ErrExist when the file exists, got %v", err)
+	}
+	w, err := files.Create(path.Join(tmp, "args"), "orderGetArgv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write([]byte("package args\n"))
+	files.Overwrite(path.Join(tmp, "orderRequest.go"), []byte("package handler\n\ntype OrderRequest struct{}\n"))
+
+	if files.Len() != 2 {
+		t.Errorf("files expect %d, got %d", 2, files.Len())
+	}
+	assert(t, files.Commit())
+
+	for filename, expected := range map[string]string{
+		"args/orderGetArgv.go": "package args\n",
+		"orderRequest.go":      "package handler\n\ntype OrderRequest struct{}\n",
+	} {
+		content, err := os.ReadFile(path.Join(tmp, filename))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if expected != string(content) {
+			t.Errorf("%s expect:\n%s\ngot:\n%s\n", filename, expected, string(content))
+		}
+	}
+	if matches, _ := filepath.Glob(path.Join(tmp, "*", ".*.tmp")); len(matches) > 0 {
+		t.Errorf("temp files should be removed, got %v", matches)
+	}
+
+	// the new files are removed if any file failed to write
+	files = new(GeneratedFileSet)
+	for _, dir := range []string{path.Join(tmp, "args"), path.Join(tmp, "reply")} {
+		w, err := files.Create(dir, "orderPutArgv")
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte("package args\n"))
+	}
+	assert(t,
+		os.WriteFile(path.Join(tmp, "reply"), []byte{}, 0644),
+	)
+	if err := files.Commit(); err == nil {
+		t.Errorf("should get error when the directory cannot be created")
+	}
+	if _, err := os.Stat(path.Join(tmp, "args", "orderPutArgv.go")); !os.IsNotExist(err) {
+		t.Errorf("args/orderPutArgv.go should be removed")
+	}
+
+	// the file created by others after planned is not replaced
+	files = new(GeneratedFileSet)
+	w, err = files.Create(path.Join(tmp, "args"), "orderPostArgv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Write([]byte("package args\n"))
+	assert(t,
+		os.WriteFile(path.Join(tmp, "args", "orderPostArgv.go"), []byte("package others\n"), 0644),
+	)
+	if err := files.Commit(); err == nil {
+		t.Errorf("should get error when the file is created by others")
+	}
+	content, err := os.ReadFile(path.Join(tmp, "args", "orderPostArgv.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "package others\n" {
+		t.Errorf("args/orderPostArgv.go should not be replaced, got:\n%s", string(content))
+	}
+	if matches, _ := filepath.Glob(path.Join(tmp, "args", ".*.tmp")); len(matches) > 0 {
+		t.Errorf("temp files should be removed, got %v", matches)
+	}
+}
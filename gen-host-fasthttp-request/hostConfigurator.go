@@ -0,0 +1,162 @@
+package main
+
+import (
+	"bytes"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/ast/astutil"
+)
+
+// HostConfigurator sets fasthttp.Server.MaxRequestBodySize in Host.Init() of
+// the internal package to MULTIPART_MAX_REQUEST_SIZE, so fasthttp rejects the
+// oversized multipart requests before buffering them. The limit already set
+// in Host.Init(), e.g: by user, is never changed.
+type HostConfigurator struct {
+	InternalDir string
+	HandlerDir  string
+}
+
+// Configure returns the file changed, or empty if nothing is changed.
+func (c *HostConfigurator) Configure() (string, error) {
+	argsDir, err := c.lookupMultipartArgsDir()
+	if err != nil || len(argsDir) == 0 {
+		return "", err
+	}
+
+	argsPackageName, err := getPackageName(filepath.Join(argsDir, MULTIPART_FILE_NAME+".go"))
+	if err != nil {
+		return "", err
+	}
+
+	filenames, err := filepath.Glob(filepath.Join(c.InternalDir, "*.go"))
+	if err != nil {
+		return "", err
+	}
+	sort.Strings(filenames)
+
+	for _, filename := range filenames {
+		if strings.HasSuffix(filename, TEST_FILE_SUFFIX+".go") {
+			continue
+		}
+
+		src, err := os.ReadFile(filename)
+		if err != nil {
+			return "", err
+		}
+		fset := token.NewFileSet()
+		f, err := parser.ParseFile(fset, filename, src, parser.ParseComments)
+		if err != nil {
+			return "", err
+		}
+
+		lit := lookupHostServerLiteral(f)
+		if lit == nil {
+			continue
+		}
+		for _, elt := range lit.Elts {
+			if kv, ok := elt.(*ast.KeyValueExpr); ok {
+				if ident, ok := kv.Key.(*ast.Ident); ok && ident.Name == HOST_MAX_REQUEST_BODY_SIZE_NAME {
+					return "", nil
+				}
+			}
+		}
+
+		var (
+			offset = fset.Position(lit.Rbrace).Offset
+			field  = "\t" + HOST_MAX_REQUEST_BODY_SIZE_NAME + ": int(" + argsPackageName + ".MULTIPART_MAX_REQUEST_SIZE),\n"
+		)
+		content := append([]byte(nil), src[:offset]...)
+		content = append(content, field...)
+		content = append(content, src[offset:]...)
+
+		// import the args package
+		fset = token.NewFileSet()
+		f, err = parser.ParseFile(fset, filename, content, parser.ParseComments)
+		if err != nil {
+			return "", err
+		}
+		argsModulePath := appModuleName + "/" + filepath.ToSlash(filepath.Clean(argsDir))
+		if path.Base(argsModulePath) == argsPackageName {
+			astutil.AddImport(fset, f, argsModulePath)
+		} else {
+			astutil.AddNamedImport(fset, f, argsPackageName, argsModulePath)
+		}
+
+		var buf bytes.Buffer
+		err = format.Node(&buf, fset, f)
+		if err != nil {
+			return "", err
+		}
+		return filename, os.WriteFile(filename, buf.Bytes(), 0644)
+	}
+	return "", nil
+}
+
+// lookupMultipartArgsDir returns the first args directory having the
+// multipart file under HandlerDir.
+func (c *HostConfigurator) lookupMultipartArgsDir() (string, error) {
+	var argsDir string
+
+	err := filepath.WalkDir(c.HandlerDir, func(filename string, d fs.DirEntry, err error) error {
+		if len(argsDir) > 0 {
+			return filepath.SkipDir
+		}
+		if err != nil {
+			if os.IsNotExist(err) {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if !d.IsDir() && d.Name() == MULTIPART_FILE_NAME+".go" {
+			argsDir = filepath.Dir(filename)
+		}
+		return nil
+	})
+	return argsDir, err
+}
+
+// lookupHostServerLiteral returns the &fasthttp.Server{...} literal in
+// Host.Init().
+func lookupHostServerLiteral(f *ast.File) *ast.CompositeLit {
+	for _, decl := range f.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || fn.Name.Name != HOST_INIT_FUNC_NAME || fn.Body == nil || !isRequestMethod(fn, HOST_TYPE_NAME) {
+			continue
+		}
+
+		var lit *ast.CompositeLit
+		ast.Inspect(fn.Body, func(n ast.Node) bool {
+			if lit != nil {
+				return false
+			}
+			if v, ok := n.(*ast.CompositeLit); ok {
+				if sel, ok := v.Type.(*ast.SelectorExpr); ok && sel.Sel.Name == "Server" {
+					if ident, ok := sel.X.(*ast.Ident); ok && ident.Name == "fasthttp" {
+						lit = v
+						return false
+					}
+				}
+			}
+			return true
+		})
+		return lit
+	}
+	return nil
+}
+
+func getPackageName(filename string) (string, error) {
+	f, err := parser.ParseFile(token.NewFileSet(), filename, nil, parser.PackageClauseOnly)
+	if err != nil {
+		return "", err
+	}
+	return f.Name.Name, nil
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"os"
+	"path"
+	"strings"
+	"testing"
+)
+
+var (
+	_FILE_CONFIGURING_INTERNAL_DEF_GO = strings.ReplaceAll(`package internal
+
+import (
+	"log"
+
+	fasthttp "github.com/Bofry/host-fasthttp"
+)
+
+type (
+	Host fasthttp.Host
+
+	Config struct {
+		ListenAddress string ”yaml:"ListenAddress"”
+		ServerName    string ”yaml:"ServerName"”
+	}
+)
+
+func (h *Host) Init(conf *Config) {
+	h.Server = &fasthttp.Server{
+		Name:             conf.ServerName,
+		DisableKeepalive: false,
+		Logger:           log.Default(),
+	}
+	h.ListenAddress = conf.ListenAddress
+}
+`, "”", "`")
+
+	_EXPECT_FILE_CONFIGURING_INTERNAL_DEF_GO = strings.ReplaceAll(`package internal
+
+import (
+	"host-fasthttp-request-demo/handler/args"
+	"log"
+
+	fasthttp "github.com/Bofry/host-fasthttp"
+)
+
+type (
+	Host fasthttp.Host
+
+	Config struct {
+		ListenAddress string ”yaml:"ListenAddress"”
+		ServerName    string ”yaml:"ServerName"”
+	}
+)
+
+func (h *Host) Init(conf *Config) {
+	h.Server = &fasthttp.Server{
+		Name:               conf.ServerName,
+		DisableKeepalive:   false,
+		Logger:             log.Default(),
+		MaxRequestBodySize: int(args.MULTIPART_MAX_REQUEST_SIZE),
+	}
+	h.ListenAddress = conf.ListenAddress
+}
+`, "”", "`")
+)
+
+func TestHostConfigurator(t *testing.T) {
+	tmp := t.TempDir()
+
+	appModuleName = "host-fasthttp-request-demo"
+	t.Cleanup(func() {
+		appModuleName = ""
+	})
+
+	assert(t,
+		createTempFiles(tmp, _FILE_CONFIGURING_INTERNAL_DEF_GO, "def.go", "internal"),
+		os.MkdirAll(path.Join(tmp, "handler"), os.ModePerm),
+	)
+
+	workdir, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.Chdir(tmp)
+	defer os.Chdir(workdir)
+
+	configurator := &HostConfigurator{
+		InternalDir: INTERNAL_MODULE_NAME,
+		HandlerDir:  HANDLER_MODULE_NAME,
+	}
+
+	// no multipart request
+	filename, err := configurator.Configure()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(filename) > 0 {
+		t.Errorf("%s should not be changed without the multipart requests", filename)
+	}
+
+	assert(t,
+		createTempFiles(tmp, "package args\n", "multipart.go", "handler/args"),
+	)
+
+	filename, err = configurator.Configure()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := path.Join("internal", "def.go"); filename != expected {
+		t.Errorf("changed file expect %q, got %q", expected, filename)
+	}
+	content, err := readFile(tmp, "def.go", "internal")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _EXPECT_FILE_CONFIGURING_INTERNAL_DEF_GO != string(content) {
+		t.Errorf("def.go expect:\n%s\ngot:\n%s\n", _EXPECT_FILE_CONFIGURING_INTERNAL_DEF_GO, string(content))
+	}
+
+	// the existing MaxRequestBodySize is kept
+	filename, err = configurator.Configure()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(filename) > 0 {
+		t.Errorf("%s should not be changed again", filename)
+	}
+}
@@ -1,7 +1,12 @@
 package main
 
 import (
+	"bytes"
+	"go/format"
 	"io"
+	"strconv"
+	"strings"
+	"text/template"
 )
 
 var _ FileWriter = new(HttpRequestFileWriter)
@@ -9,13 +14,119 @@ var _ FileWriter = new(HttpRequestFileWriter)
 type HttpRequestFileWriter struct {
 	AppModuleName       string
 	RequestPackageName  string
+	RequestPackagePath  string
 	IsSubRequestPackage bool
 	RequestName         string
 	RequestPrefix       string
+	RequestUrl          string
+	Methods             []*HttpRequestMethod
+	PathParams          []*PathParam
+	Layout              *Layout
 
-	RequestFile         io.Writer
-	RequestGetArgvFile  io.Writer
-	RequestPostArgvFile io.Writer
+	RequestFile     io.Writer
+	RequestTestFile io.Writer
+	// the shared file of multipart limits and file validators, e.g: args/multipart.go.
+	MultipartFile io.Writer
+}
+
+// HttpRequestMethod describes the request method generating on the request
+// type and its argv file, e.g: Get and args/xxxGetArgv.go.
+type HttpRequestMethod struct {
+	RequestPrefix string
+	Method        string
+	HasBody       bool
+	IsMultipart   bool
+	Reply         string
+	PathParams    []*PathParam
+	Layout        *Layout
+	// the argv generated from the request message of rpc, see -proto.
+	Proto *ProtoArgv
+
+	RequestArgvFile  io.Writer
+	RequestReplyFile io.Writer
+}
+
+func (m *HttpRequestMethod) IsJsonReply() bool {
+	return m.Reply == REPLY_JSON
+}
+
+// HasFailure reports whether the method replies the failures of the path
+// parameters or multipart form by failure.ThrowFailureMessage.
+func (m *HttpRequestMethod) HasFailure() bool {
+	return m.IsJsonReply() && (len(m.PathParams) > 0 || m.IsMultipart)
+}
+
+// HasProtoAssertions reports whether the proto argv has fields to assert.
+func (m *HttpRequestMethod) HasProtoAssertions() bool {
+	return len(m.PathParams) > 0 || m.Proto.HasValidators()
+}
+
+// TestQueryString returns the query string used in the generated tests.
+func (m *HttpRequestMethod) TestQueryString() string {
+	if m.Proto != nil {
+		return m.Proto.TestQueryString()
+	}
+	return "?nonce=0a1b2c3d"
+}
+
+// TestBody returns the go string literal of request body used in the
+// generated tests.
+func (m *HttpRequestMethod) TestBody() string {
+	body := `{"text":"hello"}`
+	if m.Proto != nil {
+		body = m.Proto.TestBody()
+	}
+	if strings.Contains(body, "`") {
+		return strconv.Quote(body)
+	}
+	return "`" + body + "`"
+}
+
+func (m *HttpRequestMethod) HasIntPathParams() bool {
+	for _, param := range m.PathParams {
+		if param.IsInt {
+			return true
+		}
+	}
+	return false
+}
+
+// HasMultipartMethods reports whether any method reads the multipart form.
+func (w *HttpRequestFileWriter) HasMultipartMethods() bool {
+	for _, m := range w.Methods {
+		if m.IsMultipart {
+			return true
+		}
+	}
+	return false
+}
+
+// HasJsonReplyMethods reports whether any method replies the reply type in
+// JSON.
+func (w *HttpRequestFileWriter) HasJsonReplyMethods() bool {
+	for _, m := range w.Methods {
+		if m.IsJsonReply() {
+			return true
+		}
+	}
+	return false
+}
+
+func (w *HttpRequestFileWriter) HasFailureMethods() bool {
+	for _, m := range w.Methods {
+		if m.HasFailure() {
+			return true
+		}
+	}
+	return false
+}
+
+// TestRequestUri returns the request uri used in the generated tests.
+func (w *HttpRequestFileWriter) TestRequestUri() string {
+	if len(w.RequestUrl) == 0 {
+		return "/"
+	}
+	return getSampleRequestUri(w.RequestUrl, w.PathParams)
 }
 
 func (w *HttpRequestFileWriter) Write() error {
@@ -26,15 +137,51 @@ func (w *HttpRequestFileWriter) Write() error {
 		return err
 	}
 
-	if w.RequestGetArgvFile != nil {
-		err = HttpRequestFileTemplate.ExecuteTemplate(w.RequestGetArgvFile, TEMPLATE_NAME_REQUEST_GET_ARGV_FILE, w)
+	if w.RequestTestFile != nil {
+		err = HttpRequestFileTemplate.ExecuteTemplate(w.RequestTestFile, TEMPLATE_NAME_REQUEST_TEST_FILE, w)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, m := range w.Methods {
+		if m.RequestArgvFile == nil {
+			continue
+		}
+
+		if m.Proto != nil {
+			err = writeRequestArgvFile(ProtoArgvFileTemplate, m.RequestArgvFile, TEMPLATE_NAME_PROTO_ARGV_FILE, m)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		templateName := TEMPLATE_NAME_REQUEST_QUERY_ARGV_FILE
+		if m.IsMultipart {
+			templateName = TEMPLATE_NAME_MULTIPART_ARGV_FILE
+		} else if m.HasBody {
+			templateName = TEMPLATE_NAME_REQUEST_BODY_ARGV_FILE
+		}
+		err = writeRequestArgvFile(HttpRequestFileTemplate, m.RequestArgvFile, templateName, m)
 		if err != nil {
 			return err
 		}
 	}
 
-	if w.RequestPostArgvFile != nil {
-		err = HttpRequestFileTemplate.ExecuteTemplate(w.RequestPostArgvFile, TEMPLATE_NAME_REQUEST_POST_ARGV_FILE, w)
+	for _, m := range w.Methods {
+		if m.RequestReplyFile == nil {
+			continue
+		}
+
+		err = HttpRequestFileTemplate.ExecuteTemplate(m.RequestReplyFile, TEMPLATE_NAME_REQUEST_REPLY_FILE, m)
+		if err != nil {
+			return err
+		}
+	}
+
+	if w.MultipartFile != nil {
+		err = MultipartFileTemplate.Execute(w.MultipartFile, w)
 		if err != nil {
 			return err
 		}
@@ -42,3 +189,21 @@ func (w *HttpRequestFileWriter) Write() error {
 
 	return nil
 }
+
+// writeRequestArgvFile formats the argv file to align the path parameter
+// fields with the others.
+func writeRequestArgvFile(tmpl *template.Template, w io.Writer, templateName string, m *HttpRequestMethod) error {
+	var buf bytes.Buffer
+
+	err := tmpl.ExecuteTemplate(&buf, templateName, m)
+	if err != nil {
+		return err
+	}
+
+	content, err := format.Source(buf.Bytes())
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(content)
+	return err
+}
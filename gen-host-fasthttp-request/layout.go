@@ -0,0 +1,217 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/token"
+	"os"
+	"path"
+	"strings"
+	"unicode"
+)
+
+const (
+	LAYOUT_CONFIG_FILE_NAME string = ".gen-host-fasthttp-request.json"
+
+	LAYOUT_OPT_HANDLER_DIR   string = "handler-dir"
+	LAYOUT_OPT_ARGS_DIR      string = "args-dir"
+	LAYOUT_OPT_REPLY_DIR     string = "reply-dir"
+	LAYOUT_OPT_WEBSOCKET_DIR string = "websocket-dir"
+	LAYOUT_OPT_SSE_DIR       string = "sse-dir"
+	LAYOUT_OPT_FILE_NAMING   string = "naming"
+	LAYOUT_OPT_IMPORT_ALIAS  string = "import-alias"
+
+	FILE_NAMING_CAMEL_CASE string = "camelCase"
+	FILE_NAMING_SNAKE_CASE string = "snake_case"
+
+	DEFAULT_IMPORT_ALIAS string = "."
+)
+
+// the imports and local variables of the generated request file.
+var reservedPackageNames = map[string]bool{
+	"argv":      true,
+	"ctx":       true,
+	"err":       true,
+	"form":      true,
+	"r":         true,
+	"result":    true,
+	"sp":        true,
+	"app":       true,
+	"bufio":     true,
+	"context":   true,
+	"failure":   true,
+	"fasthttp":  true,
+	"httparg":   true,
+	"log":       true,
+	"response":  true,
+	"tracing":   true,
+	"websocket": true,
+}
+
+// Layout describes where the files are generated and how they are named.
+// The directories are relative to the project root, e.g: internal/api.
+type Layout struct {
+	HandlerDir   string `json:"handlerDir"`
+	ArgsDir      string `json:"argsDir"`
+	ReplyDir     string `json:"replyDir"`
+	WebsocketDir string `json:"websocketDir"`
+	SseDir       string `json:"sseDir"`
+	FileNaming   string `json:"fileNaming"`
+	// the alias of the handler package imported by the RequestManager file,
+	// "." for dot import and "" for no alias.
+	ImportAlias string `json:"importAlias"`
+}
+
+func newDefaultLayout() *Layout {
+	return &Layout{
+		HandlerDir:   HANDLER_MODULE_NAME,
+		ArgsDir:      HANDLER_ARGS_DIR_PATH,
+		ReplyDir:     HANDLER_REPLY_DIR_PATH,
+		WebsocketDir: WEBSOCKET_APP_DIR_PATH,
+		SseDir:       SSE_APP_DIR_PATH,
+		FileNaming:   FILE_NAMING_CAMEL_CASE,
+		ImportAlias:  DEFAULT_IMPORT_ALIAS,
+	}
+}
+
+// loadLayout loads the layout from the config file, the options absent from
+// the file keep their defaults. It returns the default layout if the file
+// does not exist.
+func loadLayout(filename string) (*Layout, error) {
+	layout := newDefaultLayout()
+
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return layout, nil
+		}
+		return nil, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(layout); err != nil {
+		return nil, fmt.Errorf("invalid layout config '%s': %v", filename, err)
+	}
+	return layout, nil
+}
+
+// SetOption sets the layout option by its flag name, e.g: handler-dir.
+func (l *Layout) SetOption(name, value string) bool {
+	switch name {
+	case LAYOUT_OPT_HANDLER_DIR:
+		l.HandlerDir = value
+	case LAYOUT_OPT_ARGS_DIR:
+		l.ArgsDir = value
+	case LAYOUT_OPT_REPLY_DIR:
+		l.ReplyDir = value
+	case LAYOUT_OPT_WEBSOCKET_DIR:
+		l.WebsocketDir = value
+	case LAYOUT_OPT_SSE_DIR:
+		l.SseDir = value
+	case LAYOUT_OPT_FILE_NAMING:
+		l.FileNaming = value
+	case LAYOUT_OPT_IMPORT_ALIAS:
+		l.ImportAlias = value
+	default:
+		return false
+	}
+	return true
+}
+
+func (l *Layout) Validate() error {
+	dirs := []struct {
+		name  string
+		value *string
+	}{
+		{LAYOUT_OPT_HANDLER_DIR, &l.HandlerDir},
+		{LAYOUT_OPT_ARGS_DIR, &l.ArgsDir},
+		{LAYOUT_OPT_REPLY_DIR, &l.ReplyDir},
+		{LAYOUT_OPT_WEBSOCKET_DIR, &l.WebsocketDir},
+		{LAYOUT_OPT_SSE_DIR, &l.SseDir},
+	}
+	for _, dir := range dirs {
+		value := path.Clean(strings.ReplaceAll(*dir.value, "\\", "/"))
+		if len(*dir.value) == 0 || value == "." || path.IsAbs(value) || value == ".." || strings.HasPrefix(value, "../") {
+			return fmt.Errorf("invalid %s '%s', should be a relative directory in the project", dir.name, *dir.value)
+		}
+		*dir.value = value
+	}
+
+	// the directories of go packages
+	for _, dir := range []string{l.HandlerDir, l.ArgsDir, l.ReplyDir} {
+		if name := path.Base(dir); !token.IsIdentifier(name) {
+			return fmt.Errorf("invalid directory '%s', '%s' is not a valid package name", dir, name)
+		}
+	}
+	// the argv and reply packages are referred in the request methods
+	for _, dir := range []string{l.ArgsDir, l.ReplyDir} {
+		if name := path.Base(dir); reservedPackageNames[name] {
+			return fmt.Errorf("invalid directory '%s', '%s' conflicts with the identifier of request file", dir, name)
+		}
+	}
+	if l.ArgsPackageName() == l.ReplyPackageName() {
+		return fmt.Errorf("the package names of %s '%s' and %s '%s' should be different", LAYOUT_OPT_ARGS_DIR, l.ArgsDir, LAYOUT_OPT_REPLY_DIR, l.ReplyDir)
+	}
+
+	switch l.FileNaming {
+	case FILE_NAMING_CAMEL_CASE, FILE_NAMING_SNAKE_CASE:
+	default:
+		return fmt.Errorf("unsupported %s '%s', should be one of %s|%s", LAYOUT_OPT_FILE_NAMING, l.FileNaming, FILE_NAMING_CAMEL_CASE, FILE_NAMING_SNAKE_CASE)
+	}
+
+	if l.ImportAlias != "" && l.ImportAlias != "." && !token.IsIdentifier(l.ImportAlias) {
+		return fmt.Errorf("invalid %s '%s'", LAYOUT_OPT_IMPORT_ALIAS, l.ImportAlias)
+	}
+	return nil
+}
+
+func (l *Layout) HandlerPackageName() string {
+	return path.Base(l.HandlerDir)
+}
+
+func (l *Layout) ArgsPackageName() string {
+	return path.Base(l.ArgsDir)
+}
+
+func (l *Layout) ReplyPackageName() string {
+	return path.Base(l.ReplyDir)
+}
+
+// IsHandlerPackage reports whether the package name qualifying the request
+// type in RequestManager refers to the handler package, e.g: api of
+// *api.OrderRequest when the import alias is api.
+func (l *Layout) IsHandlerPackage(name string) bool {
+	switch l.ImportAlias {
+	case ".":
+		return false
+	case "":
+		return name == l.HandlerPackageName()
+	}
+	return name == l.ImportAlias
+}
+
+// FileName converts the camelCase file name by the file naming style, e.g:
+// orderPutArgv to order_put_argv.
+func (l *Layout) FileName(name string) string {
+	if l.FileNaming != FILE_NAMING_SNAKE_CASE {
+		return name
+	}
+
+	var (
+		runes = []rune(name)
+		sb    strings.Builder
+	)
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) {
+			prev := runes[i-1]
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) ||
+				(unicode.IsUpper(prev) && i+1 < len(runes) && unicode.IsLower(runes[i+1])) {
+				sb.WriteRune('_')
+			}
+		}
+		sb.WriteRune(unicode.ToLower(r))
+	}
+	return sb.String()
+}
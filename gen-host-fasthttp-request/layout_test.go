@@ -0,0 +1,219 @@
+package main
+
+import (
+	"go/parser"
+	"go/token"
+	"os"
+	"path"
+	"strings"
+	"testing"
+)
+
+func TestLayout_FileName(t *testing.T) {
+	cases := []struct {
+		naming   string
+		name     string
+		expected string
+	}{
+		{FILE_NAMING_CAMEL_CASE, "orderPutArgv", "orderPutArgv"},
+		{FILE_NAMING_SNAKE_CASE, "orderPutArgv", "order_put_argv"},
+		{FILE_NAMING_SNAKE_CASE, "xmlRequest", "xml_request"},
+		{FILE_NAMING_SNAKE_CASE, "healthCheckRequest", "health_check_request"},
+		{FILE_NAMING_SNAKE_CASE, "oauth2TokenRequest", "oauth2_token_request"},
+		{FILE_NAMING_SNAKE_CASE, "getHTTPStatusArgv", "get_http_status_argv"},
+		{FILE_NAMING_SNAKE_CASE, "multipart", "multipart"},
+	}
+
+	for _, c := range cases {
+		layout := &Layout{FileNaming: c.naming}
+		if got := layout.FileName(c.name); got != c.expected {
+			t.Errorf("%s %q: expect %q, got %q", c.naming, c.name, c.expected, got)
+		}
+	}
+}
+
+func TestLoadLayout(t *testing.T) {
+	tmp := t.TempDir()
+
+	// the default layout if the config file does not exist
+	{
+		layout, err := loadLayout(path.Join(tmp, LAYOUT_CONFIG_FILE_NAME))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if *layout != *newDefaultLayout() {
+			t.Errorf("layout expect %+v, got %+v", *newDefaultLayout(), *layout)
+		}
+	}
+
+	assert(t,
+		os.WriteFile(path.Join(tmp, LAYOUT_CONFIG_FILE_NAME), []byte(`{
+	"handlerDir": "internal/api/",
+	"fileNaming": "snake_case",
+	"importAlias": "api"
+}`), 0644),
+	)
+	layout, err := loadLayout(path.Join(tmp, LAYOUT_CONFIG_FILE_NAME))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !layout.SetOption(LAYOUT_OPT_ARGS_DIR, "params") {
+		t.Errorf("should set option '%s'", LAYOUT_OPT_ARGS_DIR)
+	}
+	if layout.SetOption("file", "app.go") {
+		t.Errorf("should not set option 'file'")
+	}
+	if err := layout.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	expected := Layout{
+		HandlerDir:   "internal/api",
+		ArgsDir:      "params",
+		ReplyDir:     HANDLER_REPLY_DIR_PATH,
+		WebsocketDir: WEBSOCKET_APP_DIR_PATH,
+		SseDir:       SSE_APP_DIR_PATH,
+		FileNaming:   FILE_NAMING_SNAKE_CASE,
+		ImportAlias:  "api",
+	}
+	if *layout != expected {
+		t.Errorf("layout expect %+v, got %+v", expected, *layout)
+	}
+
+	// unknown option
+	assert(t,
+		os.WriteFile(path.Join(tmp, LAYOUT_CONFIG_FILE_NAME), []byte(`{"handler": "api"}`), 0644),
+	)
+	if _, err := loadLayout(path.Join(tmp, LAYOUT_CONFIG_FILE_NAME)); err == nil {
+		t.Errorf("should get error when the config has unknown option")
+	}
+}
+
+func TestLayout_Validate(t *testing.T) {
+	cases := []struct {
+		name  string
+		value string
+	}{
+		{LAYOUT_OPT_HANDLER_DIR, ""},
+		{LAYOUT_OPT_HANDLER_DIR, "/handler"},
+		{LAYOUT_OPT_HANDLER_DIR, "../handler"},
+		{LAYOUT_OPT_HANDLER_DIR, "internal/http-api"},
+		{LAYOUT_OPT_ARGS_DIR, "."},
+		{LAYOUT_OPT_ARGS_DIR, "argv"},
+		{LAYOUT_OPT_REPLY_DIR, "internal/args"},
+		{LAYOUT_OPT_FILE_NAMING, "kebab-case"},
+		{LAYOUT_OPT_IMPORT_ALIAS, "my-api"},
+	}
+
+	for _, c := range cases {
+		layout := newDefaultLayout()
+		layout.SetOption(c.name, c.value)
+		if err := layout.Validate(); err == nil {
+			t.Errorf("%s %q: should get error", c.name, c.value)
+		}
+	}
+}
+
+func TestGenerateRequestFiles_Layout(t *testing.T) {
+	tmp := t.TempDir()
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert(t, os.Chdir(tmp))
+	defer os.Chdir(wd)
+
+	defaultLayout, defaultAppModuleName, defaultGofile := layout, appModuleName, gofile
+	defer func() {
+		layout, appModuleName, gofile = defaultLayout, defaultAppModuleName, defaultGofile
+	}()
+	layout = &Layout{
+		HandlerDir:   "internal/api",
+		ArgsDir:      "params",
+		ReplyDir:     HANDLER_REPLY_DIR_PATH,
+		WebsocketDir: "ws",
+		SseDir:       SSE_APP_DIR_PATH,
+		FileNaming:   FILE_NAMING_SNAKE_CASE,
+		ImportAlias:  "api",
+	}
+	appModuleName = "host-fasthttp-request-demo"
+	gofile = "app.go"
+
+	source := strings.ReplaceAll(`package main
+
+type RequestManager struct {
+	*api.OrderRequest     ”url:"/order/{id:int}" @methods:"GET,PUT"”
+	*payment.PayinRequest ”url:"/payment/payin"  @methods:"POST"”
+	*api.ChatRequest      ”url:"/chat"           @hijack:"websocket"”
+}
+`, "”", "`")
+	assert(t,
+		os.WriteFile(gofile, []byte(source), 0644),
+	)
+
+	n, subPackages, err := generateRequestFiles(token.NewFileSet(), parseRequestManager(t, source), layout.HandlerDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 3 {
+		t.Errorf("generated files expect %d, got %d", 3, n)
+	}
+
+	for _, c := range []struct {
+		filename string
+		contains []string
+	}{
+		{"internal/api/order_request.go", []string{
+			"package api\n",
+			`"host-fasthttp-request-demo/internal/api/params"`,
+			"argv := params.OrderGetArgv{}",
+		}},
+		{"internal/api/order_request_test.go", []string{"package api\n"}},
+		{"internal/api/params/order_get_argv.go", []string{"package params\n", "type OrderGetArgv struct"}},
+		{"internal/api/params/order_put_argv.go", []string{"package params\n", "type OrderPutArgv struct"}},
+		{"internal/api/payment/payin_request.go", []string{
+			"package payment\n",
+			`"host-fasthttp-request-demo/internal/api/payment/params"`,
+		}},
+		{"internal/api/payment/params/payin_post_argv.go", []string{"type PayinPostArgv struct"}},
+		{"internal/api/chat_request.go", []string{
+			`"host-fasthttp-request-demo/internal/api/params"`,
+			`"host-fasthttp-request-demo/internal/api/ws/chat"`,
+		}},
+		{"internal/api/params/chat_get_argv.go", []string{"type ChatGetArgv struct"}},
+		{"internal/api/ws/chat/app.go", []string{"package chat\n"}},
+	} {
+		content, err := os.ReadFile(c.filename)
+		if err != nil {
+			t.Errorf("%s should be generated", c.filename)
+			continue
+		}
+		for _, s := range c.contains {
+			if !strings.Contains(string(content), s) {
+				t.Errorf("%s should contain %q, got:\n%s\n", c.filename, s, string(content))
+			}
+		}
+	}
+
+	// import the handler package with alias
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, gofile, nil, parser.ParseComments)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert(t,
+		importHandlerModulePath(fset, f, subPackages),
+	)
+	content, err := os.ReadFile(gofile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{
+		`api "host-fasthttp-request-demo/internal/api"`,
+		`"host-fasthttp-request-demo/internal/api/payment"`,
+	} {
+		if !strings.Contains(string(content), s) {
+			t.Errorf("app.go should contain %q, got:\n%s\n", s, string(content))
+		}
+	}
+}
@@ -2,11 +2,13 @@ package main
 
 import (
 	"bufio"
+	"bytes"
 	"flag"
 	"fmt"
 	"go/ast"
+	"go/format"
 	"go/parser"
-	"go/printer"
+	"go/scanner"
 	"go/token"
 	"io"
 	"os"
@@ -14,6 +16,7 @@ import (
 	"path"
 	"path/filepath"
 	"reflect"
+	"sort"
 	"strconv"
 	"strings"
 	"unicode"
@@ -27,14 +30,46 @@ const (
 	REQUEST_TYPE_SUFFIX       string = "Request"
 	HANDLER_MODULE_NAME       string = "handler"
 	HANDLER_ARGS_DIR_PATH     string = "args"
+	HANDLER_REPLY_DIR_PATH    string = "reply"
 	WEBSOCKET_APP_DIR_PATH    string = "websocket"
 	WEBSOCKET_APP_FILE_NAME   string = "app"
-
-	TAG_SKIP_OPT_NAME   string = "@skip"
-	TAG_HIJACK_OPT_NAME string = "@hijack"
+	SSE_APP_DIR_PATH          string = "sse"
+	SSE_APP_FILE_NAME         string = "app"
+	SSE_BROKER_FILE_NAME      string = "broker"
+	TEST_FILE_SUFFIX          string = "_test"
+	MULTIPART_FILE_NAME       string = "multipart"
+	INTERNAL_MODULE_NAME      string = "internal"
+
+	HOST_TYPE_NAME                  string = "Host"
+	HOST_INIT_FUNC_NAME             string = "Init"
+	HOST_MAX_REQUEST_BODY_SIZE_NAME string = "MaxRequestBodySize"
+
+	GENERATE_STATUS_OK      string = "ok"
+	GENERATE_STATUS_SKIPPED string = "skipped"
+
+	TAG_URL_NAME         string = "url"
+	TAG_SKIP_OPT_NAME    string = "@skip"
+	TAG_HIJACK_OPT_NAME  string = "@hijack"
+	TAG_METHODS_OPT_NAME string = "@methods"
+	TAG_BODY_OPT_NAME    string = "@body"
+	TAG_REPLY_OPT_NAME   string = "@reply"
+
+	TAG_PROTOCOL_OPT_NAME string = "@protocol"
+	TAG_MESSAGES_OPT_NAME string = "@messages"
 
 	HIJACK_NONE      string = ""
 	HIJACK_WEBSOCKET string = "websocket"
+	HIJACK_SSE       string = "sse"
+
+	BODY_JSON      string = "json"
+	BODY_MULTIPART string = "multipart"
+
+	REPLY_TEXT string = "text"
+	REPLY_JSON string = "json"
+
+	DEFAULT_REQUEST_METHODS string = "GET,POST"
+
+	HOST_APP_HANDLER_GENERATOR string = "gen-host-app-handler"
 )
 
 var (
@@ -42,10 +77,42 @@ var (
 	gofile        string
 	workdir       string
 	appModuleName string
+	methods       string
+	list          bool
+	clientDir     string
+	layoutConfig  string
+	protoFile     string
+
+	defaultRequestMethods []string
+	layout                = newDefaultLayout()
+	// the requests resolved from -proto file by request names.
+	protoRequests = make(map[string]*ProtoRequest)
+
+	// the supported request methods and whether the request has body.
+	requestMethodTable = map[string]bool{
+		"GET":    false,
+		"HEAD":   false,
+		"DELETE": false,
+		"POST":   true,
+		"PUT":    true,
+		"PATCH":  true,
+	}
 )
 
 func init() {
 	flag.StringVar(&gofile, "file", "", "input file")
+	flag.StringVar(&methods, "methods", DEFAULT_REQUEST_METHODS, "default request methods")
+	flag.BoolVar(&list, "list", false, "list the routes of RequestManager and check the route conflicts")
+	flag.StringVar(&clientDir, "client", "", "output directory of the generated client package")
+	flag.StringVar(&layoutConfig, "config", LAYOUT_CONFIG_FILE_NAME, "layout config file")
+	flag.StringVar(&protoFile, "proto", "", "proto file of the services with google.api.http rules")
+	flag.String(LAYOUT_OPT_HANDLER_DIR, HANDLER_MODULE_NAME, "handler root directory")
+	flag.String(LAYOUT_OPT_ARGS_DIR, HANDLER_ARGS_DIR_PATH, "argv directory under the handler package")
+	flag.String(LAYOUT_OPT_REPLY_DIR, HANDLER_REPLY_DIR_PATH, "reply directory under the handler package")
+	flag.String(LAYOUT_OPT_WEBSOCKET_DIR, WEBSOCKET_APP_DIR_PATH, "websocket app directory under the handler package")
+	flag.String(LAYOUT_OPT_SSE_DIR, SSE_APP_DIR_PATH, "sse app directory under the handler package")
+	flag.String(LAYOUT_OPT_FILE_NAMING, FILE_NAMING_CAMEL_CASE, "file naming style, camelCase or snake_case")
+	flag.String(LAYOUT_OPT_IMPORT_ALIAS, DEFAULT_IMPORT_ALIAS, "import alias of the handler package")
 }
 
 func main() {
@@ -54,11 +121,42 @@ func main() {
 	)
 	flag.Parse()
 
+	// resolve the client directory before changing the work directory
+	if len(clientDir) > 0 {
+		clientDir, err = filepath.Abs(clientDir)
+		if err != nil {
+			throw(err.Error())
+			exit(1)
+			return
+		}
+	}
+	if len(protoFile) > 0 {
+		protoFile, err = filepath.Abs(protoFile)
+		if err != nil {
+			throw(err.Error())
+			exit(1)
+			return
+		}
+	}
+	// the layout config specified is relative to the work directory,
+	// otherwise it is in the project directory
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == "config" {
+			layoutConfig, err = filepath.Abs(layoutConfig)
+		}
+	})
+	if err != nil {
+		throw(err.Error())
+		exit(1)
+		return
+	}
+
 	if dir, file := path.Split(gofile); dir != "." {
 		workdir, err = os.Getwd()
 		if err != nil {
 			throw("Cannot get work directory.")
 			exit(1)
+			return
 		}
 		os.Chdir(dir)
 		gofile = file
@@ -69,14 +167,50 @@ func main() {
 		if gofile == "" {
 			throw("No file to parse.")
 			exit(1)
+			return
 		}
 	}
 
+	// parse default request methods
+	defaultRequestMethods, err = parseRequestMethods(methods)
+	if err != nil {
+		throw(err.Error())
+		exit(1)
+		return
+	}
+
 	// get module name
 	appModuleName, err = getAppModuleName()
 	if err != nil {
 		throw(err.Error())
 		exit(1)
+		return
+	}
+
+	// resolve layout, the flags take precedence over the config file
+	layout, err = loadLayout(layoutConfig)
+	if err != nil {
+		throw(err.Error())
+		exit(1)
+		return
+	}
+	flag.Visit(func(f *flag.Flag) {
+		layout.SetOption(f.Name, f.Value.String())
+	})
+	if err = layout.Validate(); err != nil {
+		throw(err.Error())
+		exit(1)
+		return
+	}
+
+	// add the requests of proto services to RequestManager
+	if len(protoFile) > 0 && !list && len(clientDir) == 0 {
+		err = mergeProtoRequests(gofile, protoFile)
+		if err != nil {
+			scanner.PrintError(os.Stderr, err)
+			exit(1)
+			return
+		}
 	}
 
 	// parse app.go to AST
@@ -85,9 +219,11 @@ func main() {
 	if err != nil {
 		throw(err.Error())
 		exit(1)
+		return
 	}
 
 	// resolve AST
+	var generateErr error
 	for _, node := range f.Decls {
 		switch realDecl := node.(type) {
 		case *ast.GenDecl:
@@ -110,11 +246,28 @@ func main() {
 						switch typeSpec.Type.(type) {
 						case *ast.StructType:
 							structType := typeSpec.Type.(*ast.StructType)
-							count, subPackages, err = generateRequestFiles(structType, HANDLER_MODULE_NAME)
-							if err != nil {
-								throw(err.Error())
-								exit(1)
+							if list {
+								err = listRoutes(os.Stdout, structType, layout.HandlerDir)
+								if err != nil {
+									throw(err.Error())
+									exit(1)
+									return
+								}
+								exit(0)
+								return
+							}
+							if len(clientDir) > 0 {
+								err = generateClientFiles(structType, layout.HandlerDir, clientDir)
+								if err != nil {
+									throw(err.Error())
+									exit(1)
+									return
+								}
+								exit(0)
+								return
 							}
+							// report the errors after importing the generated requests
+							count, subPackages, generateErr = generateRequestFiles(fset, structType, layout.HandlerDir)
 						}
 
 						if count > 0 {
@@ -123,8 +276,24 @@ func main() {
 							if err != nil {
 								throw(err.Error())
 								exit(1)
+								return
 							}
 						}
+
+						// limit the request body size of the multipart requests
+						configurator := &HostConfigurator{
+							InternalDir: INTERNAL_MODULE_NAME,
+							HandlerDir:  layout.HandlerDir,
+						}
+						filename, err := configurator.Configure()
+						if err != nil {
+							throw(err.Error())
+							exit(1)
+							return
+						}
+						if len(filename) > 0 {
+							fmt.Printf("configuring '%s' ...ok (%s)\n", filename, HOST_MAX_REQUEST_BODY_SIZE_NAME)
+						}
 						break
 					}
 				}
@@ -132,14 +301,22 @@ func main() {
 		}
 	}
 
+	if generateErr != nil {
+		scanner.PrintError(os.Stderr, generateErr)
+		exit(1)
+		return
+	}
+
 	if err := execCmd("go", "mod", "tidy"); err != nil {
 		throw(err.Error())
 		exit(1)
+		return
 	}
 
 	if err := execCmd("gofmt", "-w", gofile); err != nil {
 		throw(err.Error())
 		exit(1)
+		return
 	}
 	exit(0)
 }
@@ -155,232 +332,509 @@ func exit(code int) {
 	osExit(code)
 }
 
-func generateRequestFiles(structType *ast.StructType, handlerDir string) (n int, subPackages []string, err error) {
-	type fileOpt struct {
-		packageName string
-		hijackType  string
-		requestName string
-	}
+// requestFilePlan describes the request resolved from the RequestManager
+// field, and how its files are generated.
+type requestFilePlan struct {
+	// the position of RequestManager field, e.g: app.go:12:2.
+	pos token.Position
+	// the request type name, e.g: payment.PayinRequest.
+	typename    string
+	packageName string
+	hijackType  string
+	requestName string
+	url         string
+	methods     []string
+	body        string
+	reply       string
+	// the reply overriding the default of the request methods.
+	replyOverrides map[string]string
+	pathParams     []*PathParam
+	// the protocol and messages of websocket app.
+	protocol string
+	messages []*WebsocketMessage
+}
+
+// generateRequestFiles plans the request files of RequestManager fields and
+// generates them in the order of request type names. It keeps generating the
+// other requests when a request failed, and returns all the errors as
+// scanner.ErrorList.
+func generateRequestFiles(fset *token.FileSet, structType *ast.StructType, handlerDir string) (n int, subPackages []string, err error) {
+	plans, errs := resolveRequestFilePlans(fset, structType)
 
 	var (
-		handlerFileMap    = make(map[string]fileOpt, len(structType.Fields.List))
+		count             int
 		handlerPackageMap = make(map[string]bool)
 	)
+	for _, plan := range plans {
+		fmt.Printf("generating '%s' ...", plan.typename)
 
-	for _, field := range structType.Fields.List {
-		var (
-			opt fileOpt
-		)
+		status, err := generateRequestFile(plan, handlerDir)
+		if err != nil {
+			fmt.Println("failed")
+			errs.Add(plan.pos, fmt.Sprintf("cannot generate '%s': %v", plan.typename, err))
+			continue
+		}
+		fmt.Println(status)
+		if status != GENERATE_STATUS_SKIPPED {
+			count++
+		}
 
-		// resolve tag
-		if field.Tag != nil && field.Tag.Kind == token.STRING {
-			tagLiteral, err := strconv.Unquote(field.Tag.Value)
+		// generate the message handlers of the websocket app
+		if status == GENERATE_STATUS_OK && len(plan.messages) > 0 {
+			err = generateWebsocketMessageHandlers(getWebsocketAppFileName(plan, handlerDir))
 			if err != nil {
-				tagLiteral = field.Tag.Value
+				errs.Add(plan.pos, fmt.Sprintf("cannot generate message handlers of '%s': %v", plan.typename, err))
 			}
-			tag := reflect.StructTag(tagLiteral)
-
-			// has @skip
-			{
-				val, ok := tag.Lookup(TAG_SKIP_OPT_NAME)
-				if ok {
-					if len(val) == 0 || val == "on" {
-						continue
-					}
+		}
+
+		// collect sub package name as wall
+		if len(plan.packageName) > 0 {
+			handlerPackageMap[plan.packageName] = true
+		}
+	}
+
+	// export subPackages
+	if len(handlerPackageMap) > 0 {
+		subPackages = make([]string, 0, len(handlerPackageMap))
+		for k := range handlerPackageMap {
+			subPackages = append(subPackages, k)
+		}
+		sort.Strings(subPackages)
+	}
+
+	errs.Sort()
+	return count, subPackages, errs.Err()
+}
+
+// resolveRequestFilePlans resolves the plans of RequestManager fields sorted
+// by the request type names. The fields with @skip and the duplicated
+// requests are ignored.
+func resolveRequestFilePlans(fset *token.FileSet, structType *ast.StructType) ([]*requestFilePlan, scanner.ErrorList) {
+	var (
+		plans   = make([]*requestFilePlan, 0, len(structType.Fields.List))
+		visited = make(map[string]bool)
+		errs    scanner.ErrorList
+	)
+
+	for _, field := range structType.Fields.List {
+		plan, err := resolveRequestFilePlan(field)
+		if err != nil {
+			errs.Add(fset.Position(field.Pos()), err.Error())
+			continue
+		}
+		if plan == nil {
+			continue
+		}
+		// duplicated request name?
+		if visited[plan.typename] {
+			continue
+		}
+		visited[plan.typename] = true
+
+		plan.pos = fset.Position(field.Pos())
+		plans = append(plans, plan)
+	}
+
+	sort.Slice(plans, func(i, j int) bool {
+		return plans[i].typename < plans[j].typename
+	})
+	return plans, errs
+}
+
+// resolveRequestFilePlan resolves the plan of RequestManager field. It returns
+// nil if the field should not be generated.
+func resolveRequestFilePlan(field *ast.Field) (*requestFilePlan, error) {
+	plan := &requestFilePlan{
+		methods: defaultRequestMethods,
+		body:    BODY_JSON,
+		reply:   REPLY_TEXT,
+	}
+
+	// resolve tag
+	if field.Tag != nil && field.Tag.Kind == token.STRING {
+		tagLiteral, err := strconv.Unquote(field.Tag.Value)
+		if err != nil {
+			tagLiteral = field.Tag.Value
+		}
+		tag := reflect.StructTag(tagLiteral)
+
+		// has @skip
+		{
+			val, ok := tag.Lookup(TAG_SKIP_OPT_NAME)
+			if ok {
+				if len(val) == 0 || val == "on" {
+					return nil, nil
+				}
+			}
+		}
+		// has path parameters?
+		{
+			val, ok := tag.Lookup(TAG_URL_NAME)
+			if ok {
+				plan.url = val
+				plan.pathParams, err = parsePathParams(val)
+				if err != nil {
+					return nil, err
 				}
 			}
-			// has @hijack?
-			{
-				val, ok := tag.Lookup(TAG_HIJACK_OPT_NAME)
-				if ok {
-					opt.hijackType = val
+		}
+		// has @hijack?
+		{
+			val, ok := tag.Lookup(TAG_HIJACK_OPT_NAME)
+			if ok {
+				switch val {
+				case HIJACK_NONE, HIJACK_WEBSOCKET, HIJACK_SSE:
+					plan.hijackType = val
+				default:
+					return nil, fmt.Errorf("unsupported hijack type '%s'", val)
 				}
 			}
 		}
+		// has @methods?
+		{
+			val, ok := tag.Lookup(TAG_METHODS_OPT_NAME)
+			if ok {
+				plan.methods, err = parseRequestMethods(val)
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+		// has @body?
+		{
+			val, ok := tag.Lookup(TAG_BODY_OPT_NAME)
+			if ok {
+				switch val {
+				case BODY_JSON, BODY_MULTIPART:
+					plan.body = val
+				default:
+					return nil, fmt.Errorf("unsupported request body '%s'", val)
+				}
+			}
+		}
+		// has @reply?
+		{
+			val, ok := tag.Lookup(TAG_REPLY_OPT_NAME)
+			if ok {
+				plan.reply, plan.replyOverrides, err = parseReplyOption(val)
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+		// has @protocol or @messages?
+		{
+			protocol, hasProtocol := tag.Lookup(TAG_PROTOCOL_OPT_NAME)
+			messages, hasMessages := tag.Lookup(TAG_MESSAGES_OPT_NAME)
+			if (hasProtocol || hasMessages) && plan.hijackType != HIJACK_WEBSOCKET {
+				return nil, fmt.Errorf("%s and %s require %s:\"%s\"", TAG_PROTOCOL_OPT_NAME, TAG_MESSAGES_OPT_NAME, TAG_HIJACK_OPT_NAME, HIJACK_WEBSOCKET)
+			}
+			plan.protocol, err = parseWebsocketProtocol(protocol)
+			if err != nil {
+				return nil, err
+			}
+			if hasMessages {
+				plan.messages, err = parseWebsocketMessages(messages, plan.protocol)
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	star, ok := field.Type.(*ast.StarExpr)
+	if !ok {
+		return nil, nil
+	}
+	switch x := star.X.(type) {
+	case *ast.SelectorExpr:
+		ident, ok := x.X.(*ast.Ident)
+		if !ok {
+			return nil, fmt.Errorf("cannot resolve request package name of '%s'", x.Sel.Name)
+		}
+		plan.requestName = x.Sel.Name
+		plan.typename = plan.requestName
+		// the request type qualified by the handler package
+		if !layout.IsHandlerPackage(ident.Name) {
+			plan.packageName = ident.Name
+			plan.typename = plan.packageName + "." + plan.requestName
+		}
+	case *ast.Ident:
+		plan.requestName = x.Name
+		plan.typename = x.Name
+	default:
+		return nil, nil
+	}
 
-		switch field.Type.(type) {
-		case *ast.StarExpr:
-			star := field.Type.(*ast.StarExpr)
+	if len(getHandlerFileName(plan.requestName)) == 0 {
+		return nil, nil
+	}
+	return plan, nil
+}
 
-			switch star.X.(type) {
-			case *ast.SelectorExpr:
-				sel := star.X.(*ast.SelectorExpr)
+// generateRequestFile generates the files of the request plan in memory and
+// commits them together. It returns the generating status, e.g: ok,
+// skipped or ok (added Delete).
+func generateRequestFile(plan *requestFilePlan, handlerDir string) (string, error) {
+	var (
+		packageDir   = handlerDir
+		packageName  = layout.HandlerPackageName()
+		filename     = getHandlerFileName(plan.requestName)
+		isSubPackage = false
+
+		writer FileWriter
+		files  = new(GeneratedFileSet)
+		// the generated content merging into the existing request file
+		mergingContent *bytes.Buffer
+		// the generated content merging into the existing request test file
+		mergingTestContent *bytes.Buffer
+	)
 
-				opt.requestName = sel.Sel.Name
-				// parse package name
-				{
-					ident, ok := sel.X.(*ast.Ident)
-					if !ok {
-						// NOTE: it have not to be happen.
-						throw(fmt.Sprintf("cannot resolve request package name at '%d'", star.X.Pos()))
-						exit(1)
-					}
-					opt.packageName = ident.Name
-				}
+	if len(plan.packageName) > 0 {
+		packageDir = path.Join(handlerDir, plan.packageName)
+		packageName = plan.packageName
+		isSubPackage = true
+	}
 
-				// generate requestTypename
-				requestTypename := opt.packageName + "." + opt.requestName
-				// duplicated request name?
-				if _, ok := handlerFileMap[requestTypename]; ok {
-					continue
-				}
-				handlerFileMap[requestTypename] = opt
-			case *ast.Ident:
-				ident := star.X.(*ast.Ident)
+	requestFile, err := files.Create(packageDir, filename)
+	if err != nil {
+		if !os.IsExist(err) {
+			return "", err
+		}
+		mergingContent = new(bytes.Buffer)
+		requestFile = mergingContent
+	}
+
+	var (
+		requestPrefix            = strings.TrimSuffix(plan.requestName, REQUEST_TYPE_SUFFIX)
+		requestArgFilenamePrefix = normalizeFileName(requestPrefix)
+		requestPackagePath       = filepath.ToSlash(packageDir)
+	)
 
-				opt.requestName = ident.Name
+	switch plan.hijackType {
+	case HIJACK_NONE:
+		requestMethods := make([]*HttpRequestMethod, 0, len(plan.methods))
+		for _, method := range plan.methods {
+			m := &HttpRequestMethod{
+				RequestPrefix: requestPrefix,
+				Method:        getRequestMethodName(method),
+				HasBody:       requestMethodTable[method],
+				PathParams:    plan.pathParams,
+				Layout:        layout,
+			}
+			m.IsMultipart = m.HasBody && plan.body == BODY_MULTIPART
+			if request, ok := protoRequests[plan.typename]; ok {
+				m.Proto = request.Argvs[method]
+				m.IsMultipart = m.IsMultipart && m.Proto == nil
+			}
+			m.Reply = plan.reply
+			if reply, ok := plan.replyOverrides[method]; ok {
+				m.Reply = reply
+			}
 
-				// generate requestTypename
-				requestTypename := ident.Name
-				// duplicated request name?
-				if _, ok := handlerFileMap[requestTypename]; ok {
-					continue
+			m.RequestArgvFile, err = createRequestArgvFile(files, packageDir, layout.FileName(requestArgFilenamePrefix+m.Method+"Argv"))
+			if err != nil && !os.IsExist(err) {
+				return "", err
+			}
+
+			if m.IsJsonReply() {
+				m.RequestReplyFile, err = createRequestReplyFile(files, packageDir, layout.FileName(requestArgFilenamePrefix+m.Method+"Reply"))
+				if err != nil && !os.IsExist(err) {
+					return "", err
 				}
-				handlerFileMap[requestTypename] = opt
 			}
+			requestMethods = append(requestMethods, m)
 		}
-	}
 
-	var count int = 0
-	if len(handlerFileMap) > 0 {
-		if _, err := os.Stat(handlerDir); os.IsNotExist(err) {
-			os.Mkdir(handlerDir, os.ModePerm)
+		httpFileWriter := &HttpRequestFileWriter{
+			AppModuleName:       appModuleName,
+			RequestPackageName:  packageName,
+			RequestPackagePath:  requestPackagePath,
+			RequestName:         plan.requestName,
+			RequestPrefix:       requestPrefix,
+			RequestUrl:          plan.url,
+			IsSubRequestPackage: isSubPackage,
+			Methods:             requestMethods,
+			PathParams:          plan.pathParams,
+			Layout:              layout,
+			RequestFile:         requestFile,
 		}
 
-		for name, opt := range handlerFileMap {
-			var (
-				packageDir   = handlerDir
-				packageName  = handlerDir
-				filename     = getHandlerFileName(opt.requestName)
-				isSubPackage = false
-			)
-
-			if len(opt.packageName) > 0 {
-				packageDir = path.Join(handlerDir, opt.packageName)
-				if _, err := os.Stat(packageDir); os.IsNotExist(err) {
-					os.Mkdir(packageDir, os.ModePerm)
-				}
-				packageName = opt.packageName
-				isSubPackage = true
+		httpFileWriter.RequestTestFile, err = files.Create(packageDir, filename+TEST_FILE_SUFFIX)
+		if err != nil {
+			if !os.IsExist(err) {
+				return "", err
+			}
+			if mergingContent != nil {
+				mergingTestContent = new(bytes.Buffer)
+				httpFileWriter.RequestTestFile = mergingTestContent
+			}
+		}
 
-				// collect sub package name as wall
-				handlerPackageMap[opt.packageName] = true
+		if httpFileWriter.HasMultipartMethods() {
+			httpFileWriter.MultipartFile, err = createRequestArgvFile(files, packageDir, MULTIPART_FILE_NAME)
+			if err != nil && !os.IsExist(err) {
+				return "", err
 			}
+		}
 
-			if len(filename) > 0 {
-				var (
-					writer FileWriter = nil
-				)
+		writer = httpFileWriter
+
+	case HIJACK_WEBSOCKET:
+		websocketFileWriter := &WebsocketRequestFileWriter{
+			AppModuleName:          appModuleName,
+			RequestPackageName:     packageName,
+			RequestPackagePath:     requestPackagePath,
+			RequestName:            plan.requestName,
+			RequestPrefix:          requestPrefix,
+			IsSubRequestPackage:    isSubPackage,
+			WebsocketAppModuleName: getWebsocketAppModuleName(plan.requestName),
+			Protocol:               plan.protocol,
+			Messages:               plan.messages,
+			PathParams:             plan.pathParams,
+			Layout:                 layout,
+			RequestFile:            requestFile,
+		}
 
-				fmt.Printf("generating '%s' ...", name)
+		websocketFileWriter.RequestGetArgvFile, err = createRequestArgvFile(files, packageDir, layout.FileName(requestArgFilenamePrefix+"GetArgv"))
+		if err != nil && !os.IsExist(err) {
+			return "", err
+		}
 
-				file, err := createFile(filename, packageDir)
-				if err != nil {
-					if os.IsExist(err) {
-						fmt.Println("skipped")
-						continue
-					} else {
-						return 0, nil, err
-					}
-				}
-				defer file.Close()
-
-				requestPrefix := strings.TrimSuffix(opt.requestName, REQUEST_TYPE_SUFFIX)
-				requestArgFilenamePrefix := normalizeFileName(requestPrefix)
-
-				switch opt.hijackType {
-				case HIJACK_NONE:
-					requestGetArgvFile, err := createRequestArgvFile(packageDir, requestArgFilenamePrefix+"GetArgv")
-					if err != nil {
-						if os.IsExist(err) {
-							fmt.Println("skipped")
-						} else {
-							return 0, nil, err
-						}
-					}
-					defer requestGetArgvFile.Close()
-
-					requestPostArgvFile, err := createRequestArgvFile(packageDir, requestArgFilenamePrefix+"PostArgv")
-					if err != nil {
-						if os.IsExist(err) {
-							fmt.Println("skipped")
-						} else {
-							return 0, nil, err
-						}
-					}
-					defer requestPostArgvFile.Close()
-
-					writer = &HttpRequestFileWriter{
-						AppModuleName:       appModuleName,
-						RequestPackageName:  packageName,
-						RequestName:         opt.requestName,
-						RequestPrefix:       requestPrefix,
-						IsSubRequestPackage: isSubPackage,
-						RequestFile:         file,
-						RequestGetArgvFile:  requestGetArgvFile,
-						RequestPostArgvFile: requestPostArgvFile,
-					}
+		websocketFileWriter.WebsocketAppFile, err = createWebsocketAppFile(files, packageDir, websocketFileWriter.WebsocketAppModuleName)
+		if err != nil && !os.IsExist(err) {
+			return "", err
+		}
 
-				case HIJACK_WEBSOCKET:
-					requestGetArgvFile, err := createRequestArgvFile(packageDir, requestArgFilenamePrefix+"GetArgv")
-					if err != nil {
-						if os.IsExist(err) {
-							fmt.Println("skipped")
-						} else {
-							return 0, nil, err
-						}
-					}
-					defer requestGetArgvFile.Close()
-
-					websocketAppModuleName := getWebsocketAppModuleName(opt.requestName)
-					websocketAppFile, err := createWebsocketAppFile(packageDir, websocketAppModuleName)
-					if err != nil {
-						if os.IsExist(err) {
-							fmt.Println("skipped")
-							continue
-						} else {
-							return 0, nil, err
-						}
-					}
-					defer websocketAppFile.Close()
-
-					writer = &WebsocketRequestFileWriter{
-						AppModuleName:          appModuleName,
-						RequestPackageName:     packageName,
-						RequestName:            opt.requestName,
-						RequestPrefix:          requestPrefix,
-						IsSubRequestPackage:    isSubPackage,
-						WebsocketAppModuleName: websocketAppModuleName,
-						RequestFile:            file,
-						WebsocketAppFile:       websocketAppFile,
-						RequestGetArgvFile:     requestGetArgvFile,
-					}
-				}
+		writer = websocketFileWriter
+
+	case HIJACK_SSE:
+		sseFileWriter := &SseRequestFileWriter{
+			AppModuleName:            appModuleName,
+			RequestPackageName:       packageName,
+			RequestPackagePath:       requestPackagePath,
+			RequestName:              plan.requestName,
+			RequestPrefix:            requestPrefix,
+			IsSubRequestPackage:      isSubPackage,
+			EventSourceAppModuleName: getEventSourceAppModuleName(plan.requestName),
+			PathParams:               plan.pathParams,
+			Layout:                   layout,
+			RequestFile:              requestFile,
+		}
 
-				if writer == nil {
-					fmt.Println("skipped")
-					continue
-				}
+		sseFileWriter.RequestGetArgvFile, err = createRequestArgvFile(files, packageDir, layout.FileName(requestArgFilenamePrefix+"GetArgv"))
+		if err != nil && !os.IsExist(err) {
+			return "", err
+		}
 
-				err = writer.Write()
-				if err != nil {
-					fmt.Println("failed")
-				} else {
-					fmt.Println("ok")
-					count++
-				}
-			}
+		sseFileWriter.EventSourceAppFile, err = createEventSourceAppFile(files, packageDir, sseFileWriter.EventSourceAppModuleName, SSE_APP_FILE_NAME)
+		if err != nil && !os.IsExist(err) {
+			return "", err
+		}
+
+		sseFileWriter.EventSourceBrokerFile, err = createEventSourceAppFile(files, packageDir, sseFileWriter.EventSourceAppModuleName, SSE_BROKER_FILE_NAME)
+		if err != nil && !os.IsExist(err) {
+			return "", err
 		}
+
+		writer = sseFileWriter
 	}
 
-	// export subPackages
-	if len(handlerPackageMap) > 0 {
-		subPackages = make([]string, 0, len(handlerPackageMap))
-		for k, _ := range handlerPackageMap {
-			subPackages = append(subPackages, k)
+	err = writer.Write()
+	if err != nil {
+		return "", err
+	}
+
+	var added []string
+	if mergingContent != nil {
+		merger := &RequestFileMerger{
+			Filename:    filepath.Join(packageDir, filename+".go"),
+			RequestName: plan.requestName,
+			Generated:   mergingContent.Bytes(),
+		}
+		var content []byte
+		added, content, err = merger.MergeContent()
+		if err != nil {
+			return "", err
+		}
+		if len(added) > 0 {
+			files.Overwrite(merger.Filename, content)
+		}
+	}
+
+	if len(added) > 0 && mergingTestContent != nil {
+		// merge the tests of the added methods only, the tests removed by
+		// user are not restored
+		tests := make([]string, 0, len(added))
+		for _, name := range added {
+			tests = append(tests, "Test"+plan.requestName+"_"+name)
 		}
+		merger := &RequestFileMerger{
+			Filename:    filepath.Join(packageDir, filename+TEST_FILE_SUFFIX+".go"),
+			RequestName: plan.requestName,
+			Generated:   mergingTestContent.Bytes(),
+			Functions:   tests,
+		}
+		testAdded, content, err := merger.MergeContent()
+		if err != nil {
+			return "", err
+		}
+		if len(testAdded) > 0 {
+			files.Overwrite(merger.Filename, content)
+		}
+	}
+
+	if files.Len() == 0 {
+		return GENERATE_STATUS_SKIPPED, nil
+	}
+	err = files.Commit()
+	if err != nil {
+		return "", err
 	}
-	return count, subPackages, nil
+	if len(added) > 0 {
+		return fmt.Sprintf("%s (added %s)", GENERATE_STATUS_OK, strings.Join(added, ", ")), nil
+	}
+	return GENERATE_STATUS_OK, nil
+}
+
+func generateClientFiles(structType *ast.StructType, handlerDir string, clientDir string) error {
+	routes, err := resolveRoutes(structType, handlerDir)
+	if err != nil {
+		return err
+	}
+	clientRoutes, imports, err := resolveClientRoutes(routes, handlerDir)
+	if err != nil {
+		return err
+	}
+
+	packageName := getClientPackageName(clientDir)
+	fmt.Printf("generating client '%s' ...", packageName)
+
+	var clientFile, clientRoutesFile bytes.Buffer
+	writer := &ClientFileWriter{
+		PackageName:      packageName,
+		Imports:          imports,
+		Routes:           clientRoutes,
+		ClientFile:       &clientFile,
+		ClientRoutesFile: &clientRoutesFile,
+	}
+	err = writer.Write()
+	if err != nil {
+		fmt.Println("failed")
+		return err
+	}
+
+	// the client files are overwritten on each run
+	files := new(GeneratedFileSet)
+	files.Overwrite(filepath.Join(clientDir, CLIENT_FILE_NAME+".go"), clientFile.Bytes())
+	files.Overwrite(filepath.Join(clientDir, CLIENT_ROUTES_FILE_NAME+".go"), clientRoutesFile.Bytes())
+	err = files.Commit()
+	if err != nil {
+		fmt.Println("failed")
+		return err
+	}
+	fmt.Println("ok")
+	return nil
 }
 
 // Normalize the handler type name to file name.
@@ -409,45 +863,131 @@ func normalizeFileName(typename string) string {
 
 func getHandlerFileName(handlerName string) string {
 	if strings.HasSuffix(handlerName, REQUEST_TYPE_SUFFIX) && len(handlerName) > len(REQUEST_TYPE_SUFFIX) {
-		return normalizeFileName(handlerName)
+		return layout.FileName(normalizeFileName(handlerName))
 	}
 	return ""
 }
 
+// parseRequestMethods parses the comma-separated request methods, e.g:
+// "GET,PUT,DELETE".
+func parseRequestMethods(value string) ([]string, error) {
+	var (
+		methods []string
+		visited = make(map[string]bool)
+	)
+
+	for _, v := range strings.Split(value, ",") {
+		method := strings.ToUpper(strings.TrimSpace(v))
+		if len(method) == 0 {
+			continue
+		}
+		if _, ok := requestMethodTable[method]; !ok {
+			return nil, fmt.Errorf("unsupported request method '%s'", v)
+		}
+		if !visited[method] {
+			visited[method] = true
+			methods = append(methods, method)
+		}
+	}
+	if len(methods) == 0 {
+		return nil, fmt.Errorf("no request method specified in '%s'", value)
+	}
+	return methods, nil
+}
+
+// parseReplyOption parses the reply of request methods, e.g: "json" or
+// "json,DELETE:text". The reply without method is the default of all methods,
+// and the others override it.
+func parseReplyOption(value string) (string, map[string]string, error) {
+	var (
+		reply     = REPLY_TEXT
+		overrides = make(map[string]string)
+	)
+
+	for _, v := range strings.Split(value, ",") {
+		var (
+			method string
+			kind   = strings.ToLower(strings.TrimSpace(v))
+		)
+		if len(kind) == 0 {
+			continue
+		}
+		if pos := strings.IndexByte(kind, ':'); pos >= 0 {
+			method, kind = strings.ToUpper(strings.TrimSpace(kind[:pos])), strings.TrimSpace(kind[pos+1:])
+			if _, ok := requestMethodTable[method]; !ok {
+				return "", nil, fmt.Errorf("unsupported request method '%s' in reply '%s'", method, value)
+			}
+		}
+
+		switch kind {
+		case REPLY_TEXT, REPLY_JSON:
+		default:
+			return "", nil, fmt.Errorf("unsupported reply '%s'", v)
+		}
+
+		if len(method) > 0 {
+			overrides[method] = kind
+		} else {
+			reply = kind
+		}
+	}
+	return reply, overrides, nil
+}
+
+// Convert the request method to the method name of request type.
+// e.g: GET to Get, DELETE to Delete.
+func getRequestMethodName(method string) string {
+	return method[:1] + strings.ToLower(method[1:])
+}
+
 func getWebsocketAppModuleName(handlerName string) string {
 	name := handlerName[:len(handlerName)-len(REQUEST_TYPE_SUFFIX)]
 	return strings.ToLower(name)
 }
 
-func createFile(filename string, handlerDir string) (*os.File, error) {
-	path := filepath.Join(handlerDir, filename+".go")
+func getEventSourceAppModuleName(handlerName string) string {
+	name := handlerName[:len(handlerName)-len(REQUEST_TYPE_SUFFIX)]
+	return strings.ToLower(name)
+}
+
+// getWebsocketAppFileName returns the websocket app file of the request plan,
+// e.g: handler/websocket/chat/app.go.
+func getWebsocketAppFileName(plan *requestFilePlan, handlerDir string) string {
+	return filepath.Join(handlerDir, plan.packageName, layout.WebsocketDir, getWebsocketAppModuleName(plan.requestName), WEBSOCKET_APP_FILE_NAME+".go")
+}
+
+// generateWebsocketMessageHandlers runs gen-host-app-handler to generate the
+// MessageHandler files of the websocket app Module. It is skipped if
+// gen-host-app-handler is not installed, the handlers can be generated by
+// go generate later.
+func generateWebsocketMessageHandlers(filename string) error {
+	if _, err := exec.LookPath(HOST_APP_HANDLER_GENERATOR); err != nil {
+		fmt.Printf("%s is not installed, run 'go generate' on '%s' to generate the message handlers\n", HOST_APP_HANDLER_GENERATOR, filename)
+		return nil
+	}
 
-	_, err := os.Stat(path)
+	filename, err := filepath.Abs(filename)
 	if err != nil {
-		if os.IsNotExist(err) {
-			return os.Create(path)
-		}
-		return nil, err
+		return err
 	}
-	return nil, os.ErrExist
+	// the go.mod is tidied after generating the requests
+	return execCmd(HOST_APP_HANDLER_GENERATOR, "-file", filename, "-tidy=false")
 }
 
-func createRequestArgvFile(dir, requestArgvName string) (*os.File, error) {
-	argvDir := path.Join(dir, HANDLER_ARGS_DIR_PATH)
-	if err := os.MkdirAll(argvDir, os.ModePerm); err != nil {
-		return nil, err
-	}
+func createRequestArgvFile(files *GeneratedFileSet, dir, requestArgvName string) (io.Writer, error) {
+	return files.Create(path.Join(dir, layout.ArgsDir), requestArgvName)
+}
 
-	return createFile(requestArgvName, argvDir)
+func createRequestReplyFile(files *GeneratedFileSet, dir, requestReplyName string) (io.Writer, error) {
+	return files.Create(path.Join(dir, layout.ReplyDir), requestReplyName)
 }
 
-func createWebsocketAppFile(dir, websocketAppModuleName string) (*os.File, error) {
-	websocketAppDir := path.Join(dir, WEBSOCKET_APP_DIR_PATH, websocketAppModuleName)
-	if err := os.MkdirAll(websocketAppDir, os.ModePerm); err != nil {
-		return nil, err
-	}
+func createEventSourceAppFile(files *GeneratedFileSet, dir, eventSourceAppModuleName, filename string) (io.Writer, error) {
+	return files.Create(path.Join(dir, layout.SseDir, eventSourceAppModuleName), filename)
+}
 
-	return createFile(WEBSOCKET_APP_FILE_NAME, websocketAppDir)
+func createWebsocketAppFile(files *GeneratedFileSet, dir, websocketAppModuleName string) (io.Writer, error) {
+	return files.Create(path.Join(dir, layout.WebsocketDir, websocketAppModuleName), WEBSOCKET_APP_FILE_NAME)
 }
 
 func importHandlerModulePath(fset *token.FileSet, f *ast.File, subPackages []string) error {
@@ -456,34 +996,174 @@ func importHandlerModulePath(fset *token.FileSet, f *ast.File, subPackages []str
 	)
 
 	{
-		handlerModulePath := appModuleName + "/" + HANDLER_MODULE_NAME
-		ok := astutil.AddNamedImport(fset, f, ".", handlerModulePath)
+		var (
+			handlerModulePath = appModuleName + "/" + layout.HandlerDir
+			ok                bool
+		)
+		if len(layout.ImportAlias) > 0 {
+			ok = astutil.AddNamedImport(fset, f, layout.ImportAlias, handlerModulePath)
+		} else {
+			ok = astutil.AddImport(fset, f, handlerModulePath)
+		}
 		if ok {
 			shouldUpdateImpoty = ok
 		}
 	}
 	for _, name := range subPackages {
-		handlerModulePath := appModuleName + "/" + HANDLER_MODULE_NAME + "/" + name
+		handlerModulePath := appModuleName + "/" + layout.HandlerDir + "/" + name
 		ok := astutil.AddImport(fset, f, handlerModulePath)
 		if ok {
 			shouldUpdateImpoty = ok
 		}
 	}
 	if shouldUpdateImpoty {
-		stream, err := os.OpenFile(gofile, os.O_WRONLY|os.O_TRUNC, os.ModePerm)
+		var buf bytes.Buffer
+		err := format.Node(&buf, fset, f)
 		if err != nil {
 			return err
 		}
-		defer stream.Close()
+		return writeFileAtomic(gofile, buf.Bytes())
+	}
+	return nil
+}
 
-		err = printer.Fprint(stream, fset, f)
-		if err != nil {
-			return err
-		}
+// mergeProtoRequests resolves the requests of the services in protoFile and
+// adds the requests absent from RequestManager of gofile, e.g:
+//
+//	*OrdersByIdRequest `url:"/v1/orders/{id:int}" @methods:"GET,DELETE"`
+//
+// The argv of the request methods are generated from the request messages.
+func mergeProtoRequests(gofile string, protoFile string) error {
+	filename := filepath.Base(protoFile)
+	fmt.Printf("merging '%s' ...", filename)
+
+	file, err := parseProtoFile(protoFile)
+	if err != nil {
+		fmt.Println("failed")
+		return err
+	}
+	requests, err := resolveProtoRequests(file)
+	if err != nil {
+		fmt.Println("failed")
+		return err
+	}
+
+	src, err := os.ReadFile(gofile)
+	if err != nil {
+		fmt.Println("failed")
+		return err
 	}
+	content, added, err := addProtoRequests(gofile, src, requests)
+	if err != nil {
+		fmt.Println("failed")
+		return err
+	}
+	for _, request := range requests {
+		protoRequests[request.Name] = request
+	}
+
+	if len(added) == 0 {
+		fmt.Println(GENERATE_STATUS_SKIPPED)
+		return nil
+	}
+	err = writeFileAtomic(gofile, content)
+	if err != nil {
+		fmt.Println("failed")
+		return err
+	}
+	fmt.Printf("%s (added %s)\n", GENERATE_STATUS_OK, strings.Join(added, ", "))
 	return nil
 }
 
+// addProtoRequests inserts the fields of requests absent from RequestManager
+// before its closing brace, and returns the formatted source and the names
+// of requests added.
+func addProtoRequests(gofile string, src []byte, requests []*ProtoRequest) ([]byte, []string, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, gofile, src, parser.ParseComments)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var structType *ast.StructType
+	ast.Inspect(f, func(n ast.Node) bool {
+		if typeSpec, ok := n.(*ast.TypeSpec); ok && typeSpec.Name.Name == REQUEST_MANAGER_TYPE_NAME {
+			structType, _ = typeSpec.Type.(*ast.StructType)
+			return false
+		}
+		return structType == nil
+	})
+	if structType == nil {
+		return nil, nil, fmt.Errorf("cannot find %s struct in '%s'", REQUEST_MANAGER_TYPE_NAME, gofile)
+	}
+
+	// the requests declared in the handler package
+	declared := make(map[string]bool)
+	for _, field := range structType.Fields.List {
+		star, ok := field.Type.(*ast.StarExpr)
+		if !ok {
+			continue
+		}
+		switch x := star.X.(type) {
+		case *ast.SelectorExpr:
+			if ident, ok := x.X.(*ast.Ident); ok && layout.IsHandlerPackage(ident.Name) {
+				declared[x.Sel.Name] = true
+			}
+		case *ast.Ident:
+			declared[x.Name] = true
+		}
+	}
+
+	var qualifier string
+	switch layout.ImportAlias {
+	case ".":
+	case "":
+		qualifier = layout.HandlerPackageName() + "."
+	default:
+		qualifier = layout.ImportAlias + "."
+	}
+
+	var (
+		fields bytes.Buffer
+		added  []string
+	)
+	for _, request := range requests {
+		if declared[request.Name] {
+			continue
+		}
+		fmt.Fprintf(&fields, "\t*%s%s `%s:%q %s:%q`\n",
+			qualifier, request.Name,
+			TAG_URL_NAME, request.Url,
+			TAG_METHODS_OPT_NAME, strings.Join(request.Methods, ","))
+		added = append(added, request.Name)
+	}
+	if len(added) == 0 {
+		return src, nil, nil
+	}
+
+	var (
+		buf       bytes.Buffer
+		offset    = fset.Position(structType.Fields.Closing).Offset
+		lineStart = bytes.LastIndexByte(src[:offset], '\n') + 1
+	)
+	// insert at the line of closing brace if it starts the line
+	if len(bytes.TrimSpace(src[lineStart:offset])) == 0 {
+		offset = lineStart
+	}
+	buf.Write(src[:offset])
+	if offset != lineStart {
+		buf.WriteString("\n")
+	}
+	buf.Write(fields.Bytes())
+	buf.Write(src[offset:])
+
+	content, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, nil, err
+	}
+	return content, added, nil
+}
+
 func getAppModuleName() (string, error) {
 	goModBytes, err := os.ReadFile("go.mod")
 	if err != nil {
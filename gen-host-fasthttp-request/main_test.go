@@ -1,9 +1,15 @@
 package main
 
 import (
+	"bytes"
 	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/scanner"
+	"go/token"
 	"os"
 	"path"
+	"reflect"
 	"strings"
 	"testing"
 )
@@ -27,6 +33,10 @@ type RequestManager struct {
 	*payment.PayinRequest ”url:"/payment/payin"”
 	*HealthCheckRequest   ”url:"/healthcheck"”
 	*ChatRequest          ”url:"/chat"           @hijack:"websocket"”
+	*NotifyRequest        ”url:"/notify"         @hijack:"sse"”
+	*OrderRequest         ”url:"/order/{id:int}" @methods:"GET,PUT,DELETE"”
+	*UploadRequest        ”url:"/upload"         @methods:"POST" @body:"multipart"”
+	*ProfileRequest       ”url:"/profile/{name}" @methods:"GET,DELETE" @reply:"json,DELETE:text"”
 	*NopRequest           ”url:"/?"              @skip:"on"”
 }
 
@@ -51,6 +61,10 @@ type RequestManager struct {
 	*payment.PayinRequest ”url:"/payment/payin"”
 	*HealthCheckRequest   ”url:"/healthcheck"”
 	*ChatRequest          ”url:"/chat"           @hijack:"websocket"”
+	*NotifyRequest        ”url:"/notify"         @hijack:"sse"”
+	*OrderRequest         ”url:"/order/{id:int}" @methods:"GET,PUT,DELETE"”
+	*UploadRequest        ”url:"/upload"         @methods:"POST" @body:"multipart"”
+	*ProfileRequest       ”url:"/profile/{name}" @methods:"GET,DELETE" @reply:"json,DELETE:text"”
 	*NopRequest           ”url:"/?"              @skip:"on"”
 }
 
@@ -155,196 +169,782 @@ func (r *HealthCheckRequest) Post(ctx *fasthttp.RequestCtx) {
 }
 `
 
-	_EXPECT_FILE_CHAT_REQUEST_GO = `package handler
+	_EXPECT_FILE_ORDER_REQUEST_GO = `package handler
 
 import (
-	"context"
 	"log"
 	"host-fasthttp-request-demo/handler/args"
-	"host-fasthttp-request-demo/handler/websocket/chat"
 	. "host-fasthttp-request-demo/internal"
 
-	"github.com/Bofry/host-fasthttp/app/websocket"
 	"github.com/Bofry/host-fasthttp/response"
 	"github.com/Bofry/host-fasthttp/tracing"
-	"github.com/Bofry/host/app"
 	"github.com/Bofry/httparg"
 	"github.com/valyala/fasthttp"
 )
 
-type ChatRequest struct {
+type OrderRequest struct {
 	ServiceProvider *ServiceProvider
-	Config          *Config
-
-	WesocketApp *app.Application
 }
 
-func (r *ChatRequest) Init() {
+func (r *OrderRequest) Init() {
 	r.ServiceProvider.ConfigureLogger(log.Default())
+}
 
-	// setup WebsocketApp
-	{
-		r.WesocketApp = app.Init(&chat.Module,
-			app.BindServiceProvider(r.ServiceProvider),
-			app.BindConfig(r.Config),
-			app.BindEventClient(app.MultiEventClient{
-				// register channel and EventClient map herre
-			}),
-		)
-		r.WesocketApp.Start(context.Background())
+func (r *OrderRequest) Get(ctx *fasthttp.RequestCtx) {
+	sp := tracing.SpanFromRequestCtx(ctx)
+	_ = sp
+
+	argv := args.OrderGetArgv{}
+
+	if err := argv.BindPath(ctx.UserValue); err != nil {
+		ctx.Error(err.Error(), fasthttp.StatusBadRequest)
+		return
 	}
+
+	httparg.Args(&argv).
+		ProcessQueryString(ctx.QueryArgs().String()).
+		Validate()
+
+	response.Text.Success(ctx, "OK")
 }
 
-func (r *ChatRequest) Get(ctx *fasthttp.RequestCtx) {
+func (r *OrderRequest) Put(ctx *fasthttp.RequestCtx) {
 	sp := tracing.SpanFromRequestCtx(ctx)
 	_ = sp
 
-	argv := args.ChatGetArgv{}
+	argv := args.OrderPutArgv{}
+
+	if err := argv.BindPath(ctx.UserValue); err != nil {
+		ctx.Error(err.Error(), fasthttp.StatusBadRequest)
+		return
+	}
 
 	httparg.Args(&argv).
 		ProcessQueryString(ctx.QueryArgs().String()).
+		ProcessContent(ctx.PostBody(), string(ctx.Request.Header.ContentType())).
 		Validate()
 
-	client := websocket.NewMessageClient(ctx)
-	client.RegisterCloseHandler(func(mc app.MessageClient) {
-		// register close processing here
-	})
-	r.WesocketApp.MessageClientManager().Join(client)
+	response.Text.Success(ctx, "OK")
+}
+
+func (r *OrderRequest) Delete(ctx *fasthttp.RequestCtx) {
+	sp := tracing.SpanFromRequestCtx(ctx)
+	_ = sp
+
+	argv := args.OrderDeleteArgv{}
+
+	if err := argv.BindPath(ctx.UserValue); err != nil {
+		ctx.Error(err.Error(), fasthttp.StatusBadRequest)
+		return
+	}
+
+	httparg.Args(&argv).
+		ProcessQueryString(ctx.QueryArgs().String()).
+		Validate()
 
 	response.Text.Success(ctx, "OK")
 }
 `
 
-	_EXPECT_FILE_CHAT_APP_GO = strings.ReplaceAll(`package chat
+	_EXPECT_FILE_ORDER_REQUEST_TEST_GO = strings.ReplaceAll(`package handler
 
 import (
-	"bytes"
-	"log"
-	"host-fasthttp-request-demo/internal"
+	"testing"
+	. "host-fasthttp-request-demo/internal"
 
-	"github.com/Bofry/host/app"
+	"github.com/valyala/fasthttp"
 )
 
-//go:generate gen-host-app-handler
-var Module = struct {
-	/* put your MessageHandler below */
-	Echo app.MessageHandler ”protocol:"????"”
+func TestOrderRequest_Get(t *testing.T) {
+	r := &OrderRequest{
+		ServiceProvider: &ServiceProvider{},
+	}
+	r.Init()
 
-	/* put your EventHandler below */
-	EchoEvent app.EventHandler ”channel:"????"”
+	ctx := new(fasthttp.RequestCtx)
+	ctx.Request.Header.SetMethod(fasthttp.MethodGet)
+	ctx.Request.SetRequestURI("/order/1?nonce=0a1b2c3d")
+	ctx.SetUserValue("id", "1")
 
-	*App
-	app.ModuleOptionCollection
-}{
-	ModuleOptionCollection: app.ModuleOptions(
-		app.WithProtocolResolver(func(format app.MessageFormat, payload []byte) (string, []byte) {
-			/* processing your protocol resolving below */
-			if len(payload) > 5 && payload[4] == '\n' {
-				return string(payload[:4]), payload[5:]
-			}
-			return "", payload
-		}),
-		app.WithProtocolEmitter(func(format app.MessageFormat, protocol string, body []byte) []byte {
-			/* processing your protocol emitting below */
-			if len(protocol) == 0 {
-				return body
-			}
-			return bytes.Join(
-				[][]byte{
-					[]byte(protocol + "\n"),
-					body,
-				}, nil)
-		}),
-	),
+	r.Get(ctx)
+
+	if code := ctx.Response.StatusCode(); code != fasthttp.StatusOK {
+		t.Errorf("status code expect %d, got %d", fasthttp.StatusOK, code)
+	}
+	if body := string(ctx.Response.Body()); body != "OK" {
+		t.Errorf("body expect %q, got %q", "OK", body)
+	}
 }
 
-type App struct {
-	ServiceProvider *internal.ServiceProvider
-	Config          *internal.Config
+func TestOrderRequest_Put(t *testing.T) {
+	r := &OrderRequest{
+		ServiceProvider: &ServiceProvider{},
+	}
+	r.Init()
+
+	ctx := new(fasthttp.RequestCtx)
+	ctx.Request.Header.SetMethod(fasthttp.MethodPut)
+	ctx.Request.SetRequestURI("/order/1?nonce=0a1b2c3d")
+	ctx.SetUserValue("id", "1")
+	ctx.Request.Header.SetContentType("application/json")
+	ctx.Request.SetBodyString(”{"text":"hello"}”)
+
+	r.Put(ctx)
+
+	if code := ctx.Response.StatusCode(); code != fasthttp.StatusOK {
+		t.Errorf("status code expect %d, got %d", fasthttp.StatusOK, code)
+	}
+	if body := string(ctx.Response.Body()); body != "OK" {
+		t.Errorf("body expect %q, got %q", "OK", body)
+	}
 }
 
-func (ap *App) Init() {
-	ap.ServiceProvider.ConfigureLogger(log.Default())
+func TestOrderRequest_Delete(t *testing.T) {
+	r := &OrderRequest{
+		ServiceProvider: &ServiceProvider{},
+	}
+	r.Init()
+
+	ctx := new(fasthttp.RequestCtx)
+	ctx.Request.Header.SetMethod(fasthttp.MethodDelete)
+	ctx.Request.SetRequestURI("/order/1?nonce=0a1b2c3d")
+	ctx.SetUserValue("id", "1")
+
+	r.Delete(ctx)
+
+	if code := ctx.Response.StatusCode(); code != fasthttp.StatusOK {
+		t.Errorf("status code expect %d, got %d", fasthttp.StatusOK, code)
+	}
+	if body := string(ctx.Response.Body()); body != "OK" {
+		t.Errorf("body expect %q, got %q", "OK", body)
+	}
 }
+`, "”", "`")
 
-func (ap *App) DefaultMessageHandler(ctx *app.Context, message *app.Message) {
+	_EXPECT_FILE_ORDER_PUT_ARGV_GO = strings.ReplaceAll(`package args
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/Bofry/arg"
+	"github.com/Bofry/httparg"
+)
 
+var (
+	_ httparg.Validatable = new(OrderPutArgv)
+)
+
+//go:generate gen-bofry-arg-assertor
+type OrderPutArgv struct /* tag=json */ {
+	Id    int64   ”path:"id" ^:"path"”
+	Nonce *string ”query:"nonce"   ^:"query"”
+	Text  string  ”json:"*text"”
 }
 
-func (ap *App) DefaultEventHandler(ctx *app.Context, event *app.Event) error {
+// Validate implements httparg.Validatable.
+func (argv *OrderPutArgv) Validate() error {
+	v := argv.Assertor()
+
+	err := arg.Assert(
+		v.Id(arg.Ints.NonNegativeInteger),
+		v.Nonce(arg.StringPtr.NonEmpty),
+		v.Text(arg.Strings.NonEmpty),
+	)
+	return err
+}
+
+// BindPath binds the path parameters resolved by value, e.g: fasthttp.RequestCtx.UserValue.
+func (argv *OrderPutArgv) BindPath(value func(key interface{}) interface{}) error {
+	if v, ok := value("id").(string); ok {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid path parameter 'id': %v", err)
+		}
+		argv.Id = n
+	}
 	return nil
 }
 `, "”", "`")
+
+	_EXPECT_FILE_ORDER_DELETE_ARGV_GO = strings.ReplaceAll(`package args
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/Bofry/arg"
+	"github.com/Bofry/httparg"
 )
 
-func Test(t *testing.T) {
-	tmp := t.TempDir()
-	t.Cleanup(func() {
-		os.RemoveAll(tmp)
-		os.Clearenv()
-	})
+var (
+	_ httparg.Validatable = new(OrderDeleteArgv)
+)
 
-	assert(t,
-		createTempFiles(tmp, _FILE_GO_MOD, "go.mod", ""),
-		createTempFiles(tmp, _FILE_APP_GO, "app.go", ""),
-		createTempFiles(tmp, _FILE_INTERNAL_SERVICE_PROVIDER_GO, "serviceProvider.go", "internal"),
+//go:generate gen-bofry-arg-assertor
+type OrderDeleteArgv struct /* tag=query */ {
+	Id    int64   ”path:"id" ^:"path"”
+	Nonce *string ”query:"nonce"”
+}
+
+// Validate implements httparg.Validatable.
+func (argv *OrderDeleteArgv) Validate() error {
+	v := argv.Assertor()
+
+	err := arg.Assert(
+		v.Id(arg.Ints.NonNegativeInteger),
+		v.Nonce(arg.StringPtr.NonEmpty),
 	)
+	return err
+}
 
-	os.Args = []string{
-		"gen-host-fasthttp-request",
-		"-file",
-		path.Join(tmp, "app.go"),
-	}
-	// NOTE: avoid painc when call os.Exit() under testing
-	osExit = func(i int) {
-		if i != 0 {
-			t.Fatalf("got exit code %d", i)
+// BindPath binds the path parameters resolved by value, e.g: fasthttp.RequestCtx.UserValue.
+func (argv *OrderDeleteArgv) BindPath(value func(key interface{}) interface{}) error {
+	if v, ok := value("id").(string); ok {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid path parameter 'id': %v", err)
 		}
+		argv.Id = n
 	}
-	main()
+	return nil
+}
+`, "”", "`")
 
-	{
-		content, err := readFile(tmp, "app.go", "")
-		if err != nil {
-			t.Fatal(err)
-		}
-		expectedContent := _EXPECT_FILE_APP_GO
-		if expectedContent != string(content) {
-			t.Errorf("app.go expect:\n%s\ngot:\n%s\n", expectedContent, string(content))
-		}
+	_EXPECT_FILE_UPLOAD_REQUEST_GO = `package handler
+
+import (
+	"log"
+	"host-fasthttp-request-demo/handler/args"
+	. "host-fasthttp-request-demo/internal"
+
+	"github.com/Bofry/host-fasthttp/response"
+	"github.com/Bofry/host-fasthttp/tracing"
+	"github.com/Bofry/httparg"
+	"github.com/valyala/fasthttp"
+)
+
+type UploadRequest struct {
+	ServiceProvider *ServiceProvider
+}
+
+func (r *UploadRequest) Init() {
+	r.ServiceProvider.ConfigureLogger(log.Default())
+}
+
+func (r *UploadRequest) Post(ctx *fasthttp.RequestCtx) {
+	sp := tracing.SpanFromRequestCtx(ctx)
+	_ = sp
+
+	argv := args.UploadPostArgv{}
+
+	// check the bytes read rather than Content-Length, which is -1 for the
+	// chunked requests.
+	if int64(len(ctx.Request.Body())) > args.MULTIPART_MAX_REQUEST_SIZE {
+		ctx.Error(fasthttp.StatusMessage(fasthttp.StatusRequestEntityTooLarge), fasthttp.StatusRequestEntityTooLarge)
+		return
 	}
-	{
-		content, err := readFile(tmp, "payinRequest.go", "handler/payment")
-		if err != nil {
-			t.Fatal(err)
-		}
-		expectedContent := _EXPECT_FILE_PAYMENT_PAYIN_REQUEST_GO
-		if expectedContent != string(content) {
-			t.Errorf("payinRequest.go expect:\n%s\ngot:\n%s\n", expectedContent, string(content))
-		}
+
+	form, err := ctx.MultipartForm()
+	if err != nil {
+		ctx.Error(err.Error(), fasthttp.StatusBadRequest)
+		return
 	}
-	{
-		content, err := readFile(tmp, "healthCheckRequest.go", "handler")
-		if err != nil {
-			t.Fatal(err)
-		}
-		expectedContent := _EXPECT_FILE_HEALTHCHECK_REQUEST_GO
-		if expectedContent != string(content) {
-			t.Errorf("healthCheckRequest.go expect:\n%s\ngot:\n%s\n", expectedContent, string(content))
-		}
+	defer ctx.Request.RemoveMultipartFormFiles()
+
+	argv.BindForm(form)
+
+	httparg.Args(&argv).
+		ProcessQueryString(ctx.QueryArgs().String()).
+		Validate()
+
+	response.Text.Success(ctx, "OK")
+}
+`
+
+	_EXPECT_FILE_UPLOAD_POST_ARGV_GO = strings.ReplaceAll(`package args
+
+import (
+	"mime/multipart"
+
+	"github.com/Bofry/arg"
+	"github.com/Bofry/httparg"
+)
+
+var (
+	_ httparg.Validatable = new(UploadPostArgv)
+)
+
+//go:generate gen-bofry-arg-assertor
+type UploadPostArgv struct /* tag=form */ {
+	Nonce *string               ”query:"nonce" ^:"query"”
+	Text  string                ”form:"text"”
+	File  *multipart.FileHeader ”form:"file"”
+}
+
+// Validate implements httparg.Validatable.
+func (argv *UploadPostArgv) Validate() error {
+	v := argv.Assertor()
+
+	err := arg.Assert(
+		v.Nonce(arg.StringPtr.NonEmpty),
+		v.Text(arg.Strings.NonEmpty),
+		v.File(
+			FileRequired,
+			FileMaxSize(MULTIPART_MAX_FILE_SIZE),
+			FileContentTypes(MULTIPART_CONTENT_TYPES...),
+		),
+	)
+	return err
+}
+
+// BindForm binds the values and files of the multipart form.
+func (argv *UploadPostArgv) BindForm(form *multipart.Form) {
+	if values := form.Value["text"]; len(values) > 0 {
+		argv.Text = values[0]
 	}
-	{
-		content, err := readFile(tmp, "chatRequest.go", "handler")
-		if err != nil {
-			t.Fatal(err)
-		}
-		expectedContent := _EXPECT_FILE_CHAT_REQUEST_GO
-		if expectedContent != string(content) {
-			t.Errorf("chatRequest.go expect:\n%s\ngot:\n%s\n", expectedContent, string(content))
-		}
+	if files := form.File["file"]; len(files) > 0 {
+		argv.File = files[0]
 	}
-	{
-		content, err := readFile(tmp, "app.go", "handler/websocket/chat")
+}
+`, "”", "`")
+
+	_EXPECT_FILE_PROFILE_REQUEST_GO = `package handler
+
+import (
+	"log"
+	"host-fasthttp-request-demo/handler/args"
+	"host-fasthttp-request-demo/handler/reply"
+	. "host-fasthttp-request-demo/internal"
+
+	"github.com/Bofry/host-fasthttp/response"
+	"github.com/Bofry/host-fasthttp/response/failure"
+	"github.com/Bofry/host-fasthttp/tracing"
+	"github.com/Bofry/httparg"
+	"github.com/valyala/fasthttp"
+)
+
+type ProfileRequest struct {
+	ServiceProvider *ServiceProvider
+}
+
+func (r *ProfileRequest) Init() {
+	r.ServiceProvider.ConfigureLogger(log.Default())
+}
+
+func (r *ProfileRequest) Get(ctx *fasthttp.RequestCtx) {
+	sp := tracing.SpanFromRequestCtx(ctx)
+	_ = sp
+
+	argv := args.ProfileGetArgv{}
+
+	if err := argv.BindPath(ctx.UserValue); err != nil {
+		failure.ThrowFailureMessage(failure.INVALID_ARGUMENT, err.Error())
+	}
+
+	httparg.Args(&argv).
+		ProcessQueryString(ctx.QueryArgs().String()).
+		Validate()
+
+	result := reply.ProfileGetReply{
+		Message: "OK",
+	}
+	response.Json.Success(ctx, &result)
+}
+
+func (r *ProfileRequest) Delete(ctx *fasthttp.RequestCtx) {
+	sp := tracing.SpanFromRequestCtx(ctx)
+	_ = sp
+
+	argv := args.ProfileDeleteArgv{}
+
+	if err := argv.BindPath(ctx.UserValue); err != nil {
+		ctx.Error(err.Error(), fasthttp.StatusBadRequest)
+		return
+	}
+
+	httparg.Args(&argv).
+		ProcessQueryString(ctx.QueryArgs().String()).
+		Validate()
+
+	response.Text.Success(ctx, "OK")
+}
+`
+
+	_EXPECT_FILE_PROFILE_GET_REPLY_GO = strings.ReplaceAll(`package reply
+
+type ProfileGetReply struct {
+	Message string ”json:"message"”
+}
+`, "”", "`")
+
+	_EXPECT_FILE_NOTIFY_REQUEST_GO = `package handler
+
+import (
+	"bufio"
+	"context"
+	"log"
+	"host-fasthttp-request-demo/handler/args"
+	"host-fasthttp-request-demo/handler/sse/notify"
+	. "host-fasthttp-request-demo/internal"
+
+	"github.com/Bofry/host-fasthttp/tracing"
+	"github.com/Bofry/host/app"
+	"github.com/Bofry/httparg"
+	"github.com/valyala/fasthttp"
+)
+
+type NotifyRequest struct {
+	ServiceProvider *ServiceProvider
+	Config          *Config
+
+	EventSourceApp *app.Application
+}
+
+func (r *NotifyRequest) Init() {
+	r.ServiceProvider.ConfigureLogger(log.Default())
+
+	// setup EventSourceApp
+	{
+		r.EventSourceApp = app.Init(&notify.Module,
+			app.BindServiceProvider(r.ServiceProvider),
+			app.BindConfig(r.Config),
+			app.BindEventClient(app.MultiEventClient{
+				// register channel and EventClient map herre
+			}),
+		)
+		r.EventSourceApp.Start(context.Background())
+	}
+}
+
+func (r *NotifyRequest) Get(ctx *fasthttp.RequestCtx) {
+	sp := tracing.SpanFromRequestCtx(ctx)
+	_ = sp
+
+	argv := args.NotifyGetArgv{}
+
+	httparg.Args(&argv).
+		ProcessQueryString(ctx.QueryArgs().String()).
+		Validate()
+
+	// the clients requesting the same path parameters share the stream, and
+	// resume the events after Last-Event-ID when reconnected
+	stream := notify.StreamKey()
+	subscriber := notify.Broker.Subscribe(stream, string(ctx.Request.Header.Peek("Last-Event-ID")))
+
+	ctx.SetContentType("text/event-stream")
+	ctx.Response.Header.Set("Cache-Control", "no-cache")
+	ctx.Response.Header.Set("Connection", "keep-alive")
+	ctx.Response.Header.Set("X-Accel-Buffering", "no")
+	ctx.SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer notify.Broker.Unsubscribe(subscriber)
+
+		notify.Serve(w, subscriber)
+	})
+}
+`
+
+	_EXPECT_FILE_CHAT_REQUEST_GO = `package handler
+
+import (
+	"context"
+	"log"
+	"host-fasthttp-request-demo/handler/args"
+	"host-fasthttp-request-demo/handler/websocket/chat"
+	. "host-fasthttp-request-demo/internal"
+
+	"github.com/Bofry/host-fasthttp/app/websocket"
+	"github.com/Bofry/host-fasthttp/response"
+	"github.com/Bofry/host-fasthttp/tracing"
+	"github.com/Bofry/host/app"
+	"github.com/Bofry/httparg"
+	"github.com/valyala/fasthttp"
+)
+
+type ChatRequest struct {
+	ServiceProvider *ServiceProvider
+	Config          *Config
+
+	WesocketApp *app.Application
+}
+
+func (r *ChatRequest) Init() {
+	r.ServiceProvider.ConfigureLogger(log.Default())
+
+	// setup WebsocketApp
+	{
+		r.WesocketApp = app.Init(&chat.Module,
+			app.BindServiceProvider(r.ServiceProvider),
+			app.BindConfig(r.Config),
+			app.BindEventClient(app.MultiEventClient{
+				// register channel and EventClient map herre
+			}),
+		)
+		r.WesocketApp.Start(context.Background())
+	}
+}
+
+func (r *ChatRequest) Get(ctx *fasthttp.RequestCtx) {
+	sp := tracing.SpanFromRequestCtx(ctx)
+	_ = sp
+
+	argv := args.ChatGetArgv{}
+
+	httparg.Args(&argv).
+		ProcessQueryString(ctx.QueryArgs().String()).
+		Validate()
+
+	client := websocket.NewMessageClient(ctx)
+	client.RegisterCloseHandler(func(mc app.MessageClient) {
+		// register close processing here
+	})
+	r.WesocketApp.MessageClientManager().Join(client)
+
+	response.Text.Success(ctx, "OK")
+}
+`
+
+	_EXPECT_FILE_CHAT_APP_GO = strings.ReplaceAll(`package chat
+
+import (
+	"bytes"
+	"log"
+	"host-fasthttp-request-demo/internal"
+
+	"github.com/Bofry/host/app"
+)
+
+//go:generate gen-host-app-handler
+var Module = struct {
+	/* put your MessageHandler below */
+	Echo app.MessageHandler ”protocol:"????"”
+
+	/* put your EventHandler below */
+	EchoEvent app.EventHandler ”channel:"????"”
+
+	*App
+	app.ModuleOptionCollection
+}{
+	ModuleOptionCollection: app.ModuleOptions(
+		app.WithProtocolResolver(func(format app.MessageFormat, payload []byte) (string, []byte) {
+			/* processing your protocol resolving below */
+			if len(payload) > 5 && payload[4] == '\n' {
+				return string(payload[:4]), payload[5:]
+			}
+			return "", payload
+		}),
+		app.WithProtocolEmitter(func(format app.MessageFormat, protocol string, body []byte) []byte {
+			/* processing your protocol emitting below */
+			if len(protocol) == 0 {
+				return body
+			}
+			return bytes.Join(
+				[][]byte{
+					[]byte(protocol + "\n"),
+					body,
+				}, nil)
+		}),
+	),
+}
+
+type App struct {
+	ServiceProvider *internal.ServiceProvider
+	Config          *internal.Config
+}
+
+func (ap *App) Init() {
+	ap.ServiceProvider.ConfigureLogger(log.Default())
+}
+
+func (ap *App) DefaultMessageHandler(ctx *app.Context, message *app.Message) {
+
+}
+
+func (ap *App) DefaultEventHandler(ctx *app.Context, event *app.Event) error {
+	return nil
+}
+`, "”", "`")
+)
+
+func Test(t *testing.T) {
+	tmp := t.TempDir()
+	t.Cleanup(func() {
+		os.RemoveAll(tmp)
+		os.Clearenv()
+	})
+
+	assert(t,
+		createTempFiles(tmp, _FILE_GO_MOD, "go.mod", ""),
+		createTempFiles(tmp, _FILE_APP_GO, "app.go", ""),
+		createTempFiles(tmp, _FILE_INTERNAL_SERVICE_PROVIDER_GO, "serviceProvider.go", "internal"),
+	)
+
+	os.Args = []string{
+		"gen-host-fasthttp-request",
+		"-file",
+		path.Join(tmp, "app.go"),
+	}
+	// NOTE: avoid painc when call os.Exit() under testing
+	osExit = func(i int) {
+		if i != 0 {
+			t.Fatalf("got exit code %d", i)
+		}
+	}
+	main()
+
+	{
+		content, err := readFile(tmp, "app.go", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		expectedContent := _EXPECT_FILE_APP_GO
+		if expectedContent != string(content) {
+			t.Errorf("app.go expect:\n%s\ngot:\n%s\n", expectedContent, string(content))
+		}
+	}
+	{
+		content, err := readFile(tmp, "payinRequest.go", "handler/payment")
+		if err != nil {
+			t.Fatal(err)
+		}
+		expectedContent := _EXPECT_FILE_PAYMENT_PAYIN_REQUEST_GO
+		if expectedContent != string(content) {
+			t.Errorf("payinRequest.go expect:\n%s\ngot:\n%s\n", expectedContent, string(content))
+		}
+	}
+	{
+		content, err := readFile(tmp, "healthCheckRequest.go", "handler")
+		if err != nil {
+			t.Fatal(err)
+		}
+		expectedContent := _EXPECT_FILE_HEALTHCHECK_REQUEST_GO
+		if expectedContent != string(content) {
+			t.Errorf("healthCheckRequest.go expect:\n%s\ngot:\n%s\n", expectedContent, string(content))
+		}
+	}
+	{
+		content, err := readFile(tmp, "orderRequest.go", "handler")
+		if err != nil {
+			t.Fatal(err)
+		}
+		expectedContent := _EXPECT_FILE_ORDER_REQUEST_GO
+		if expectedContent != string(content) {
+			t.Errorf("orderRequest.go expect:\n%s\ngot:\n%s\n", expectedContent, string(content))
+		}
+	}
+	{
+		content, err := readFile(tmp, "orderRequest_test.go", "handler")
+		if err != nil {
+			t.Fatal(err)
+		}
+		expectedContent := _EXPECT_FILE_ORDER_REQUEST_TEST_GO
+		if expectedContent != string(content) {
+			t.Errorf("orderRequest_test.go expect:\n%s\ngot:\n%s\n", expectedContent, string(content))
+		}
+		if _, err := readFile(tmp, "chatRequest_test.go", "handler"); err == nil {
+			t.Errorf("chatRequest_test.go should not be generated")
+		}
+	}
+	{
+		content, err := readFile(tmp, "orderPutArgv.go", "handler/args")
+		if err != nil {
+			t.Fatal(err)
+		}
+		expectedContent := _EXPECT_FILE_ORDER_PUT_ARGV_GO
+		if expectedContent != string(content) {
+			t.Errorf("orderPutArgv.go expect:\n%s\ngot:\n%s\n", expectedContent, string(content))
+		}
+	}
+	{
+		content, err := readFile(tmp, "orderDeleteArgv.go", "handler/args")
+		if err != nil {
+			t.Fatal(err)
+		}
+		expectedContent := _EXPECT_FILE_ORDER_DELETE_ARGV_GO
+		if expectedContent != string(content) {
+			t.Errorf("orderDeleteArgv.go expect:\n%s\ngot:\n%s\n", expectedContent, string(content))
+		}
+		if _, err := readFile(tmp, "orderPostArgv.go", "handler/args"); err == nil {
+			t.Errorf("orderPostArgv.go should not be generated")
+		}
+	}
+	{
+		content, err := readFile(tmp, "uploadRequest.go", "handler")
+		if err != nil {
+			t.Fatal(err)
+		}
+		expectedContent := _EXPECT_FILE_UPLOAD_REQUEST_GO
+		if expectedContent != string(content) {
+			t.Errorf("uploadRequest.go expect:\n%s\ngot:\n%s\n", expectedContent, string(content))
+		}
+	}
+	{
+		content, err := readFile(tmp, "uploadPostArgv.go", "handler/args")
+		if err != nil {
+			t.Fatal(err)
+		}
+		expectedContent := _EXPECT_FILE_UPLOAD_POST_ARGV_GO
+		if expectedContent != string(content) {
+			t.Errorf("uploadPostArgv.go expect:\n%s\ngot:\n%s\n", expectedContent, string(content))
+		}
+		if _, err := readFile(tmp, "multipart.go", "handler/args"); err != nil {
+			t.Errorf("multipart.go should be generated")
+		}
+	}
+	{
+		content, err := readFile(tmp, "profileRequest.go", "handler")
+		if err != nil {
+			t.Fatal(err)
+		}
+		expectedContent := _EXPECT_FILE_PROFILE_REQUEST_GO
+		if expectedContent != string(content) {
+			t.Errorf("profileRequest.go expect:\n%s\ngot:\n%s\n", expectedContent, string(content))
+		}
+	}
+	{
+		content, err := readFile(tmp, "profileGetReply.go", "handler/reply")
+		if err != nil {
+			t.Fatal(err)
+		}
+		expectedContent := _EXPECT_FILE_PROFILE_GET_REPLY_GO
+		if expectedContent != string(content) {
+			t.Errorf("profileGetReply.go expect:\n%s\ngot:\n%s\n", expectedContent, string(content))
+		}
+		if _, err := readFile(tmp, "profileDeleteReply.go", "handler/reply"); err == nil {
+			t.Errorf("profileDeleteReply.go should not be generated")
+		}
+	}
+	{
+		content, err := readFile(tmp, "notifyRequest.go", "handler")
+		if err != nil {
+			t.Fatal(err)
+		}
+		expectedContent := _EXPECT_FILE_NOTIFY_REQUEST_GO
+		if expectedContent != string(content) {
+			t.Errorf("notifyRequest.go expect:\n%s\ngot:\n%s\n", expectedContent, string(content))
+		}
+		for _, filename := range []string{"app.go", "broker.go"} {
+			content, err := readFile(tmp, filename, "handler/sse/notify")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.HasPrefix(string(content), "package notify\n") {
+				t.Errorf("sse/notify/%s should be package notify", filename)
+			}
+		}
+	}
+	{
+		content, err := readFile(tmp, "chatRequest.go", "handler")
+		if err != nil {
+			t.Fatal(err)
+		}
+		expectedContent := _EXPECT_FILE_CHAT_REQUEST_GO
+		if expectedContent != string(content) {
+			t.Errorf("chatRequest.go expect:\n%s\ngot:\n%s\n", expectedContent, string(content))
+		}
+	}
+	{
+		content, err := readFile(tmp, "app.go", "handler/websocket/chat")
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -355,6 +955,516 @@ func Test(t *testing.T) {
 	}
 }
 
+func TestGenerateRequestFiles_Errors(t *testing.T) {
+	tmp := t.TempDir()
+
+	source := strings.ReplaceAll(`package main
+
+type RequestManager struct {
+	*OrderRequest    ”url:"/order"       @methods:"GET,FETCH"”
+	*HealthRequest   ”url:"/health"      @methods:"GET"”
+	*UploadRequest   ”url:"/upload"      @body:"xml"”
+	*ProfileRequest  ”url:"/profile/{}"  @methods:"GET"”
+	*ChatRequest     ”url:"/chat"        @hijack:"grpc"”
+	*CatalogRequest  ”url:"/catalog"     @methods:"GET"”
+}
+`, "”", "`")
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "app.go", source, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	structType := f.Decls[0].(*ast.GenDecl).Specs[0].(*ast.TypeSpec).Type.(*ast.StructType)
+
+	n, _, err := generateRequestFiles(fset, structType, path.Join(tmp, "handler"))
+	if n != 2 {
+		t.Errorf("generated requests expect %d, got %d", 2, n)
+	}
+	errs, ok := err.(scanner.ErrorList)
+	if !ok {
+		t.Fatalf("should get scanner.ErrorList, got %v", err)
+	}
+	expectedErrors := []string{
+		"app.go:4:2: unsupported request method 'FETCH'",
+		"app.go:6:2: unsupported request body 'xml'",
+		"app.go:7:2: invalid path parameter",
+		"app.go:8:2: unsupported hijack type 'grpc'",
+	}
+	if len(errs) != len(expectedErrors) {
+		t.Fatalf("errors expect %d, got %d:\n%v", len(expectedErrors), len(errs), errs)
+	}
+	for i, expected := range expectedErrors {
+		if !strings.HasPrefix(errs[i].Error(), expected) {
+			t.Errorf("error expect %q, got %q", expected, errs[i].Error())
+		}
+	}
+
+	// the valid requests are generated
+	for _, filename := range []string{"healthRequest.go", "catalogRequest.go", "args/catalogGetArgv.go"} {
+		if _, err := readFile(tmp, path.Base(filename), path.Join("handler", path.Dir(filename))); err != nil {
+			t.Errorf("%s should be generated", filename)
+		}
+	}
+	if _, err := readFile(tmp, "orderRequest.go", "handler"); err == nil {
+		t.Errorf("orderRequest.go should not be generated")
+	}
+}
+
+func TestResolveRequestFilePlans(t *testing.T) {
+	source := strings.ReplaceAll(`package main
+
+type RequestManager struct {
+	*payment.PayinRequest ”url:"/payment/payin"”
+	*OrderRequest         ”url:"/order"”
+	*HealthCheckRequest   ”url:"/healthcheck"”
+	*OrderRequest         ”url:"/order/v2"”
+	*NopRequest           ”url:"/?" @skip:"on"”
+}
+`, "”", "`")
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "app.go", source, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	structType := f.Decls[0].(*ast.GenDecl).Specs[0].(*ast.TypeSpec).Type.(*ast.StructType)
+
+	plans, errs := resolveRequestFilePlans(fset, structType)
+	if len(errs) > 0 {
+		t.Fatal(errs)
+	}
+	var typenames []string
+	for _, plan := range plans {
+		typenames = append(typenames, fmt.Sprintf("%s@%s", plan.typename, plan.pos))
+	}
+	expected := []string{
+		"HealthCheckRequest@app.go:6:2",
+		"OrderRequest@app.go:5:2",
+		"payment.PayinRequest@app.go:4:2",
+	}
+	if !reflect.DeepEqual(expected, typenames) {
+		t.Errorf("plans expect %v, got %v", expected, typenames)
+	}
+}
+
+func TestGenerateRequestFiles_Unescaped(t *testing.T) {
+	tmp := t.TempDir()
+
+	workdir, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.Chdir(tmp)
+	defer os.Chdir(workdir)
+
+	source := strings.ReplaceAll(`package main
+
+type RequestManager struct {
+	*TagRequest ”url:"/tags/c++/{id:int}" @methods:"GET,POST"”
+}
+`, "”", "`")
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "app.go", source, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	structType := f.Decls[0].(*ast.GenDecl).Specs[0].(*ast.TypeSpec).Type.(*ast.StructType)
+
+	_, _, err = generateRequestFiles(fset, structType, "handler")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the go source should not be escaped as html
+	content, err := readFile(tmp, "tagRequest_test.go", "handler")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{
+		`SetRequestURI("/tags/c++/1?nonce=0a1b2c3d")`,
+		"SetBodyString(`{\"text\":\"hello\"}`)",
+	} {
+		if !strings.Contains(string(content), expected) {
+			t.Errorf("tagRequest_test.go should contain %q, got:\n%s", expected, string(content))
+		}
+	}
+}
+
+func TestGenerateRequestFiles_SseStream(t *testing.T) {
+	tmp := t.TempDir()
+
+	workdir, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.Chdir(tmp)
+	defer os.Chdir(workdir)
+
+	source := strings.ReplaceAll(`package main
+
+type RequestManager struct {
+	*RoomRequest ”url:"/notify/{room}/{seq:int}" @hijack:"sse"”
+}
+`, "”", "`")
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "app.go", source, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	structType := f.Decls[0].(*ast.GenDecl).Specs[0].(*ast.TypeSpec).Type.(*ast.StructType)
+
+	_, _, err = generateRequestFiles(fset, structType, "handler")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the stream should be keyed by the path parameters
+	content, err := readFile(tmp, "roomRequest.go", "handler")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{
+		`stream := room.StreamKey(ctx.UserValue("room"), ctx.UserValue("seq"))`,
+		`room.Broker.Subscribe(stream, string(ctx.Request.Header.Peek("Last-Event-ID")))`,
+	} {
+		if !strings.Contains(string(content), expected) {
+			t.Errorf("roomRequest.go should contain %q, got:\n%s", expected, string(content))
+		}
+	}
+
+	content, err = readFile(tmp, "app.go", "handler/sse/room")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(content), "????") {
+		t.Errorf("sse/room/app.go should not contain the placeholder EventHandler, got:\n%s", string(content))
+	}
+}
+
+func TestGenerateRequestFiles_MergeTests(t *testing.T) {
+	tmp := t.TempDir()
+
+	workdir, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.Chdir(tmp)
+	defer os.Chdir(workdir)
+
+	handlerDir := "handler"
+
+	generate := func(methods string) {
+		source := fmt.Sprintf(strings.ReplaceAll(`package main
+
+type RequestManager struct {
+	*OrderRequest ”url:"/order" @methods:"%s"”
+}
+`, "”", "`"), methods)
+		fset := token.NewFileSet()
+		f, err := parser.ParseFile(fset, "app.go", source, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		structType := f.Decls[0].(*ast.GenDecl).Specs[0].(*ast.TypeSpec).Type.(*ast.StructType)
+
+		_, _, err = generateRequestFiles(fset, structType, handlerDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	generate("GET,PUT")
+
+	// the user removes the test of Put
+	filename := path.Join(handlerDir, "orderRequest_test.go")
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	i := strings.Index(string(content), "\nfunc TestOrderRequest_Put(")
+	if i < 0 {
+		t.Fatalf("orderRequest_test.go should contain TestOrderRequest_Put, got:\n%s", string(content))
+	}
+	assert(t,
+		os.WriteFile(filename, content[:i+1], 0644),
+	)
+
+	generate("GET,PUT,POST")
+
+	content, err = os.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(content), "\nfunc TestOrderRequest_Post(") {
+		t.Errorf("orderRequest_test.go should contain TestOrderRequest_Post, got:\n%s", string(content))
+	}
+	if strings.Contains(string(content), "\nfunc TestOrderRequest_Put(") {
+		t.Errorf("orderRequest_test.go should not restore TestOrderRequest_Put, got:\n%s", string(content))
+	}
+	if strings.Count(string(content), "\nfunc TestOrderRequest_Get(") != 1 {
+		t.Errorf("orderRequest_test.go should contain one TestOrderRequest_Get, got:\n%s", string(content))
+	}
+}
+
+func TestParseRequestMethods(t *testing.T) {
+	methods, err := parseRequestMethods(" get,PUT , delete,GET")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedMethods := []string{"GET", "PUT", "DELETE"}
+	if !reflect.DeepEqual(expectedMethods, methods) {
+		t.Errorf("methods expect %v, got %v", expectedMethods, methods)
+	}
+
+	if _, err = parseRequestMethods("GET,CONNECT"); err == nil {
+		t.Errorf("should get error when the method is unsupported")
+	}
+	if _, err = parseRequestMethods(""); err == nil {
+		t.Errorf("should get error when no method is specified")
+	}
+}
+
+func TestParseReplyOption(t *testing.T) {
+	reply, overrides, err := parseReplyOption("json, delete:TEXT")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reply != REPLY_JSON {
+		t.Errorf("reply expect %s, got %s", REPLY_JSON, reply)
+	}
+	expectedOverrides := map[string]string{"DELETE": REPLY_TEXT}
+	if !reflect.DeepEqual(expectedOverrides, overrides) {
+		t.Errorf("overrides expect %v, got %v", expectedOverrides, overrides)
+	}
+
+	if _, _, err = parseReplyOption("xml"); err == nil {
+		t.Errorf("should get error when the reply is unsupported")
+	}
+	if _, _, err = parseReplyOption("CONNECT:json"); err == nil {
+		t.Errorf("should get error when the method is unsupported")
+	}
+}
+
+func TestParsePathParams(t *testing.T) {
+	params, err := parsePathParams("/users/{user_id}/orders/{orderId:int}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedParams := []*PathParam{
+		{Name: "user_id", FieldName: "UserId", FieldType: "*string"},
+		{Name: "orderId", FieldName: "OrderId", FieldType: "int64", IsInt: true},
+	}
+	if !reflect.DeepEqual(expectedParams, params) {
+		t.Errorf("params expect %+v, got %+v", expectedParams, params)
+	}
+
+	if _, err = parsePathParams("/users/{id:uuid}"); err == nil {
+		t.Errorf("should get error when the type is unsupported")
+	}
+	if _, err = parsePathParams("/users/{id}/orders/{id}"); err == nil {
+		t.Errorf("should get error when the parameter is duplicated")
+	}
+	if _, err = parsePathParams("/users/{}"); err == nil {
+		t.Errorf("should get error when the parameter name is empty")
+	}
+}
+
+func TestListRoutes(t *testing.T) {
+	tmp := t.TempDir()
+
+	assert(t,
+		createTempFiles(tmp, `package handler
+
+type OrderRequest struct{}
+
+func (r *OrderRequest) Init()                          {}
+func (r *OrderRequest) Get(ctx *fasthttp.RequestCtx)    {}
+func (r *OrderRequest) Delete(ctx *fasthttp.RequestCtx) {}
+func (r *OrderRequest) get(ctx *fasthttp.RequestCtx)    {}
+`, "orderRequest.go", ""),
+	)
+
+	structType := parseRequestManager(t, strings.ReplaceAll(`package main
+
+type RequestManager struct {
+	*payment.PayinRequest ”url:"/payment/payin"”
+	*OrderRequest         ”url:"/order/{id:int}" @methods:"GET,DELETE"”
+	*ChatRequest          ”url:"/chat"           @hijack:"websocket"”
+	*NopRequest           ”url:"/?"              @skip:"on"”
+}
+`, "”", "`"))
+
+	var buf bytes.Buffer
+	err := listRoutes(&buf, structType, tmp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedOutput := "URL              REQUEST       PACKAGE          HIJACK     METHODS\n" +
+		"/payment/payin   PayinRequest  handler/payment  -          -\n" +
+		"/order/{id:int}  OrderRequest  handler          -          GET,DELETE\n" +
+		"/chat            ChatRequest   handler          websocket  -\n"
+	if buf.String() != expectedOutput {
+		t.Errorf("output expect:\n%s\ngot:\n%s\n", expectedOutput, buf.String())
+	}
+
+	// the planned methods of the requests not generated yet are checked
+	structType = parseRequestManager(t, strings.ReplaceAll(`package main
+
+type RequestManager struct {
+	*FileRequest   ”url:"/files/{path}" @methods:"GET"”
+	*UploadRequest ”url:"/files/x"      @methods:"POST"”
+}
+`, "”", "`"))
+	buf.Reset()
+	if err := listRoutes(&buf, structType, tmp); err != nil {
+		t.Errorf("should get no error, got %v", err)
+	}
+}
+
+func TestCheckRouteConflicts(t *testing.T) {
+	cases := []struct {
+		a, b     string
+		expected int
+	}{
+		{"/order", "/order/", ROUTE_DISJOINT},
+		{"/order/{id:int}", "/order/{id:int}/", ROUTE_DISJOINT},
+		{"/order/", "/order/", ROUTE_DUPLICATE},
+		{"/order/{id}", "/order/{no}", ROUTE_DUPLICATE},
+		{"/order/{id:int}", "/order/{no}", ROUTE_OVERLAP},
+		{"/order/{id}", "/order/latest", ROUTE_OVERLAP},
+		{"/order/{id:int}", "/order/latest", ROUTE_DISJOINT},
+		{"/order/{id:int}", "/order/7", ROUTE_OVERLAP},
+		{"/order/{id}", "/order/{id}/items", ROUTE_DISJOINT},
+		{"/order", "/payment", ROUTE_DISJOINT},
+	}
+
+	for _, c := range cases {
+		if result := compareRoutePatterns(c.a, c.b); result != c.expected {
+			t.Errorf("compare '%s' and '%s' expect %d, got %d", c.a, c.b, c.expected, result)
+		}
+	}
+
+	err := checkRouteConflicts([]*Route{
+		{Url: "/order/{id}", RequestName: "OrderRequest"},
+		{Url: "/order/latest", RequestName: "LatestOrderRequest"},
+		{Url: "/order/{no}", RequestName: "LegacyOrderRequest"},
+		{Url: "/payment", RequestName: "PaymentRequest"},
+	})
+	expectedError := "found 3 route conflict(s):\n" +
+		"  route '/order/{id}' of OrderRequest shadows '/order/latest' of LatestOrderRequest\n" +
+		"  duplicate route '/order/{id}' of OrderRequest and '/order/{no}' of LegacyOrderRequest\n" +
+		"  route '/order/latest' of LatestOrderRequest shadows '/order/{no}' of LegacyOrderRequest"
+	if err == nil || err.Error() != expectedError {
+		t.Errorf("error expect:\n%s\ngot:\n%v\n", expectedError, err)
+	}
+
+	// the routes of different request methods never conflict
+	err = checkRouteConflicts([]*Route{
+		{Url: "/files/{path}", RequestName: "FileRequest", routeMethods: []string{"GET"}},
+		{Url: "/files/x", RequestName: "UploadRequest", routeMethods: []string{"POST"}},
+		{Url: "/files/{name}", RequestName: "DeleteFileRequest", routeMethods: []string{"DELETE", "PUT"}},
+	})
+	if err != nil {
+		t.Errorf("should get no error, got %v", err)
+	}
+	err = checkRouteConflicts([]*Route{
+		{Url: "/files/{path}", RequestName: "FileRequest", routeMethods: []string{"GET", "PUT"}},
+		{Url: "/files/x", RequestName: "UploadRequest", routeMethods: []string{"POST", "PUT"}},
+	})
+	expectedError = "found 1 route conflict(s):\n" +
+		"  route '/files/{path}' of FileRequest shadows '/files/x' of UploadRequest"
+	if err == nil || err.Error() != expectedError {
+		t.Errorf("error expect:\n%s\ngot:\n%v\n", expectedError, err)
+	}
+}
+
+func TestGenerateClientFiles(t *testing.T) {
+	tmp := t.TempDir()
+
+	assert(t,
+		createTempFiles(tmp, `package handler
+
+type OrderRequest struct{}
+
+func (r *OrderRequest) Put(ctx *fasthttp.RequestCtx)    {}
+func (r *OrderRequest) Delete(ctx *fasthttp.RequestCtx) {}
+`, "orderRequest.go", "handler"),
+		createTempFiles(tmp, _EXPECT_FILE_ORDER_PUT_ARGV_GO, "orderPutArgv.go", "handler/args"),
+		os.WriteFile(path.Join(tmp, "handler/args", "orderDeleteArgv.go"), []byte(_EXPECT_FILE_ORDER_DELETE_ARGV_GO), 0644),
+	)
+
+	structType := parseRequestManager(t, strings.ReplaceAll(`package main
+
+type RequestManager struct {
+	*OrderRequest ”url:"/order/{id:int}" @methods:"PUT,DELETE"”
+	*ChatRequest  ”url:"/chat"           @hijack:"websocket"”
+}
+`, "”", "`"))
+
+	err := generateClientFiles(structType, path.Join(tmp, "handler"), path.Join(tmp, "sdk", "orderclient"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := readFile(tmp, "routes.go", "sdk/orderclient")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedContent := strings.ReplaceAll(`// Code generated by gen-host-fasthttp-request. DO NOT EDIT.
+
+package orderclient
+
+import (
+	"context"
+	"net/http"
+)
+
+type OrderPutArgv struct {
+	Id    int64   ”path:"id" ^:"path"”
+	Nonce *string ”query:"nonce"   ^:"query"”
+	Text  string  ”json:"*text"”
+}
+
+// PutOrder calls PUT /order/{id:int}.
+func (c *Client) PutOrder(ctx context.Context, argv *OrderPutArgv) (*Response, error) {
+	return c.do(ctx, http.MethodPut, "/order/{id:int}", argv, true)
+}
+
+type OrderDeleteArgv struct {
+	Id    int64   ”path:"id" ^:"path"”
+	Nonce *string ”query:"nonce"”
+}
+
+// DeleteOrder calls DELETE /order/{id:int}.
+func (c *Client) DeleteOrder(ctx context.Context, argv *OrderDeleteArgv) (*Response, error) {
+	return c.do(ctx, http.MethodDelete, "/order/{id:int}", argv, false)
+}
+`, "”", "`")
+	if expectedContent != string(content) {
+		t.Errorf("routes.go expect:\n%s\ngot:\n%s\n", expectedContent, string(content))
+	}
+	if _, err := readFile(tmp, "client.go", "sdk/orderclient"); err != nil {
+		t.Errorf("client.go should be generated")
+	}
+}
+
+func parseRequestManager(t *testing.T, source string) *ast.StructType {
+	f, err := parser.ParseFile(token.NewFileSet(), "app.go", source, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, decl := range f.Decls {
+		if genDecl, ok := decl.(*ast.GenDecl); ok {
+			for _, spec := range genDecl.Specs {
+				if typeSpec, ok := spec.(*ast.TypeSpec); ok && typeSpec.Name.Name == REQUEST_MANAGER_TYPE_NAME {
+					return typeSpec.Type.(*ast.StructType)
+				}
+			}
+		}
+	}
+	t.Fatalf("cannot find %s", REQUEST_MANAGER_TYPE_NAME)
+	return nil
+}
+
 func assert(t *testing.T, err ...error) {
 	for _, e := range err {
 		if e != nil {
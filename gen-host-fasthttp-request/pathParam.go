@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+const (
+	PATH_PARAM_TYPE_STRING string = "string"
+	PATH_PARAM_TYPE_INT    string = "int"
+)
+
+var (
+	pathParamRegexp     = regexp.MustCompile(`\{([^{}]*)\}`)
+	pathParamNameRegexp = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]*$`)
+)
+
+// PathParam describes the path parameter declared in url tag, e.g:
+// {id} or {id:int}.
+type PathParam struct {
+	Name      string
+	FieldName string
+	FieldType string
+	IsInt     bool
+}
+
+// SampleValue returns the path parameter value used in the generated tests.
+func (p *PathParam) SampleValue() string {
+	if p.IsInt {
+		return "1"
+	}
+	return p.Name
+}
+
+// getSampleRequestUri replaces the path parameters of url pattern with their
+// sample values, e.g: "/users/{id:int}" to "/users/1".
+func getSampleRequestUri(url string, params []*PathParam) string {
+	var i int
+	return pathParamRegexp.ReplaceAllStringFunc(url, func(string) string {
+		if i >= len(params) {
+			return ""
+		}
+		param := params[i]
+		i++
+		return param.SampleValue()
+	})
+}
+
+// parsePathParams parses the path parameters of url pattern, e.g:
+// "/users/{id}/orders/{orderId:int}".
+func parsePathParams(url string) ([]*PathParam, error) {
+	var (
+		params  []*PathParam
+		visited = make(map[string]bool)
+	)
+
+	for _, match := range pathParamRegexp.FindAllStringSubmatch(url, -1) {
+		var (
+			name      = match[1]
+			paramType = PATH_PARAM_TYPE_STRING
+		)
+
+		if pos := strings.IndexByte(name, ':'); pos >= 0 {
+			name, paramType = name[:pos], name[pos+1:]
+		}
+		if !pathParamNameRegexp.MatchString(name) {
+			return nil, fmt.Errorf("invalid path parameter '%s' in url '%s'", match[0], url)
+		}
+
+		param := &PathParam{
+			Name:      name,
+			FieldName: getPathParamFieldName(name),
+		}
+		switch paramType {
+		case PATH_PARAM_TYPE_STRING:
+			param.FieldType = "*string"
+		case PATH_PARAM_TYPE_INT:
+			param.FieldType = "int64"
+			param.IsInt = true
+		default:
+			return nil, fmt.Errorf("unsupported path parameter type '%s' in url '%s'", paramType, url)
+		}
+
+		if visited[param.FieldName] {
+			return nil, fmt.Errorf("duplicated path parameter '%s' in url '%s'", name, url)
+		}
+		visited[param.FieldName] = true
+		params = append(params, param)
+	}
+	return params, nil
+}
+
+// Convert the path parameter name to the argv field name.
+// e.g: id to Id, order_id to OrderId.
+func getPathParamFieldName(name string) string {
+	var sb strings.Builder
+	for _, part := range strings.Split(name, "_") {
+		if len(part) == 0 {
+			continue
+		}
+		runes := []rune(part)
+		runes[0] = unicode.ToUpper(runes[0])
+		sb.WriteString(string(runes))
+	}
+	return sb.String()
+}
@@ -0,0 +1,785 @@
+package main
+
+import (
+	"fmt"
+	"go/scanner"
+	"go/token"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+const (
+	PROTO_TOKEN_EOF = iota
+	PROTO_TOKEN_IDENT
+	PROTO_TOKEN_INT
+	PROTO_TOKEN_FLOAT
+	PROTO_TOKEN_STRING
+	PROTO_TOKEN_PUNCT
+)
+
+// ProtoFile describes the declarations of .proto file used to generate the
+// requests, i.e. the messages, enums and services. The nested messages and
+// enums are named by their parents, e.g: Order.Item.
+type ProtoFile struct {
+	Filename string
+	Package  string
+	Messages map[string]*ProtoMessage
+	Enums    map[string]*ProtoEnum
+	Services []*ProtoService
+}
+
+type ProtoMessage struct {
+	Name   string
+	Fields []*ProtoField
+}
+
+type ProtoField struct {
+	Name     string
+	Type     string
+	Number   int
+	Repeated bool
+	// the key type of map field, e.g: string of map<string, int32>.
+	MapKey  string
+	Options ProtoOptions
+	// the message declares the field, used to resolve the field type.
+	Scope string
+	Pos   token.Position
+}
+
+type ProtoEnum struct {
+	Name   string
+	Values []string
+	// the numbers of values.
+	Numbers []int
+}
+
+type ProtoService struct {
+	Name string
+	Rpcs []*ProtoRpc
+}
+
+type ProtoRpc struct {
+	Name            string
+	InputType       string
+	OutputType      string
+	ClientStreaming bool
+	ServerStreaming bool
+	Options         ProtoOptions
+	Pos             token.Position
+}
+
+// ProtoOption is the option declared by "option" statement or field options,
+// e.g: (google.api.http) or (validate.rules).string.min_len.
+type ProtoOption struct {
+	Name  string
+	Value *ProtoValue
+}
+
+// ProtoValue is the constant or the aggregate of option value. The strings
+// are unquoted.
+type ProtoValue struct {
+	Literal string
+	Fields  ProtoOptions
+	List    []*ProtoValue
+}
+
+type ProtoOptions []*ProtoOption
+
+// Lookup finds the values of option name, e.g: (validate.rules).string.min_len
+// matches the option declared as
+//
+//	[(validate.rules).string.min_len = 1]
+//	[(validate.rules).string = {min_len: 1}]
+//
+// The values of list are expanded.
+func (options ProtoOptions) Lookup(name string) []*ProtoValue {
+	var values []*ProtoValue
+	for _, opt := range options {
+		switch {
+		case opt.Name == name:
+			if opt.Value.List != nil {
+				values = append(values, opt.Value.List...)
+			} else {
+				values = append(values, opt.Value)
+			}
+		case strings.HasPrefix(name, opt.Name+"."):
+			values = append(values, opt.Value.Fields.Lookup(name[len(opt.Name)+1:])...)
+		}
+	}
+	return values
+}
+
+// LookupLiteral returns the literal of the last value of option name.
+func (options ProtoOptions) LookupLiteral(name string) (string, bool) {
+	values := options.Lookup(name)
+	for i := len(values) - 1; i >= 0; i-- {
+		if values[i].Fields == nil {
+			return values[i].Literal, true
+		}
+	}
+	return "", false
+}
+
+type protoToken struct {
+	kind int
+	text string
+	pos  token.Position
+}
+
+// protoParser is the recursive descent parser of the proto2 and proto3
+// syntax. It keeps the declarations required by generating the requests and
+// skips the others, e.g: reserved, extensions and extend.
+type protoParser struct {
+	filename string
+	src      []rune
+	offset   int
+	line     int
+	column   int
+
+	tok  protoToken
+	file *ProtoFile
+}
+
+// parseProtoFile parses the .proto file. The errors are returned as
+// scanner.ErrorList with the positions in the file.
+func parseProtoFile(filename string) (*ProtoFile, error) {
+	src, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return parseProtoSource(filename, string(src))
+}
+
+func parseProtoSource(filename string, src string) (file *ProtoFile, err error) {
+	p := &protoParser{
+		filename: filename,
+		src:      []rune(src),
+		line:     1,
+		column:   1,
+		file: &ProtoFile{
+			Filename: filename,
+			Messages: make(map[string]*ProtoMessage),
+			Enums:    make(map[string]*ProtoEnum),
+		},
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			e, ok := r.(*scanner.Error)
+			if !ok {
+				panic(r)
+			}
+			var errs scanner.ErrorList
+			errs.Add(e.Pos, e.Msg)
+			file, err = nil, errs.Err()
+		}
+	}()
+
+	p.next()
+	p.parseFile()
+	return p.file, nil
+}
+
+func (p *protoParser) errorf(pos token.Position, format string, args ...interface{}) {
+	panic(&scanner.Error{Pos: pos, Msg: fmt.Sprintf(format, args...)})
+}
+
+func (p *protoParser) position() token.Position {
+	return token.Position{
+		Filename: p.filename,
+		Offset:   p.offset,
+		Line:     p.line,
+		Column:   p.column,
+	}
+}
+
+func (p *protoParser) peekRune(n int) rune {
+	if p.offset+n < len(p.src) {
+		return p.src[p.offset+n]
+	}
+	return 0
+}
+
+func (p *protoParser) readRune() rune {
+	r := p.src[p.offset]
+	p.offset++
+	if r == '\n' {
+		p.line++
+		p.column = 1
+	} else {
+		p.column++
+	}
+	return r
+}
+
+// next scans the next token, the whitespaces and comments are skipped.
+func (p *protoParser) next() {
+	for p.offset < len(p.src) {
+		r := p.peekRune(0)
+		switch {
+		case unicode.IsSpace(r):
+			p.readRune()
+		case r == '/' && p.peekRune(1) == '/':
+			for p.offset < len(p.src) && p.peekRune(0) != '\n' {
+				p.readRune()
+			}
+		case r == '/' && p.peekRune(1) == '*':
+			pos := p.position()
+			p.readRune()
+			p.readRune()
+			for {
+				if p.offset >= len(p.src) {
+					p.errorf(pos, "comment not terminated")
+				}
+				if p.peekRune(0) == '*' && p.peekRune(1) == '/' {
+					p.readRune()
+					p.readRune()
+					break
+				}
+				p.readRune()
+			}
+		default:
+			p.tok = p.scanToken()
+			return
+		}
+	}
+	p.tok = protoToken{kind: PROTO_TOKEN_EOF, pos: p.position()}
+}
+
+func (p *protoParser) scanToken() protoToken {
+	var (
+		pos   = p.position()
+		start = p.offset
+		r     = p.peekRune(0)
+	)
+
+	switch {
+	case r == '_' || unicode.IsLetter(r):
+		for r := p.peekRune(0); r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r); r = p.peekRune(0) {
+			p.readRune()
+		}
+		return protoToken{PROTO_TOKEN_IDENT, string(p.src[start:p.offset]), pos}
+
+	case unicode.IsDigit(r) || (r == '.' && unicode.IsDigit(p.peekRune(1))):
+		kind := PROTO_TOKEN_INT
+		for {
+			r := p.peekRune(0)
+			if r == '.' || ((r == 'e' || r == 'E') && !strings.HasPrefix(strings.ToLower(string(p.src[start:p.offset])), "0x")) {
+				kind = PROTO_TOKEN_FLOAT
+				p.readRune()
+				if r := p.peekRune(0); r == '+' || r == '-' {
+					p.readRune()
+				}
+				continue
+			}
+			if r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r) {
+				p.readRune()
+				continue
+			}
+			break
+		}
+		return protoToken{kind, string(p.src[start:p.offset]), pos}
+
+	case r == '"' || r == '\'':
+		quote := p.readRune()
+		for {
+			if p.offset >= len(p.src) || p.peekRune(0) == '\n' {
+				p.errorf(pos, "string literal not terminated")
+			}
+			r := p.readRune()
+			if r == '\\' {
+				if p.offset >= len(p.src) {
+					p.errorf(pos, "string literal not terminated")
+				}
+				p.readRune()
+				continue
+			}
+			if r == quote {
+				break
+			}
+		}
+		return protoToken{PROTO_TOKEN_STRING, string(p.src[start:p.offset]), pos}
+	}
+
+	p.readRune()
+	return protoToken{PROTO_TOKEN_PUNCT, string(r), pos}
+}
+
+func (p *protoParser) is(text string) bool {
+	return (p.tok.kind == PROTO_TOKEN_PUNCT || p.tok.kind == PROTO_TOKEN_IDENT) && p.tok.text == text
+}
+
+func (p *protoParser) expect(text string) token.Position {
+	pos := p.tok.pos
+	if !p.is(text) {
+		p.errorf(pos, "expected '%s', found %s", text, p.describe())
+	}
+	p.next()
+	return pos
+}
+
+func (p *protoParser) describe() string {
+	if p.tok.kind == PROTO_TOKEN_EOF {
+		return "EOF"
+	}
+	return "'" + p.tok.text + "'"
+}
+
+func (p *protoParser) parseIdent() string {
+	if p.tok.kind != PROTO_TOKEN_IDENT {
+		p.errorf(p.tok.pos, "expected identifier, found %s", p.describe())
+	}
+	text := p.tok.text
+	p.next()
+	return text
+}
+
+// parseFullIdent parses the dotted identifier, e.g: google.api.http or
+// .order.v1.Order.
+func (p *protoParser) parseFullIdent() string {
+	var sb strings.Builder
+	if p.is(".") {
+		sb.WriteString(".")
+		p.next()
+	}
+	sb.WriteString(p.parseIdent())
+	for p.is(".") {
+		p.next()
+		sb.WriteString(".")
+		sb.WriteString(p.parseIdent())
+	}
+	return sb.String()
+}
+
+func (p *protoParser) parseString() string {
+	if p.tok.kind != PROTO_TOKEN_STRING {
+		p.errorf(p.tok.pos, "expected string, found %s", p.describe())
+	}
+	var sb strings.Builder
+	// the adjacent strings are concatenated
+	for p.tok.kind == PROTO_TOKEN_STRING {
+		sb.WriteString(p.unquote(p.tok))
+		p.next()
+	}
+	return sb.String()
+}
+
+func (p *protoParser) unquote(tok protoToken) string {
+	text := tok.text
+	if text[0] == '\'' {
+		// convert to the double-quoted string
+		text = "\"" + strings.ReplaceAll(strings.ReplaceAll(text[1:len(text)-1], "\\'", "'"), "\"", "\\\"") + "\""
+	}
+	s, err := strconv.Unquote(text)
+	if err != nil {
+		p.errorf(tok.pos, "invalid string literal %s", tok.text)
+	}
+	return s
+}
+
+func (p *protoParser) parseInt() int {
+	var (
+		pos  = p.tok.pos
+		sign = ""
+	)
+	if p.is("-") {
+		sign = "-"
+		p.next()
+	}
+	if p.tok.kind != PROTO_TOKEN_INT {
+		p.errorf(pos, "expected integer, found %s", p.describe())
+	}
+	n, err := strconv.ParseInt(sign+p.tok.text, 0, 64)
+	if err != nil {
+		p.errorf(pos, "invalid integer %s%s", sign, p.tok.text)
+	}
+	p.next()
+	return int(n)
+}
+
+// skipStatement skips the tokens until ';' or the balanced block.
+func (p *protoParser) skipStatement() {
+	for depth := 0; ; p.next() {
+		switch {
+		case p.tok.kind == PROTO_TOKEN_EOF:
+			p.errorf(p.tok.pos, "unexpected EOF")
+		case p.is("{"):
+			depth++
+		case p.is("}"):
+			depth--
+			if depth == 0 {
+				p.next()
+				return
+			}
+		case p.is(";") && depth == 0:
+			p.next()
+			return
+		}
+	}
+}
+
+func (p *protoParser) parseFile() {
+	for p.tok.kind != PROTO_TOKEN_EOF {
+		switch {
+		case p.is(";"):
+			p.next()
+		case p.is("syntax"), p.is("edition"):
+			p.next()
+			p.expect("=")
+			p.parseString()
+			p.expect(";")
+		case p.is("package"):
+			p.next()
+			p.file.Package = p.parseFullIdent()
+			p.expect(";")
+		case p.is("import"), p.is("extend"):
+			p.skipStatement()
+		case p.is("option"):
+			p.parseOptionStatement()
+		case p.is("message"):
+			p.parseMessage("")
+		case p.is("enum"):
+			p.parseEnum("")
+		case p.is("service"):
+			p.parseService()
+		default:
+			p.errorf(p.tok.pos, "unexpected %s", p.describe())
+		}
+	}
+}
+
+func (p *protoParser) parseMessage(scope string) {
+	p.expect("message")
+	name := p.parseIdent()
+	if len(scope) > 0 {
+		name = scope + "." + name
+	}
+	message := &ProtoMessage{
+		Name: name,
+	}
+	p.file.Messages[name] = message
+
+	p.expect("{")
+	for !p.is("}") {
+		switch {
+		case p.tok.kind == PROTO_TOKEN_EOF:
+			p.errorf(p.tok.pos, "unexpected EOF in message %s", name)
+		case p.is(";"):
+			p.next()
+		case p.is("message"):
+			p.parseMessage(name)
+		case p.is("enum"):
+			p.parseEnum(name)
+		case p.is("option"):
+			p.parseOptionStatement()
+		case p.is("reserved"), p.is("extensions"), p.is("extend"):
+			p.skipStatement()
+		case p.is("oneof"):
+			p.next()
+			p.parseIdent()
+			p.expect("{")
+			for !p.is("}") {
+				switch {
+				case p.is(";"):
+					p.next()
+				case p.is("option"):
+					p.parseOptionStatement()
+				default:
+					message.Fields = append(message.Fields, p.parseField(name))
+				}
+			}
+			p.next()
+		default:
+			message.Fields = append(message.Fields, p.parseField(name))
+		}
+	}
+	p.next()
+}
+
+// parseField parses the normal field and the map field, e.g:
+//
+//	repeated string tags = 3 [(validate.rules).repeated.min_items = 1];
+//	map<string, int32> counts = 4;
+func (p *protoParser) parseField(scope string) *ProtoField {
+	field := &ProtoField{
+		Scope: scope,
+		Pos:   p.tok.pos,
+	}
+
+	switch {
+	case p.is("repeated"):
+		field.Repeated = true
+		p.next()
+	case p.is("optional"), p.is("required"):
+		p.next()
+	case p.is("group"):
+		p.errorf(p.tok.pos, "group is not supported")
+	}
+
+	if p.is("map") {
+		p.next()
+		p.expect("<")
+		field.MapKey = p.parseFullIdent()
+		p.expect(",")
+		field.Type = p.parseFullIdent()
+		p.expect(">")
+	} else {
+		field.Type = p.parseFullIdent()
+	}
+	field.Name = p.parseIdent()
+	p.expect("=")
+	field.Number = p.parseInt()
+	field.Options = p.parseFieldOptions()
+	p.expect(";")
+	return field
+}
+
+func (p *protoParser) parseFieldOptions() ProtoOptions {
+	var options ProtoOptions
+	if !p.is("[") {
+		return options
+	}
+	p.next()
+	for {
+		options = append(options, p.parseOption())
+		if !p.is(",") {
+			break
+		}
+		p.next()
+	}
+	p.expect("]")
+	return options
+}
+
+func (p *protoParser) parseEnum(scope string) {
+	p.expect("enum")
+	name := p.parseIdent()
+	if len(scope) > 0 {
+		name = scope + "." + name
+	}
+	enum := &ProtoEnum{
+		Name: name,
+	}
+	p.file.Enums[name] = enum
+
+	p.expect("{")
+	for !p.is("}") {
+		switch {
+		case p.tok.kind == PROTO_TOKEN_EOF:
+			p.errorf(p.tok.pos, "unexpected EOF in enum %s", name)
+		case p.is(";"):
+			p.next()
+		case p.is("option"):
+			p.parseOptionStatement()
+		case p.is("reserved"):
+			p.skipStatement()
+		default:
+			enum.Values = append(enum.Values, p.parseIdent())
+			p.expect("=")
+			enum.Numbers = append(enum.Numbers, p.parseInt())
+			p.parseFieldOptions()
+			p.expect(";")
+		}
+	}
+	p.next()
+}
+
+func (p *protoParser) parseService() {
+	p.expect("service")
+	service := &ProtoService{
+		Name: p.parseIdent(),
+	}
+	p.file.Services = append(p.file.Services, service)
+
+	p.expect("{")
+	for !p.is("}") {
+		switch {
+		case p.tok.kind == PROTO_TOKEN_EOF:
+			p.errorf(p.tok.pos, "unexpected EOF in service %s", service.Name)
+		case p.is(";"):
+			p.next()
+		case p.is("option"):
+			p.parseOptionStatement()
+		case p.is("rpc"):
+			service.Rpcs = append(service.Rpcs, p.parseRpc())
+		default:
+			p.errorf(p.tok.pos, "unexpected %s in service %s", p.describe(), service.Name)
+		}
+	}
+	p.next()
+}
+
+// parseRpc parses the rpc and its options, e.g:
+//
+//	rpc GetOrder(GetOrderRequest) returns (Order) {
+//	  option (google.api.http) = { get: "/v1/orders/{id}" };
+//	}
+func (p *protoParser) parseRpc() *ProtoRpc {
+	rpc := &ProtoRpc{
+		Pos: p.expect("rpc"),
+	}
+	rpc.Name = p.parseIdent()
+
+	p.expect("(")
+	if p.is("stream") {
+		rpc.ClientStreaming = true
+		p.next()
+	}
+	rpc.InputType = p.parseFullIdent()
+	p.expect(")")
+	p.expect("returns")
+	p.expect("(")
+	if p.is("stream") {
+		rpc.ServerStreaming = true
+		p.next()
+	}
+	rpc.OutputType = p.parseFullIdent()
+	p.expect(")")
+
+	if p.is("{") {
+		p.next()
+		for !p.is("}") {
+			switch {
+			case p.tok.kind == PROTO_TOKEN_EOF:
+				p.errorf(p.tok.pos, "unexpected EOF in rpc %s", rpc.Name)
+			case p.is(";"):
+				p.next()
+			case p.is("option"):
+				p.next()
+				rpc.Options = append(rpc.Options, p.parseOption())
+				p.expect(";")
+			default:
+				p.errorf(p.tok.pos, "unexpected %s in rpc %s", p.describe(), rpc.Name)
+			}
+		}
+		p.next()
+	} else {
+		p.expect(";")
+	}
+	return rpc
+}
+
+// parseOptionStatement parses the option of file, message, enum and service,
+// which are not used by generating.
+func (p *protoParser) parseOptionStatement() {
+	p.expect("option")
+	p.parseOption()
+	p.expect(";")
+}
+
+// parseOption parses the option name and value, e.g:
+// (validate.rules).string = {min_len: 1}.
+func (p *protoParser) parseOption() *ProtoOption {
+	option := &ProtoOption{
+		Name: p.parseOptionName(),
+	}
+	p.expect("=")
+	option.Value = p.parseValue()
+	return option
+}
+
+func (p *protoParser) parseOptionName() string {
+	var sb strings.Builder
+	for {
+		if p.is("(") {
+			p.next()
+			sb.WriteString("(" + p.parseFullIdent() + ")")
+			p.expect(")")
+		} else {
+			sb.WriteString(p.parseIdent())
+		}
+		if !p.is(".") {
+			break
+		}
+		p.next()
+		sb.WriteString(".")
+	}
+	return sb.String()
+}
+
+// parseValue parses the constant or the aggregate in protobuf text format.
+func (p *protoParser) parseValue() *ProtoValue {
+	switch {
+	case p.is("{"), p.is("<"):
+		return &ProtoValue{Fields: p.parseAggregate()}
+	case p.is("["):
+		p.next()
+		value := &ProtoValue{List: []*ProtoValue{}}
+		for !p.is("]") {
+			value.List = append(value.List, p.parseValue())
+			if !p.is(",") {
+				break
+			}
+			p.next()
+		}
+		p.expect("]")
+		return value
+	case p.tok.kind == PROTO_TOKEN_STRING:
+		return &ProtoValue{Literal: p.parseString()}
+	case p.is("-"), p.is("+"):
+		sign := p.tok.text
+		p.next()
+		if p.tok.kind != PROTO_TOKEN_INT && p.tok.kind != PROTO_TOKEN_FLOAT && p.tok.kind != PROTO_TOKEN_IDENT {
+			p.errorf(p.tok.pos, "expected number, found %s", p.describe())
+		}
+		literal := p.tok.text
+		p.next()
+		if sign == "-" {
+			literal = sign + literal
+		}
+		return &ProtoValue{Literal: literal}
+	case p.tok.kind == PROTO_TOKEN_INT, p.tok.kind == PROTO_TOKEN_FLOAT:
+		literal := p.tok.text
+		p.next()
+		return &ProtoValue{Literal: literal}
+	case p.tok.kind == PROTO_TOKEN_IDENT:
+		return &ProtoValue{Literal: p.parseFullIdent()}
+	}
+	p.errorf(p.tok.pos, "expected value, found %s", p.describe())
+	return nil
+}
+
+func (p *protoParser) parseAggregate() ProtoOptions {
+	closing := "}"
+	if p.is("<") {
+		closing = ">"
+	}
+	p.next()
+
+	options := ProtoOptions{}
+	for !p.is(closing) {
+		if p.tok.kind == PROTO_TOKEN_EOF {
+			p.errorf(p.tok.pos, "unexpected EOF in aggregate value")
+		}
+
+		var name string
+		if p.is("[") {
+			p.next()
+			name = "(" + p.parseFullIdent() + ")"
+			p.expect("]")
+		} else {
+			name = p.parseIdent()
+		}
+
+		option := &ProtoOption{
+			Name: name,
+		}
+		if p.is(":") {
+			p.next()
+			option.Value = p.parseValue()
+		} else if p.is("{") || p.is("<") {
+			option.Value = &ProtoValue{Fields: p.parseAggregate()}
+		} else {
+			p.errorf(p.tok.pos, "expected ':' or '{', found %s", p.describe())
+		}
+		options = append(options, option)
+
+		if p.is(",") || p.is(";") {
+			p.next()
+		}
+	}
+	p.next()
+	return options
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"go/scanner"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseProtoSource(t *testing.T) {
+	source := `syntax = "proto3";
+
+package order.v1;
+
+import "google/api/annotations.proto";
+option go_package = "example.com/order/v1;orderv1";
+
+/* the order service */
+service OrderService {
+  option (google.api.default_host) = "order.example.com";
+
+  rpc GetOrder(GetOrderRequest) returns (Order) {
+    option (google.api.http) = {
+      get: "/v1/orders/{id}"
+      additional_bindings { get: "/v1/shops/{shop_id}/orders/{id}" }
+    };
+  }
+  rpc WatchOrders(stream GetOrderRequest) returns (stream .order.v1.Order);
+}
+
+message GetOrderRequest {
+  reserved 3, 4;
+  int64 id = 1 [(validate.rules).int64 = {gt: 0, not_in: [7, -8]}];
+  string shop_id = 2 [json_name = "shop", (validate.rules).string.min_len = 1];
+}
+
+message Order {
+  enum Status {
+    STATUS_UNSPECIFIED = 0;
+    STATUS_OPEN = 1 [deprecated = true];
+  }
+  message Item {
+    string sku = 1;
+  }
+  oneof owner {
+    string user = 2;
+    string team = 3;
+  }
+  // the items
+  repeated Item items = 4;
+  map<string, int32> counts = 5;
+  optional Status status = 6;
+}
+`
+	file, err := parseProtoSource("order.proto", source)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if file.Package != "order.v1" {
+		t.Errorf("package expect %q, got %q", "order.v1", file.Package)
+	}
+	for _, name := range []string{"GetOrderRequest", "Order", "Order.Item"} {
+		if _, ok := file.Messages[name]; !ok {
+			t.Errorf("message %s should be parsed", name)
+		}
+	}
+	if enum := file.Enums["Order.Status"]; enum == nil ||
+		!reflect.DeepEqual(enum.Values, []string{"STATUS_UNSPECIFIED", "STATUS_OPEN"}) {
+		t.Errorf("enum Order.Status should be parsed, got %+v", enum)
+	}
+
+	// fields
+	var fields []string
+	for _, field := range file.Messages["Order"].Fields {
+		fields = append(fields, field.Name+":"+field.Type)
+	}
+	if expected := []string{"user:string", "team:string", "items:Item", "counts:int32", "status:Status"}; !reflect.DeepEqual(fields, expected) {
+		t.Errorf("fields expect %v, got %v", expected, fields)
+	}
+	if field := file.Messages["Order"].LookupField("items"); !field.Repeated || field.Number != 4 {
+		t.Errorf("field items should be repeated with number 4, got %+v", field)
+	}
+	if field := file.Messages["Order"].LookupField("counts"); field.MapKey != "string" {
+		t.Errorf("field counts should be map with string key, got %+v", field)
+	}
+	if field := file.Messages["GetOrderRequest"].LookupField("shop_id"); field.Pos.Line != 24 || field.Pos.Column != 3 {
+		t.Errorf("field shop_id position expect 24:3, got %s", field.Pos)
+	}
+
+	// rpcs
+	service := file.Services[0]
+	if service.Name != "OrderService" || len(service.Rpcs) != 2 {
+		t.Fatalf("service OrderService with 2 rpcs expected, got %+v", service)
+	}
+	watch := service.Rpcs[1]
+	if !watch.ClientStreaming || !watch.ServerStreaming || watch.OutputType != ".order.v1.Order" {
+		t.Errorf("rpc WatchOrders should be streaming, got %+v", watch)
+	}
+
+	// options
+	get := service.Rpcs[0]
+	rules := get.Options.Lookup(PROTO_HTTP_OPTION_NAME)
+	if len(rules) != 1 {
+		t.Fatalf("http rule expect 1, got %d", len(rules))
+	}
+	if v, _ := rules[0].Fields.LookupLiteral("get"); v != "/v1/orders/{id}" {
+		t.Errorf("get expect %q, got %q", "/v1/orders/{id}", v)
+	}
+	if v, _ := get.Options.LookupLiteral("(google.api.http).additional_bindings.get"); v != "/v1/shops/{shop_id}/orders/{id}" {
+		t.Errorf("additional binding expect %q, got %q", "/v1/shops/{shop_id}/orders/{id}", v)
+	}
+
+	id := file.Messages["GetOrderRequest"].LookupField("id")
+	if v, _ := id.Options.LookupLiteral("(validate.rules).int64.gt"); v != "0" {
+		t.Errorf("int64.gt expect %q, got %q", "0", v)
+	}
+	var notIn []string
+	for _, v := range id.Options.Lookup("(validate.rules).int64.not_in") {
+		notIn = append(notIn, v.Literal)
+	}
+	if expected := []string{"7", "-8"}; !reflect.DeepEqual(notIn, expected) {
+		t.Errorf("int64.not_in expect %v, got %v", expected, notIn)
+	}
+	shopId := file.Messages["GetOrderRequest"].LookupField("shop_id")
+	if v, _ := shopId.Options.LookupLiteral("(validate.rules).string.min_len"); v != "1" {
+		t.Errorf("string.min_len expect %q, got %q", "1", v)
+	}
+	if name := getProtoJsonName(shopId); name != "shop" {
+		t.Errorf("json name expect %q, got %q", "shop", name)
+	}
+}
+
+func TestParseProtoSource_Errors(t *testing.T) {
+	cases := []struct {
+		source   string
+		expected string
+	}{
+		{`message Order { string id = ; }`, "order.proto:1:29: expected integer, found ';'"},
+		{`message Order {
+  string id = 1
+}`, "order.proto:3:1: expected ';', found '}'"},
+		{`service OrderService { message Order {} }`, "order.proto:1:24: unexpected 'message' in service OrderService"},
+		{`option (a) = "value;`, "order.proto:1:14: string literal not terminated"},
+		{`/* comment`, "order.proto:1:1: comment not terminated"},
+		{`message Order { string id = 1 [(validate.rules).string = {min_len 1}]; }`, "order.proto:1:67: expected ':' or '{', found '1'"},
+	}
+
+	for _, c := range cases {
+		_, err := parseProtoSource("order.proto", c.source)
+		if _, ok := err.(scanner.ErrorList); !ok {
+			t.Errorf("%q: should get scanner.ErrorList, got %v", c.source, err)
+			continue
+		}
+		if !strings.HasPrefix(err.Error(), c.expected) {
+			t.Errorf("%q: error expect %q, got %q", c.source, c.expected, err.Error())
+		}
+	}
+}
@@ -0,0 +1,1001 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/scanner"
+	"go/token"
+	"math"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+const (
+	PROTO_HTTP_OPTION_NAME           string = "(google.api.http)"
+	PROTO_FIELD_BEHAVIOR_OPTION_NAME string = "(google.api.field_behavior)"
+	PROTO_BODY_ALL                   string = "*"
+
+	PROTO_FIELD_KIND_STRING  string = "string"
+	PROTO_FIELD_KIND_INT     string = "int"
+	PROTO_FIELD_KIND_UINT    string = "uint"
+	PROTO_FIELD_KIND_FLOAT   string = "float"
+	PROTO_FIELD_KIND_BOOL    string = "bool"
+	PROTO_FIELD_KIND_ENUM    string = "enum"
+	PROTO_FIELD_KIND_BYTES   string = "bytes"
+	PROTO_FIELD_KIND_MESSAGE string = "message"
+)
+
+var (
+	// the option prefixes of field rules, protoc-gen-validate and protovalidate.
+	protoRuleOptionNames = []string{
+		"(validate.rules)",
+		"(buf.validate.field)",
+	}
+
+	// the http rule methods and the request methods.
+	protoHttpMethodTable = map[string]string{
+		"get":    "GET",
+		"put":    "PUT",
+		"post":   "POST",
+		"delete": "DELETE",
+		"patch":  "PATCH",
+	}
+
+	// the scalar value types and the kinds.
+	protoScalarKindTable = map[string]string{
+		"string":   PROTO_FIELD_KIND_STRING,
+		"int32":    PROTO_FIELD_KIND_INT,
+		"sint32":   PROTO_FIELD_KIND_INT,
+		"sfixed32": PROTO_FIELD_KIND_INT,
+		"int64":    PROTO_FIELD_KIND_INT,
+		"sint64":   PROTO_FIELD_KIND_INT,
+		"sfixed64": PROTO_FIELD_KIND_INT,
+		"uint32":   PROTO_FIELD_KIND_UINT,
+		"fixed32":  PROTO_FIELD_KIND_UINT,
+		"uint64":   PROTO_FIELD_KIND_UINT,
+		"fixed64":  PROTO_FIELD_KIND_UINT,
+		"float":    PROTO_FIELD_KIND_FLOAT,
+		"double":   PROTO_FIELD_KIND_FLOAT,
+		"bool":     PROTO_FIELD_KIND_BOOL,
+		"bytes":    PROTO_FIELD_KIND_BYTES,
+	}
+
+	protoApiVersionRegexp = regexp.MustCompile(`^v[0-9]+([a-z]+[0-9]*)?$`)
+)
+
+// ProtoRequest describes the request resolved from the http rules of rpcs
+// sharing the same url, e.g: GetOrder and DeleteOrder of "/v1/orders/{id}".
+type ProtoRequest struct {
+	Name    string
+	Url     string
+	Methods []string
+	// the argv of request methods, e.g: GET.
+	Argvs map[string]*ProtoArgv
+	Pos   token.Position
+}
+
+// ProtoArgv describes the argv generated from the request message of rpc.
+type ProtoArgv struct {
+	// the full name of rpc, e.g: order.v1.OrderService.GetOrder.
+	Rpc     string
+	Message string
+	Fields  []*ProtoArgvField
+}
+
+// ProtoArgvField describes the argv field generated from the message field
+// which is not bound by path.
+type ProtoArgvField struct {
+	Name       string
+	Type       string
+	Tag        string
+	Validators []string
+	InQuery    bool
+
+	key string
+	// the value satisfies the validators, nil if the field can be absent.
+	sample interface{}
+}
+
+// IsRawMessage reports whether the field keeps the message or map value as
+// json.RawMessage.
+func (f *ProtoArgvField) IsRawMessage() bool {
+	return f.Type == "json.RawMessage"
+}
+
+func (a *ProtoArgv) HasRawMessageFields() bool {
+	for _, f := range a.Fields {
+		if f.IsRawMessage() {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *ProtoArgv) HasValidators() bool {
+	for _, f := range a.Fields {
+		if len(f.Validators) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// TestQueryString returns the query string of the sample values of query
+// fields, e.g: ?page=1.
+func (a *ProtoArgv) TestQueryString() string {
+	values := make(url.Values)
+	for _, f := range a.Fields {
+		if !f.InQuery || f.sample == nil {
+			continue
+		}
+		values.Set(f.key, formatProtoSampleValue(f.sample))
+	}
+	if len(values) == 0 {
+		return ""
+	}
+	return "?" + values.Encode()
+}
+
+// TestBody returns the json body of the sample values of body fields, the
+// fields are in the order of declaration.
+func (a *ProtoArgv) TestBody() string {
+	var buf bytes.Buffer
+	buf.WriteString("{")
+	for _, f := range a.Fields {
+		if f.InQuery || f.sample == nil {
+			continue
+		}
+		if buf.Len() > 1 {
+			buf.WriteString(",")
+		}
+		key, _ := json.Marshal(f.key)
+		value, _ := json.Marshal(f.sample)
+		buf.Write(key)
+		buf.WriteString(":")
+		buf.Write(value)
+	}
+	buf.WriteString("}")
+	return buf.String()
+}
+
+func formatProtoSampleValue(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+	return fmt.Sprint(v)
+}
+
+// protoHttpBinding is the http rule of rpc, e.g: get: "/v1/orders/{id}".
+type protoHttpBinding struct {
+	method  string
+	pattern string
+	body    string
+}
+
+// resolveProtoRequests resolves the requests of the rpcs annotated with
+// google.api.http in the services of file. The rpcs without http rules are
+// ignored. The requests are sorted by names.
+func resolveProtoRequests(file *ProtoFile) ([]*ProtoRequest, error) {
+	var (
+		requests   []*ProtoRequest
+		requestMap = make(map[string]*ProtoRequest)
+		nameMap    = make(map[string]*ProtoRequest)
+		errs       scanner.ErrorList
+	)
+
+	for _, service := range file.Services {
+		for _, rpc := range service.Rpcs {
+			rpcName := service.Name + "." + rpc.Name
+			if len(file.Package) > 0 {
+				rpcName = file.Package + "." + rpcName
+			}
+
+			bindings, err := resolveProtoHttpBindings(rpc)
+			if err != nil {
+				errs.Add(rpc.Pos, fmt.Sprintf("rpc %s: %v", rpc.Name, err))
+				continue
+			}
+			if len(bindings) == 0 {
+				continue
+			}
+			if rpc.ClientStreaming || rpc.ServerStreaming {
+				errs.Add(rpc.Pos, fmt.Sprintf("rpc %s: streaming rpc is not supported", rpc.Name))
+				continue
+			}
+			message := file.LookupMessage(rpc.InputType, "")
+			if message == nil {
+				errs.Add(rpc.Pos, fmt.Sprintf("rpc %s: cannot resolve request message '%s'", rpc.Name, rpc.InputType))
+				continue
+			}
+
+			for _, binding := range bindings {
+				url, pathFields, err := resolveProtoHttpPath(file, message, binding.pattern)
+				if err != nil {
+					errs.Add(rpc.Pos, fmt.Sprintf("rpc %s: %v", rpc.Name, err))
+					continue
+				}
+
+				argv, err := resolveProtoArgv(file, message, binding, pathFields)
+				if err != nil {
+					errs.Add(rpc.Pos, fmt.Sprintf("rpc %s: %v", rpc.Name, err))
+					continue
+				}
+				argv.Rpc = rpcName
+
+				request, ok := requestMap[url]
+				if !ok {
+					request = &ProtoRequest{
+						Name:  getProtoRequestName(binding.pattern),
+						Url:   url,
+						Argvs: make(map[string]*ProtoArgv),
+						Pos:   rpc.Pos,
+					}
+					if other, ok := nameMap[request.Name]; ok {
+						errs.Add(rpc.Pos, fmt.Sprintf("rpc %s: request name '%s' of url '%s' conflicts with url '%s'", rpc.Name, request.Name, url, other.Url))
+						continue
+					}
+					requestMap[url] = request
+					nameMap[request.Name] = request
+					requests = append(requests, request)
+				}
+				if other, ok := request.Argvs[binding.method]; ok {
+					errs.Add(rpc.Pos, fmt.Sprintf("rpc %s: %s %s is bound by rpc %s", rpc.Name, binding.method, url, other.Rpc))
+					continue
+				}
+				request.Methods = append(request.Methods, binding.method)
+				request.Argvs[binding.method] = argv
+			}
+		}
+	}
+
+	sort.Slice(requests, func(i, j int) bool {
+		return requests[i].Name < requests[j].Name
+	})
+	errs.Sort()
+	return requests, errs.Err()
+}
+
+// resolveProtoHttpBindings resolves the http rule and its additional
+// bindings of rpc.
+func resolveProtoHttpBindings(rpc *ProtoRpc) ([]*protoHttpBinding, error) {
+	var (
+		bindings []*protoHttpBinding
+		rules    = rpc.Options.Lookup(PROTO_HTTP_OPTION_NAME)
+		// the rule declared by fields, e.g: option (google.api.http).get = "/v1/orders";
+		fieldRule *ProtoValue
+	)
+	for _, opt := range rpc.Options {
+		if name := strings.TrimPrefix(opt.Name, PROTO_HTTP_OPTION_NAME+"."); name != opt.Name {
+			if fieldRule == nil {
+				fieldRule = &ProtoValue{}
+				rules = append(rules, fieldRule)
+			}
+			fieldRule.Fields = append(fieldRule.Fields, &ProtoOption{Name: name, Value: opt.Value})
+		}
+	}
+
+	for _, rule := range rules {
+		binding, err := resolveProtoHttpBinding(rule)
+		if err != nil {
+			return nil, err
+		}
+		bindings = append(bindings, binding)
+
+		for _, additional := range rule.Fields.Lookup("additional_bindings") {
+			binding, err := resolveProtoHttpBinding(additional)
+			if err != nil {
+				return nil, err
+			}
+			bindings = append(bindings, binding)
+		}
+	}
+	return bindings, nil
+}
+
+func resolveProtoHttpBinding(rule *ProtoValue) (*protoHttpBinding, error) {
+	binding := new(protoHttpBinding)
+	for _, opt := range rule.Fields {
+		switch opt.Name {
+		case "get", "put", "post", "delete", "patch":
+			if len(binding.method) > 0 {
+				return nil, fmt.Errorf("http rule has multiple patterns")
+			}
+			binding.method = protoHttpMethodTable[opt.Name]
+			binding.pattern = opt.Value.Literal
+		case "custom":
+			return nil, fmt.Errorf("custom http rule is not supported")
+		case "body":
+			binding.body = opt.Value.Literal
+		case "response_body", "selector", "additional_bindings":
+		default:
+			return nil, fmt.Errorf("unknown http rule field '%s'", opt.Name)
+		}
+	}
+	if len(binding.method) == 0 {
+		return nil, fmt.Errorf("http rule has no pattern")
+	}
+
+	switch binding.body {
+	case "":
+	case PROTO_BODY_ALL:
+		if !requestMethodTable[binding.method] {
+			return nil, fmt.Errorf("%s %s should not have body", binding.method, binding.pattern)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported body '%s', should be '*' or absent", binding.body)
+	}
+	return binding, nil
+}
+
+// resolveProtoHttpPath converts the path template to the request url, e.g:
+// "/v1/orders/{id}" to "/v1/orders/{id:int}" if id is an integer. It returns
+// the message fields bound by path.
+func resolveProtoHttpPath(file *ProtoFile, message *ProtoMessage, pattern string) (string, map[string]bool, error) {
+	if !strings.HasPrefix(pattern, "/") {
+		return "", nil, fmt.Errorf("invalid path template '%s'", pattern)
+	}
+	if pos := strings.LastIndexByte(pattern, ':'); pos > strings.LastIndexByte(pattern, '}') {
+		return "", nil, fmt.Errorf("custom verb of path template '%s' is not supported", pattern)
+	}
+
+	for _, match := range pathParamRegexp.FindAllStringSubmatch(pattern, -1) {
+		if strings.ContainsAny(match[1], "=.") {
+			return "", nil, fmt.Errorf("path variable '%s' of '%s' is not supported, should be a top-level field", match[1], pattern)
+		}
+	}
+
+	var (
+		segments   = strings.Split(pattern[1:], "/")
+		pathFields = make(map[string]bool)
+	)
+	for i, segment := range segments {
+		if strings.ContainsAny(segment, "*") {
+			return "", nil, fmt.Errorf("wildcard of path template '%s' is not supported", pattern)
+		}
+		if !strings.HasPrefix(segment, "{") {
+			if strings.ContainsAny(segment, "{}") {
+				return "", nil, fmt.Errorf("invalid path template '%s'", pattern)
+			}
+			continue
+		}
+		if !strings.HasSuffix(segment, "}") {
+			return "", nil, fmt.Errorf("invalid path template '%s'", pattern)
+		}
+
+		name := segment[1 : len(segment)-1]
+		field := message.LookupField(name)
+		if field == nil {
+			return "", nil, fmt.Errorf("path variable '%s' of '%s' is not a field of %s", name, pattern, message.Name)
+		}
+		if field.Repeated || len(field.MapKey) > 0 {
+			return "", nil, fmt.Errorf("path variable '%s' of '%s' should be a scalar field", name, pattern)
+		}
+
+		switch kind := file.FieldKind(field); kind {
+		case PROTO_FIELD_KIND_STRING:
+			segments[i] = "{" + name + "}"
+		case PROTO_FIELD_KIND_INT, PROTO_FIELD_KIND_UINT:
+			segments[i] = "{" + name + ":" + PATH_PARAM_TYPE_INT + "}"
+		default:
+			return "", nil, fmt.Errorf("path variable '%s' of '%s' should be a string or integer field", name, pattern)
+		}
+		pathFields[name] = true
+	}
+
+	url := "/" + strings.Join(segments, "/")
+	if _, err := parsePathParams(url); err != nil {
+		return "", nil, err
+	}
+	return url, pathFields, nil
+}
+
+// resolveProtoArgv resolves the argv fields from the message fields not
+// bound by path. The fields are bound from the json body if the body is "*",
+// otherwise from the query string.
+func resolveProtoArgv(file *ProtoFile, message *ProtoMessage, binding *protoHttpBinding, pathFields map[string]bool) (*ProtoArgv, error) {
+	argv := &ProtoArgv{
+		Message: message.Name,
+	}
+	if len(file.Package) > 0 {
+		argv.Message = file.Package + "." + message.Name
+	}
+
+	var (
+		hasBody = requestMethodTable[binding.method]
+		visited = make(map[string]bool)
+	)
+	for _, field := range message.Fields {
+		if pathFields[field.Name] {
+			continue
+		}
+
+		argvField, err := resolveProtoArgvField(file, field, binding.body != PROTO_BODY_ALL)
+		if err != nil {
+			return nil, err
+		}
+		if visited[argvField.Name] {
+			return nil, fmt.Errorf("field '%s' of %s conflicts with argv field '%s'", field.Name, message.Name, argvField.Name)
+		}
+		visited[argvField.Name] = true
+
+		// the argv of request with body is tagged by json
+		if argvField.InQuery && hasBody {
+			argvField.Tag += ` ^:"query"`
+		}
+		argv.Fields = append(argv.Fields, argvField)
+	}
+	return argv, nil
+}
+
+// resolveProtoArgvField maps the message field to the argv field. The query
+// fields are pointers to tell the absent values, and only the scalar fields
+// can be bound from query string.
+func resolveProtoArgvField(file *ProtoFile, field *ProtoField, inQuery bool) (*ProtoArgvField, error) {
+	var (
+		kind     = file.FieldKind(field)
+		fieldKey = getProtoJsonName(field)
+		required = isProtoFieldRequired(field)
+	)
+	if inQuery {
+		fieldKey = field.Name
+	}
+
+	argvField := &ProtoArgvField{
+		Name:    getPathParamFieldName(field.Name),
+		InQuery: inQuery,
+		key:     fieldKey,
+	}
+
+	var (
+		elemType string
+		rules    = newProtoFieldRules(field.Options)
+	)
+	switch kind {
+	case PROTO_FIELD_KIND_STRING, PROTO_FIELD_KIND_ENUM:
+		elemType = "string"
+	case PROTO_FIELD_KIND_INT:
+		elemType = "int64"
+		if field.Type == "int32" || field.Type == "sint32" || field.Type == "sfixed32" {
+			elemType = "int32"
+		}
+	case PROTO_FIELD_KIND_UINT:
+		elemType = "int64"
+	case PROTO_FIELD_KIND_FLOAT:
+		elemType = "float64"
+	case PROTO_FIELD_KIND_BOOL:
+		elemType = "bool"
+	case PROTO_FIELD_KIND_BYTES:
+		elemType = "[]byte"
+	default:
+		elemType = "json.RawMessage"
+	}
+
+	switch {
+	case len(field.MapKey) > 0:
+		argvField.Type = "json.RawMessage"
+	case field.Repeated:
+		argvField.Type = "[]" + elemType
+		if kind == PROTO_FIELD_KIND_BYTES || kind == PROTO_FIELD_KIND_MESSAGE {
+			argvField.Type = "json.RawMessage"
+		}
+	case inQuery:
+		argvField.Type = "*" + elemType
+	default:
+		argvField.Type = elemType
+	}
+
+	if inQuery && (field.Repeated || len(field.MapKey) > 0 || kind == PROTO_FIELD_KIND_BYTES || kind == PROTO_FIELD_KIND_MESSAGE) {
+		return nil, fmt.Errorf("field '%s' of type '%s' cannot be bound from query string, use body \"*\"", field.Name, field.Type)
+	}
+
+	// the tag of required json field is marked with '*'
+	if inQuery {
+		argvField.Tag = fmt.Sprintf(`query:"%s"`, fieldKey)
+	} else if required {
+		argvField.Tag = fmt.Sprintf(`json:"*%s"`, fieldKey)
+	} else {
+		argvField.Tag = fmt.Sprintf(`json:"%s"`, fieldKey)
+	}
+
+	switch {
+	case field.Repeated || len(field.MapKey) > 0:
+		resolveProtoCollectionValidators(argvField, field, kind, rules, required)
+	case kind == PROTO_FIELD_KIND_STRING:
+		resolveProtoStringValidators(argvField, rules, "string", required)
+	case kind == PROTO_FIELD_KIND_ENUM:
+		resolveProtoEnumValidators(argvField, file.LookupEnum(field.Type, field.Scope), rules, required)
+	case kind == PROTO_FIELD_KIND_INT, kind == PROTO_FIELD_KIND_UINT:
+		resolveProtoIntValidators(argvField, rules, field.Type, kind == PROTO_FIELD_KIND_UINT, required)
+	case kind == PROTO_FIELD_KIND_FLOAT:
+		resolveProtoFloatValidators(argvField, rules, field.Type, required)
+	case kind == PROTO_FIELD_KIND_BOOL:
+		if required {
+			argvField.sample = true
+		}
+	case kind == PROTO_FIELD_KIND_BYTES:
+		if n, _ := rules.int("bytes.min_len"); required || n > 0 {
+			argvField.Validators = append(argvField.Validators, "arg.Slices.NonEmpty")
+			argvField.sample = []byte("hello")
+		}
+	default:
+		if required || rules.bool("message.required") {
+			argvField.Validators = append(argvField.Validators, "arg.Values.NotNil")
+			argvField.sample = json.RawMessage("{}")
+		}
+	}
+	return argvField, nil
+}
+
+func resolveProtoStringValidators(f *ProtoArgvField, rules protoFieldRules, key string, required bool) {
+	var (
+		assertion = "arg.Strings"
+		sample    = "hello"
+		has       = required
+	)
+	if f.InQuery {
+		assertion = "arg.StringPtr"
+	}
+
+	if required {
+		f.Validators = append(f.Validators, assertion+".NonEmpty")
+	}
+	if n, ok := rules.int(key + ".len"); ok {
+		f.Validators = append(f.Validators, fmt.Sprintf("%s.MinLength(%d)", assertion, n), fmt.Sprintf("%s.MaxLength(%d)", assertion, n))
+		sample = resizeProtoSampleString(sample, int(n), int(n))
+		has = true
+	}
+	minLen, hasMin := rules.int(key + ".min_len")
+	if hasMin {
+		f.Validators = append(f.Validators, fmt.Sprintf("%s.MinLength(%d)", assertion, minLen))
+		has = true
+	}
+	maxLen, hasMax := rules.int(key + ".max_len")
+	if hasMax {
+		f.Validators = append(f.Validators, fmt.Sprintf("%s.MaxLength(%d)", assertion, maxLen))
+		has = true
+	}
+	if hasMin || hasMax {
+		if !hasMax {
+			maxLen = math.MaxInt32
+		}
+		sample = resizeProtoSampleString(sample, int(minLen), int(maxLen))
+	}
+	if values := rules.strings(key + ".in"); len(values) > 0 {
+		f.Validators = append(f.Validators, fmt.Sprintf("%s.In(%s)", assertion, joinQuotedStrings(values)))
+		sample = values[0]
+		has = true
+	}
+	if pattern, ok := rules.string(key + ".pattern"); ok {
+		f.Validators = append(f.Validators, fmt.Sprintf("%s.MatchAny(%s)", assertion, strconv.Quote(pattern)))
+		has = true
+	}
+	if rules.bool(key + ".email") {
+		f.Validators = append(f.Validators, assertion+".MatchAny(arg.EmailPattern)")
+		sample = "hello@example.com"
+		has = true
+	}
+	if has {
+		f.sample = sample
+	}
+}
+
+func resolveProtoEnumValidators(f *ProtoArgvField, enum *ProtoEnum, rules protoFieldRules, required bool) {
+	assertion := "arg.Strings"
+	if f.InQuery {
+		assertion = "arg.StringPtr"
+	}
+
+	if required {
+		f.Validators = append(f.Validators, assertion+".NonEmpty")
+	}
+	if enum == nil {
+		if required {
+			f.sample = "hello"
+		}
+		return
+	}
+
+	values := enum.Values
+	if rules.bool("enum.defined_only") {
+		f.Validators = append(f.Validators, fmt.Sprintf("%s.In(%s)", assertion, joinQuotedStrings(values)))
+	} else if !required {
+		return
+	}
+	// prefer the value other than the default
+	f.sample = values[0]
+	for i, n := range enum.Numbers {
+		if n != 0 {
+			f.sample = values[i]
+			break
+		}
+	}
+}
+
+func resolveProtoIntValidators(f *ProtoArgvField, rules protoFieldRules, key string, unsigned bool, required bool) {
+	var (
+		assertion = "arg.Ints"
+		has       = required
+		low       = int64(math.MinInt64)
+		high      = int64(math.MaxInt64)
+	)
+	if f.InQuery {
+		assertion = "arg.IntPtr"
+	}
+
+	if required && f.InQuery {
+		f.Validators = append(f.Validators, assertion+".NotNil")
+	}
+	if unsigned {
+		f.Validators = append(f.Validators, assertion+".NonNegativeInteger")
+		low = 0
+	}
+	if n, ok := rules.int(key + ".gte"); ok {
+		f.Validators = append(f.Validators, fmt.Sprintf("%s.GreaterOrEqual(%d)", assertion, n))
+		low, has = n, true
+	}
+	if n, ok := rules.int(key + ".gt"); ok {
+		f.Validators = append(f.Validators, fmt.Sprintf("%s.GreaterOrEqual(%d)", assertion, n+1))
+		low, has = n+1, true
+	}
+	if n, ok := rules.int(key + ".lte"); ok {
+		f.Validators = append(f.Validators, fmt.Sprintf("%s.LessOrEqual(%d)", assertion, n))
+		high, has = n, true
+	}
+	if n, ok := rules.int(key + ".lt"); ok {
+		f.Validators = append(f.Validators, fmt.Sprintf("%s.LessOrEqual(%d)", assertion, n-1))
+		high, has = n-1, true
+	}
+
+	sample := int64(1)
+	if sample < low {
+		sample = low
+	}
+	if sample > high {
+		sample = high
+	}
+	if values := rules.ints(key + ".in"); len(values) > 0 {
+		f.Validators = append(f.Validators, fmt.Sprintf("%s.In(%s)", assertion, joinInts(values)))
+		sample, has = values[0], true
+	}
+	if values := rules.ints(key + ".not_in"); len(values) > 0 {
+		f.Validators = append(f.Validators, fmt.Sprintf("%s.NotIn(%s)", assertion, joinInts(values)))
+		for containsInt(values, sample) && sample < high {
+			sample++
+		}
+		has = true
+	}
+	if has {
+		f.sample = sample
+	}
+}
+
+func resolveProtoFloatValidators(f *ProtoArgvField, rules protoFieldRules, key string, required bool) {
+	var (
+		assertion = "arg.Floats"
+		has       = required
+		low       = math.Inf(-1)
+		high      = math.Inf(1)
+	)
+	if f.InQuery {
+		assertion = "arg.FloatPtr"
+	}
+
+	if required && f.InQuery {
+		f.Validators = append(f.Validators, assertion+".NotNil")
+	}
+	if n, ok := rules.float(key + ".gte"); ok {
+		f.Validators = append(f.Validators, fmt.Sprintf("%s.GreaterOrEqual(%s)", assertion, formatFloat(n)))
+		low, has = n, true
+	}
+	if n, ok := rules.float(key + ".gt"); ok {
+		f.Validators = append(f.Validators, fmt.Sprintf("%s.Greater(%s)", assertion, formatFloat(n)))
+		low, has = math.Nextafter(n, math.Inf(1)), true
+	}
+	if n, ok := rules.float(key + ".lte"); ok {
+		f.Validators = append(f.Validators, fmt.Sprintf("%s.LessOrEqual(%s)", assertion, formatFloat(n)))
+		high, has = n, true
+	}
+	if n, ok := rules.float(key + ".lt"); ok {
+		f.Validators = append(f.Validators, fmt.Sprintf("%s.Less(%s)", assertion, formatFloat(n)))
+		high, has = math.Nextafter(n, math.Inf(-1)), true
+	}
+
+	sample := 1.0
+	if sample < low || sample > high {
+		switch {
+		case math.IsInf(low, -1):
+			sample = math.Floor(high)
+		case math.IsInf(high, 1):
+			sample = math.Ceil(low)
+		default:
+			sample = low + (high-low)/2
+		}
+	}
+	if has {
+		f.sample = sample
+	}
+}
+
+// resolveProtoCollectionValidators resolves the validators of the repeated
+// and map fields, only the non-empty rules are supported.
+func resolveProtoCollectionValidators(f *ProtoArgvField, field *ProtoField, kind string, rules protoFieldRules, required bool) {
+	key := "repeated.min_items"
+	if len(field.MapKey) > 0 {
+		key = "map.min_pairs"
+	}
+	n, _ := rules.int(key)
+	if !required && n <= 0 {
+		return
+	}
+
+	if f.IsRawMessage() {
+		f.Validators = append(f.Validators, "arg.Values.NotNil")
+		if len(field.MapKey) > 0 {
+			f.sample = json.RawMessage("{}")
+		} else {
+			f.sample = json.RawMessage("[{}]")
+		}
+		return
+	}
+
+	f.Validators = append(f.Validators, "arg.Slices.NonEmpty")
+	var elem interface{}
+	switch kind {
+	case PROTO_FIELD_KIND_STRING, PROTO_FIELD_KIND_ENUM:
+		elem = "hello"
+	case PROTO_FIELD_KIND_INT, PROTO_FIELD_KIND_UINT:
+		elem = 1
+	case PROTO_FIELD_KIND_FLOAT:
+		elem = 1.0
+	case PROTO_FIELD_KIND_BOOL:
+		elem = true
+	}
+	f.sample = []interface{}{elem}
+}
+
+// isProtoFieldRequired reports whether the field is annotated as required by
+// google.api.field_behavior or the required rule.
+func isProtoFieldRequired(field *ProtoField) bool {
+	for _, value := range field.Options.Lookup(PROTO_FIELD_BEHAVIOR_OPTION_NAME) {
+		if value.Literal == "REQUIRED" {
+			return true
+		}
+	}
+	return newProtoFieldRules(field.Options).bool("required")
+}
+
+// protoFieldRules looks up the field rules of protoc-gen-validate and
+// protovalidate, e.g: string.min_len of (validate.rules).string.min_len.
+type protoFieldRules ProtoOptions
+
+func newProtoFieldRules(options ProtoOptions) protoFieldRules {
+	return protoFieldRules(options)
+}
+
+func (rules protoFieldRules) lookup(name string) []*ProtoValue {
+	var values []*ProtoValue
+	for _, prefix := range protoRuleOptionNames {
+		values = append(values, ProtoOptions(rules).Lookup(prefix+"."+name)...)
+	}
+	return values
+}
+
+func (rules protoFieldRules) string(name string) (string, bool) {
+	values := rules.lookup(name)
+	if len(values) == 0 {
+		return "", false
+	}
+	return values[len(values)-1].Literal, true
+}
+
+func (rules protoFieldRules) strings(name string) []string {
+	var values []string
+	for _, v := range rules.lookup(name) {
+		values = append(values, v.Literal)
+	}
+	return values
+}
+
+func (rules protoFieldRules) bool(name string) bool {
+	v, ok := rules.string(name)
+	return ok && v == "true"
+}
+
+func (rules protoFieldRules) int(name string) (int64, bool) {
+	v, ok := rules.string(name)
+	if !ok {
+		return 0, false
+	}
+	n, err := strconv.ParseInt(v, 0, 64)
+	return n, err == nil
+}
+
+func (rules protoFieldRules) ints(name string) []int64 {
+	var values []int64
+	for _, v := range rules.strings(name) {
+		if n, err := strconv.ParseInt(v, 0, 64); err == nil {
+			values = append(values, n)
+		}
+	}
+	return values
+}
+
+func (rules protoFieldRules) float(name string) (float64, bool) {
+	v, ok := rules.string(name)
+	if !ok {
+		return 0, false
+	}
+	n, err := strconv.ParseFloat(v, 64)
+	return n, err == nil
+}
+
+// LookupMessage resolves the message of type name referred in scope, e.g:
+// Item in message Order refers to Order.Item if it is declared.
+func (f *ProtoFile) LookupMessage(name string, scope string) *ProtoMessage {
+	for _, candidate := range f.resolveTypeNames(name, scope) {
+		if message, ok := f.Messages[candidate]; ok {
+			return message
+		}
+	}
+	return nil
+}
+
+func (f *ProtoFile) LookupEnum(name string, scope string) *ProtoEnum {
+	for _, candidate := range f.resolveTypeNames(name, scope) {
+		if enum, ok := f.Enums[candidate]; ok {
+			return enum
+		}
+	}
+	return nil
+}
+
+// resolveTypeNames returns the candidate names of type name from the
+// innermost scope, the package prefix is removed.
+func (f *ProtoFile) resolveTypeNames(name string, scope string) []string {
+	if strings.HasPrefix(name, ".") {
+		name = name[1:]
+		if len(f.Package) > 0 && strings.HasPrefix(name, f.Package+".") {
+			return []string{name[len(f.Package)+1:]}
+		}
+		return []string{name}
+	}
+
+	var candidates []string
+	for len(scope) > 0 {
+		candidates = append(candidates, scope+"."+name)
+		pos := strings.LastIndexByte(scope, '.')
+		if pos < 0 {
+			break
+		}
+		scope = scope[:pos]
+	}
+	candidates = append(candidates, name)
+	if len(f.Package) > 0 && strings.HasPrefix(name, f.Package+".") {
+		candidates = append(candidates, name[len(f.Package)+1:])
+	}
+	return candidates
+}
+
+// FieldKind returns the kind of field value type. The types not declared in
+// the file, e.g: google.protobuf.Timestamp, are messages.
+func (f *ProtoFile) FieldKind(field *ProtoField) string {
+	if kind, ok := protoScalarKindTable[field.Type]; ok {
+		return kind
+	}
+	if f.LookupEnum(field.Type, field.Scope) != nil {
+		return PROTO_FIELD_KIND_ENUM
+	}
+	return PROTO_FIELD_KIND_MESSAGE
+}
+
+func (m *ProtoMessage) LookupField(name string) *ProtoField {
+	for _, field := range m.Fields {
+		if field.Name == name {
+			return field
+		}
+	}
+	return nil
+}
+
+// getProtoJsonName returns the json name of field, e.g: order_id to orderId.
+func getProtoJsonName(field *ProtoField) string {
+	if name, ok := field.Options.LookupLiteral("json_name"); ok {
+		return name
+	}
+
+	var (
+		sb    strings.Builder
+		upper bool
+	)
+	for _, r := range field.Name {
+		if r == '_' {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+// getProtoRequestName names the request by path template, the version
+// segments are ignored and the path variables are named by "By", e.g:
+// "/v1/orders/{id}/items" to OrdersByIdItemsRequest.
+func getProtoRequestName(pattern string) string {
+	var sb strings.Builder
+	for _, segment := range strings.Split(pattern, "/") {
+		switch {
+		case len(segment) == 0, protoApiVersionRegexp.MatchString(segment):
+			continue
+		case strings.HasPrefix(segment, "{"):
+			sb.WriteString("By")
+			segment = strings.Trim(segment, "{}")
+		}
+		sb.WriteString(getPathParamFieldName(strings.Map(func(r rune) rune {
+			if r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r) {
+				return r
+			}
+			return '_'
+		}, segment)))
+	}
+	if sb.Len() == 0 {
+		sb.WriteString("Root")
+	}
+	return sb.String() + REQUEST_TYPE_SUFFIX
+}
+
+// resizeProtoSampleString pads or truncates the sample string by the length
+// range.
+func resizeProtoSampleString(s string, min, max int) string {
+	if len(s) < min {
+		s += strings.Repeat("o", min-len(s))
+	}
+	if len(s) > max {
+		s = s[:max]
+	}
+	return s
+}
+
+func joinQuotedStrings(values []string) string {
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = strconv.Quote(v)
+	}
+	return strings.Join(quoted, ", ")
+}
+
+func joinInts(values []int64) string {
+	s := make([]string, len(values))
+	for i, v := range values {
+		s[i] = strconv.FormatInt(v, 10)
+	}
+	return strings.Join(s, ", ")
+}
+
+func containsInt(values []int64, v int64) bool {
+	for _, n := range values {
+		if n == v {
+			return true
+		}
+	}
+	return false
+}
+
+func formatFloat(v float64) string {
+	s := strconv.FormatFloat(v, 'g', -1, 64)
+	if !strings.ContainsAny(s, ".eEn") {
+		s += ".0"
+	}
+	return s
+}
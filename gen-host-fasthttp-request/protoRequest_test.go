@@ -0,0 +1,299 @@
+package main
+
+import (
+	"go/scanner"
+	"go/token"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const TEST_PROTO_SOURCE = `syntax = "proto3";
+
+package order.v1;
+
+service OrderService {
+  rpc ListOrders(ListOrdersRequest) returns (ListOrdersResponse) {
+    option (google.api.http) = { get: "/v1/orders" };
+  }
+  rpc CreateOrder(CreateOrderRequest) returns (Order) {
+    option (google.api.http) = { post: "/v1/orders" body: "*" };
+  }
+  rpc GetOrder(GetOrderRequest) returns (Order) {
+    option (google.api.http).get = "/v1/orders/{id}";
+  }
+  rpc DeleteOrder(GetOrderRequest) returns (Order) {
+    option (google.api.http) = {
+      delete: "/v1/orders/{id}"
+      additional_bindings { delete: "/v1/shops/{shop_id}/orders/{id}" }
+    };
+  }
+  rpc Ping(Order) returns (Order);
+}
+
+message ListOrdersRequest {
+  int32 page_size = 1 [(validate.rules).int32 = {gt: 0, lte: 100}];
+  Order.Status status = 2 [(validate.rules).enum.defined_only = true];
+}
+
+message ListOrdersResponse {
+  repeated Order orders = 1;
+}
+
+message GetOrderRequest {
+  int64 id = 1;
+  string shop_id = 2;
+}
+
+message CreateOrderRequest {
+  string customer_email = 1 [(validate.rules).string.email = true, (google.api.field_behavior) = REQUIRED];
+  string name = 2 [(buf.validate.field).string = {min_len: 8, max_len: 32}];
+  repeated string tags = 3 [(validate.rules).repeated.min_items = 1];
+  double amount = 4 [(validate.rules).double = {gte: 0.5, lt: 1}];
+  uint32 quantity = 5;
+  Item item = 6 [(validate.rules).message.required = true];
+  map<string, string> labels = 7;
+  string currency = 8 [json_name = "ccy", (validate.rules).string = {in: ["USD", "EUR"]}];
+
+  message Item {
+    string sku = 1;
+  }
+}
+
+message Order {
+  enum Status {
+    STATUS_UNSPECIFIED = 0;
+    STATUS_OPEN = 1;
+  }
+  int64 id = 1;
+}
+`
+
+func TestResolveProtoRequests(t *testing.T) {
+	file, err := parseProtoSource("order.proto", TEST_PROTO_SOURCE)
+	if err != nil {
+		t.Fatal(err)
+	}
+	requests, err := resolveProtoRequests(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var routes []string
+	for _, request := range requests {
+		routes = append(routes, request.Name+" "+request.Url+" "+strings.Join(request.Methods, ","))
+	}
+	expected := []string{
+		"OrdersByIdRequest /v1/orders/{id:int} GET,DELETE",
+		"OrdersRequest /v1/orders GET,POST",
+		"ShopsByShopIdOrdersByIdRequest /v1/shops/{shop_id}/orders/{id:int} DELETE",
+	}
+	if !reflect.DeepEqual(routes, expected) {
+		t.Errorf("requests expect %v, got %v", expected, routes)
+	}
+
+	// the path fields are not argv fields
+	argv := requests[0].Argvs["DELETE"]
+	if argv.Rpc != "order.v1.OrderService.DeleteOrder" || len(argv.Fields) != 1 || argv.Fields[0].Tag != `query:"shop_id"` {
+		t.Errorf("argv of DELETE /v1/orders/{id} should have query field shop_id, got %+v", argv)
+	}
+	if argv := requests[1].Argvs["GET"]; argv.TestQueryString() != "?page_size=1&status=STATUS_OPEN" {
+		t.Errorf("test query string expect %q, got %q", "?page_size=1&status=STATUS_OPEN", argv.TestQueryString())
+	}
+}
+
+func TestResolveProtoRequests_Errors(t *testing.T) {
+	source := `syntax = "proto3";
+
+service S {
+  rpc A(M) returns (M) { option (google.api.http) = { get: "/v1/a/{name=shelves/*}" }; }
+  rpc B(M) returns (M) { option (google.api.http) = { get: "/v1/b" body: "*" }; }
+  rpc C(M) returns (M) { option (google.api.http) = { custom: { kind: "HEAD" path: "/c" } }; }
+  rpc D(M) returns (stream M) { option (google.api.http) = { get: "/v1/d" }; }
+  rpc E(M) returns (M) { option (google.api.http) = { get: "/v1/e" }; }
+  rpc F(M) returns (M) { option (google.api.http) = { get: "/v1/e" }; }
+  rpc G(N) returns (M) { option (google.api.http) = { get: "/v1/g/{tags}" }; }
+  rpc H(N) returns (M) { option (google.api.http) = { get: "/v1/h" }; }
+  rpc I(M) returns (M) { option (google.api.http) = { get: "/v2/e" }; }
+}
+
+message M {
+  string name = 1;
+}
+
+message N {
+  repeated string tags = 1;
+}
+`
+	file, err := parseProtoSource("s.proto", source)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = resolveProtoRequests(file)
+	errs, ok := err.(scanner.ErrorList)
+	if !ok {
+		t.Fatalf("should get scanner.ErrorList, got %v", err)
+	}
+	expectedErrors := []string{
+		"s.proto:4:3: rpc A: path variable 'name=shelves/*'",
+		"s.proto:5:3: rpc B: GET /v1/b should not have body",
+		"s.proto:6:3: rpc C: custom http rule is not supported",
+		"s.proto:7:3: rpc D: streaming rpc is not supported",
+		"s.proto:9:3: rpc F: GET /v1/e is bound by rpc S.E",
+		"s.proto:10:3: rpc G: path variable 'tags' of '/v1/g/{tags}' should be a scalar field",
+		"s.proto:11:3: rpc H: field 'tags' of type 'string' cannot be bound from query string",
+		"s.proto:12:3: rpc I: request name 'ERequest' of url '/v2/e' conflicts with url '/v1/e'",
+	}
+	if len(errs) != len(expectedErrors) {
+		t.Fatalf("errors expect %d, got %d:\n%v", len(expectedErrors), len(errs), errs)
+	}
+	for i, expected := range expectedErrors {
+		if !strings.HasPrefix(errs[i].Error(), expected) {
+			t.Errorf("error expect %q, got %q", expected, errs[i].Error())
+		}
+	}
+}
+
+func TestGenerateRequestFiles_Proto(t *testing.T) {
+	tmp := t.TempDir()
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert(t, os.Chdir(tmp))
+	defer os.Chdir(wd)
+
+	defaultLayout, defaultAppModuleName, defaultGofile := layout, appModuleName, gofile
+	defer func() {
+		layout, appModuleName, gofile = defaultLayout, defaultAppModuleName, defaultGofile
+		protoRequests = make(map[string]*ProtoRequest)
+	}()
+	layout = newDefaultLayout()
+	appModuleName = "host-fasthttp-request-demo"
+	gofile = "app.go"
+
+	source := strings.ReplaceAll(`package main
+
+type RequestManager struct {
+	*OrdersRequest ”url:"/v1/orders" @methods:"GET,POST"”
+}
+`, "”", "`")
+	assert(t,
+		os.WriteFile(gofile, []byte(source), 0644),
+		os.WriteFile("order.proto", []byte(TEST_PROTO_SOURCE), 0644),
+	)
+
+	assert(t,
+		mergeProtoRequests(gofile, "order.proto"),
+	)
+	// the declared requests are kept
+	content, err := os.ReadFile(gofile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedSource := strings.ReplaceAll(`package main
+
+type RequestManager struct {
+	*OrdersRequest                  ”url:"/v1/orders" @methods:"GET,POST"”
+	*OrdersByIdRequest              ”url:"/v1/orders/{id:int}" @methods:"GET,DELETE"”
+	*ShopsByShopIdOrdersByIdRequest ”url:"/v1/shops/{shop_id}/orders/{id:int}" @methods:"DELETE"”
+}
+`, "”", "`")
+	if string(content) != expectedSource {
+		t.Errorf("app.go expect:\n%s\ngot:\n%s\n", expectedSource, string(content))
+	}
+
+	n, _, err := generateRequestFiles(token.NewFileSet(), parseRequestManager(t, string(content)), layout.HandlerDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 3 {
+		t.Errorf("generated requests expect %d, got %d", 3, n)
+	}
+
+	expectedArgv := strings.ReplaceAll(`package args
+
+import (
+	"encoding/json"
+
+	"github.com/Bofry/arg"
+	"github.com/Bofry/httparg"
+)
+
+var (
+	_ httparg.Validatable = new(OrdersPostArgv)
+)
+
+// OrdersPostArgv is generated from order.v1.CreateOrderRequest of rpc order.v1.OrderService.CreateOrder.
+//
+//go:generate gen-bofry-arg-assertor
+type OrdersPostArgv struct /* tag=json */ {
+	CustomerEmail string          ”json:"*customerEmail"”
+	Name          string          ”json:"name"”
+	Tags          []string        ”json:"tags"”
+	Amount        float64         ”json:"amount"”
+	Quantity      int64           ”json:"quantity"”
+	Item          json.RawMessage ”json:"item"”
+	Labels        json.RawMessage ”json:"labels"”
+	Currency      string          ”json:"ccy"”
+}
+
+// Validate implements httparg.Validatable.
+func (argv *OrdersPostArgv) Validate() error {
+	v := argv.Assertor()
+
+	err := arg.Assert(
+		v.CustomerEmail(arg.Strings.NonEmpty, arg.Strings.MatchAny(arg.EmailPattern)),
+		v.Name(arg.Strings.MinLength(8), arg.Strings.MaxLength(32)),
+		v.Tags(arg.Slices.NonEmpty),
+		v.Amount(arg.Floats.GreaterOrEqual(0.5), arg.Floats.Less(1.0)),
+		v.Quantity(arg.Ints.NonNegativeInteger),
+		v.Item(arg.Values.NotNil),
+		v.Currency(arg.Strings.In("USD", "EUR")),
+	)
+	return err
+}
+`, "”", "`")
+	argv, err := readFile(tmp, "ordersPostArgv.go", "handler/args")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(argv) != expectedArgv {
+		t.Errorf("ordersPostArgv.go expect:\n%s\ngot:\n%s\n", expectedArgv, string(argv))
+	}
+
+	for _, c := range []struct {
+		filename string
+		subDir   string
+		contains []string
+	}{
+		{"ordersGetArgv.go", "handler/args", []string{
+			"type OrdersGetArgv struct /* tag=query */ {",
+			"PageSize *int32  `query:\"page_size\"`",
+			`v.PageSize(arg.IntPtr.GreaterOrEqual(1), arg.IntPtr.LessOrEqual(100)),`,
+			`v.Status(arg.StringPtr.In("STATUS_UNSPECIFIED", "STATUS_OPEN")),`,
+		}},
+		{"ordersByIdDeleteArgv.go", "handler/args", []string{
+			"Id     int64   `path:\"id\" ^:\"path\"`",
+			"ShopId *string `query:\"shop_id\"`",
+			`v.Id(arg.Ints.NonNegativeInteger),`,
+		}},
+		{"ordersRequest_test.go", "handler", []string{
+			`ctx.Request.SetRequestURI("/v1/orders?page_size=1&status=STATUS_OPEN")`,
+			"ctx.Request.SetBodyString(`{\"customerEmail\":\"hello@example.com\",\"name\":\"helloooo\",\"tags\":[\"hello\"],\"amount\":0.75,\"item\":{},\"ccy\":\"USD\"}`)",
+		}},
+	} {
+		content, err := readFile(tmp, c.filename, c.subDir)
+		if err != nil {
+			t.Errorf("%s should be generated", c.filename)
+			continue
+		}
+		for _, s := range c.contains {
+			if !strings.Contains(string(content), s) {
+				t.Errorf("%s should contain %q, got:\n%s\n", c.filename, s, string(content))
+			}
+		}
+	}
+}
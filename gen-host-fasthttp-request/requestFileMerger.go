@@ -0,0 +1,324 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// RequestFileMerger merges the methods, fields and imports of the generated
+// request file which are missing in the existing request file. The existing
+// declarations, e.g: the method bodies written by user, are never changed.
+type RequestFileMerger struct {
+	Filename    string
+	RequestName string
+	Generated   []byte
+	// the names of the functions merged besides the request methods, e.g:
+	// the tests of the methods added into the request file.
+	Functions []string
+}
+
+// sourceReplacement replaces the source between offset and end with text.
+type sourceReplacement struct {
+	offset int
+	end    int
+	text   string
+}
+
+// Merge returns the names of the methods, fields and functions added into
+// the file.
+func (m *RequestFileMerger) Merge() ([]string, error) {
+	added, content, err := m.MergeContent()
+	if err != nil {
+		return nil, err
+	}
+	if len(added) == 0 {
+		return nil, nil
+	}
+	return added, writeFileAtomic(m.Filename, content)
+}
+
+// MergeContent returns the names of the methods, fields and functions added
+// and the merged content without writing the file.
+func (m *RequestFileMerger) MergeContent() ([]string, []byte, error) {
+	src, err := os.ReadFile(m.Filename)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, m.Filename, src, parser.ParseComments)
+	if err != nil {
+		return nil, nil, err
+	}
+	genFset := token.NewFileSet()
+	gen, err := parser.ParseFile(genFset, "", m.Generated, parser.ParseComments)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var (
+		added        []string
+		replacements []sourceReplacement
+		appendix     bytes.Buffer
+		referenced   = make(map[string]bool)
+		genSource    = func(from, to token.Pos) string {
+			return string(m.Generated[genFset.Position(from).Offset:genFset.Position(to).Offset])
+		}
+		collectRefs = func(node ast.Node) {
+			ast.Inspect(node, func(n ast.Node) bool {
+				if sel, ok := n.(*ast.SelectorExpr); ok {
+					if ident, ok := sel.X.(*ast.Ident); ok {
+						referenced[ident.Name] = true
+					}
+				}
+				return true
+			})
+		}
+	)
+
+	// fields
+	if _, genStruct := lookupStructType(gen, m.RequestName); genStruct != nil {
+		if existDecl, existStruct := lookupStructType(f, m.RequestName); existStruct != nil {
+			var (
+				fields = make(map[string]bool)
+				buf    bytes.Buffer
+			)
+			for _, field := range existStruct.Fields.List {
+				for _, name := range getFieldNames(field) {
+					fields[name] = true
+				}
+			}
+
+			for _, field := range genStruct.Fields.List {
+				names := getFieldNames(field)
+				if len(names) == 0 || fields[names[0]] {
+					continue
+				}
+				buf.WriteString(genSource(field.Pos(), field.End()) + "\n")
+				added = append(added, names[0])
+				collectRefs(field)
+			}
+
+			// rewrite the type declaration only to align the added fields
+			if buf.Len() > 0 {
+				var (
+					start   = fset.Position(existDecl.Pos()).Offset
+					closing = fset.Position(existStruct.Fields.Closing).Offset
+					end     = fset.Position(existDecl.End()).Offset
+				)
+				text := buf.String()
+				if src[closing-1] != '\n' {
+					text = "\n" + text
+				}
+				decl, err := formatDecl(string(src[start:closing]) + text + string(src[closing:end]))
+				if err != nil {
+					return nil, nil, err
+				}
+				replacements = append(replacements, sourceReplacement{start, end, decl})
+			}
+		}
+	}
+
+	// methods and functions
+	{
+		var (
+			methods   = make(map[string]bool)
+			functions = make(map[string]bool)
+			merging   = make(map[string]bool)
+		)
+		for _, name := range m.Functions {
+			merging[name] = true
+		}
+		for _, decl := range f.Decls {
+			if fn, ok := decl.(*ast.FuncDecl); ok {
+				if isRequestMethod(fn, m.RequestName) {
+					methods[fn.Name.Name] = true
+				} else if fn.Recv == nil {
+					functions[fn.Name.Name] = true
+				}
+			}
+		}
+
+		for _, decl := range gen.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok {
+				continue
+			}
+			if isRequestMethod(fn, m.RequestName) {
+				if methods[fn.Name.Name] {
+					continue
+				}
+			} else if fn.Recv != nil || !merging[fn.Name.Name] || functions[fn.Name.Name] {
+				continue
+			}
+			start := fn.Pos()
+			if fn.Doc != nil {
+				start = fn.Doc.Pos()
+			}
+			appendix.WriteString("\n" + genSource(start, fn.End()) + "\n")
+			added = append(added, fn.Name.Name)
+			collectRefs(fn)
+		}
+	}
+
+	if len(added) == 0 {
+		return nil, src, nil
+	}
+
+	// imports
+	{
+		imports := make(map[string]bool)
+		for _, spec := range f.Imports {
+			if importPath, err := strconv.Unquote(spec.Path.Value); err == nil {
+				imports[importPath] = true
+			}
+		}
+
+		var specs []string
+		for _, spec := range gen.Imports {
+			importPath, err := strconv.Unquote(spec.Path.Value)
+			if err != nil || imports[importPath] {
+				continue
+			}
+			name := path.Base(importPath)
+			if spec.Name != nil {
+				name = spec.Name.Name
+			}
+			if !referenced[name] {
+				continue
+			}
+			if spec.Name != nil {
+				specs = append(specs, fmt.Sprintf("%s %s", spec.Name.Name, spec.Path.Value))
+			} else {
+				specs = append(specs, spec.Path.Value)
+			}
+		}
+
+		if len(specs) > 0 {
+			replacements = append(replacements, m.importInsertion(fset, f, specs))
+		}
+	}
+
+	// apply replacements from the end to keep the offsets
+	sort.Slice(replacements, func(i, j int) bool {
+		return replacements[i].offset > replacements[j].offset
+	})
+	content := append([]byte(nil), src...)
+	for _, v := range replacements {
+		content = append(content[:v.offset], append([]byte(v.text), content[v.end:]...)...)
+	}
+	content = append(content, appendix.Bytes()...)
+
+	// NOTE: the content is not reformatted to keep the existing source as is
+	_, err = parser.ParseFile(token.NewFileSet(), m.Filename, content, parser.AllErrors)
+	if err != nil {
+		return nil, nil, err
+	}
+	return added, content, nil
+}
+
+func (m *RequestFileMerger) importInsertion(fset *token.FileSet, f *ast.File, specs []string) sourceReplacement {
+	for _, decl := range f.Decls {
+		genDecl, ok := decl.(*ast.GenDecl)
+		if !ok || genDecl.Tok != token.IMPORT {
+			continue
+		}
+		if genDecl.Lparen.IsValid() {
+			var buf bytes.Buffer
+			for _, spec := range specs {
+				buf.WriteString("\t" + spec + "\n")
+			}
+			offset := fset.Position(genDecl.Rparen).Offset
+			return sourceReplacement{offset, offset, buf.String()}
+		}
+		var buf bytes.Buffer
+		for _, spec := range specs {
+			buf.WriteString("\nimport " + spec)
+		}
+		offset := fset.Position(genDecl.End()).Offset
+		return sourceReplacement{offset, offset, buf.String()}
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("\n")
+	for _, spec := range specs {
+		buf.WriteString("\nimport " + spec)
+	}
+	offset := fset.Position(f.Name.End()).Offset
+	return sourceReplacement{offset, offset, buf.String()}
+}
+
+// formatDecl formats the source of the declaration.
+func formatDecl(decl string) (string, error) {
+	const header = "package p\n\n"
+
+	content, err := format.Source([]byte(header + decl))
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(strings.TrimPrefix(string(content), header), "\n"), nil
+}
+
+func lookupStructType(f *ast.File, typeName string) (*ast.GenDecl, *ast.StructType) {
+	for _, decl := range f.Decls {
+		genDecl, ok := decl.(*ast.GenDecl)
+		if !ok || genDecl.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range genDecl.Specs {
+			typeSpec, ok := spec.(*ast.TypeSpec)
+			if !ok || typeSpec.Name.Name != typeName {
+				continue
+			}
+			if structType, ok := typeSpec.Type.(*ast.StructType); ok {
+				return genDecl, structType
+			}
+		}
+	}
+	return nil, nil
+}
+
+func isRequestMethod(fn *ast.FuncDecl, typeName string) bool {
+	if fn.Recv == nil || len(fn.Recv.List) == 0 {
+		return false
+	}
+	expr := fn.Recv.List[0].Type
+	if star, ok := expr.(*ast.StarExpr); ok {
+		expr = star.X
+	}
+	ident, ok := expr.(*ast.Ident)
+	return ok && ident.Name == typeName
+}
+
+// getFieldNames returns the field names, the embedded field is named by
+// its type name.
+func getFieldNames(field *ast.Field) []string {
+	if len(field.Names) > 0 {
+		names := make([]string, 0, len(field.Names))
+		for _, name := range field.Names {
+			names = append(names, name.Name)
+		}
+		return names
+	}
+
+	expr := field.Type
+	if star, ok := expr.(*ast.StarExpr); ok {
+		expr = star.X
+	}
+	switch t := expr.(type) {
+	case *ast.Ident:
+		return []string{t.Name}
+	case *ast.SelectorExpr:
+		return []string{t.Sel.Name}
+	}
+	return nil
+}
@@ -0,0 +1,261 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path"
+	"reflect"
+	"testing"
+)
+
+var (
+	_FILE_MERGING_ORDER_REQUEST_GO = `package handler
+
+import (
+	"log"
+	"host-fasthttp-request-demo/handler/args"
+	. "host-fasthttp-request-demo/internal"
+
+	"github.com/Bofry/host-fasthttp/response"
+	"github.com/Bofry/host-fasthttp/tracing"
+	"github.com/Bofry/httparg"
+	"github.com/valyala/fasthttp"
+)
+
+type OrderRequest struct {
+	ServiceProvider *ServiceProvider
+}
+
+func (r *OrderRequest) Init() {
+	r.ServiceProvider.ConfigureLogger(log.Default())
+}
+
+func (r *OrderRequest) Get(ctx *fasthttp.RequestCtx) {
+	sp := tracing.SpanFromRequestCtx(ctx)
+	_ = sp
+
+	argv := args.OrderGetArgv{}
+
+	httparg.Args(&argv).
+		ProcessQueryString(ctx.QueryArgs().String()).
+		Validate()
+
+	response.Text.Success(ctx, "order")
+}
+`
+
+	_FILE_MERGING_CHAT_REQUEST_GO = `package handler
+
+import (
+	"log"
+	"host-fasthttp-request-demo/handler/args"
+	. "host-fasthttp-request-demo/internal"
+
+	"github.com/Bofry/host-fasthttp/response"
+	"github.com/Bofry/host-fasthttp/tracing"
+	"github.com/Bofry/httparg"
+	"github.com/valyala/fasthttp"
+)
+
+type ChatRequest struct {
+	ServiceProvider *ServiceProvider
+}
+
+func (r *ChatRequest) Init() {
+	r.ServiceProvider.ConfigureLogger(log.Default())
+}
+
+func (r *ChatRequest) Get(ctx *fasthttp.RequestCtx) {
+	sp := tracing.SpanFromRequestCtx(ctx)
+	_ = sp
+
+	argv := args.ChatGetArgv{}
+
+	httparg.Args(&argv).
+		ProcessQueryString(ctx.QueryArgs().String()).
+		Validate()
+
+	response.Text.Success(ctx, "OK")
+}
+`
+
+	_EXPECT_FILE_MERGED_ORDER_REQUEST_GO = `package handler
+
+import (
+	"log"
+	"host-fasthttp-request-demo/handler/args"
+	. "host-fasthttp-request-demo/internal"
+
+	"github.com/Bofry/host-fasthttp/response"
+	"github.com/Bofry/host-fasthttp/tracing"
+	"github.com/Bofry/httparg"
+	"github.com/valyala/fasthttp"
+)
+
+type OrderRequest struct {
+	ServiceProvider *ServiceProvider
+}
+
+func (r *OrderRequest) Init() {
+	r.ServiceProvider.ConfigureLogger(log.Default())
+}
+
+func (r *OrderRequest) Get(ctx *fasthttp.RequestCtx) {
+	sp := tracing.SpanFromRequestCtx(ctx)
+	_ = sp
+
+	argv := args.OrderGetArgv{}
+
+	httparg.Args(&argv).
+		ProcessQueryString(ctx.QueryArgs().String()).
+		Validate()
+
+	response.Text.Success(ctx, "order")
+}
+
+func (r *OrderRequest) Delete(ctx *fasthttp.RequestCtx) {
+	sp := tracing.SpanFromRequestCtx(ctx)
+	_ = sp
+
+	argv := args.OrderDeleteArgv{}
+
+	httparg.Args(&argv).
+		ProcessQueryString(ctx.QueryArgs().String()).
+		Validate()
+
+	response.Text.Success(ctx, "OK")
+}
+`
+
+	_EXPECT_FILE_MERGED_CHAT_REQUEST_GO = `package handler
+
+import (
+	"log"
+	"host-fasthttp-request-demo/handler/args"
+	. "host-fasthttp-request-demo/internal"
+
+	"github.com/Bofry/host-fasthttp/response"
+	"github.com/Bofry/host-fasthttp/tracing"
+	"github.com/Bofry/httparg"
+	"github.com/valyala/fasthttp"
+	"github.com/Bofry/host/app"
+)
+
+type ChatRequest struct {
+	ServiceProvider *ServiceProvider
+	Config          *Config
+	WesocketApp     *app.Application
+}
+
+func (r *ChatRequest) Init() {
+	r.ServiceProvider.ConfigureLogger(log.Default())
+}
+
+func (r *ChatRequest) Get(ctx *fasthttp.RequestCtx) {
+	sp := tracing.SpanFromRequestCtx(ctx)
+	_ = sp
+
+	argv := args.ChatGetArgv{}
+
+	httparg.Args(&argv).
+		ProcessQueryString(ctx.QueryArgs().String()).
+		Validate()
+
+	response.Text.Success(ctx, "OK")
+}
+`
+)
+
+func TestRequestFileMerger(t *testing.T) {
+	tmp := t.TempDir()
+
+	cases := []struct {
+		requestName   string
+		existing      string
+		writer        func(buf *bytes.Buffer) FileWriter
+		expectedAdded []string
+		expected      string
+	}{
+		{
+			requestName: "OrderRequest",
+			existing:    _FILE_MERGING_ORDER_REQUEST_GO,
+			writer: func(buf *bytes.Buffer) FileWriter {
+				return &HttpRequestFileWriter{
+					AppModuleName:      "host-fasthttp-request-demo",
+					RequestPackageName: "handler",
+					RequestPackagePath: "handler",
+					RequestName:        "OrderRequest",
+					RequestPrefix:      "Order",
+					Methods: []*HttpRequestMethod{
+						{RequestPrefix: "Order", Method: "Get"},
+						{RequestPrefix: "Order", Method: "Delete"},
+					},
+					Layout:      newDefaultLayout(),
+					RequestFile: buf,
+				}
+			},
+			expectedAdded: []string{"Delete"},
+			expected:      _EXPECT_FILE_MERGED_ORDER_REQUEST_GO,
+		},
+		{
+			requestName: "ChatRequest",
+			existing:    _FILE_MERGING_CHAT_REQUEST_GO,
+			writer: func(buf *bytes.Buffer) FileWriter {
+				return &WebsocketRequestFileWriter{
+					AppModuleName:          "host-fasthttp-request-demo",
+					RequestPackageName:     "handler",
+					RequestPackagePath:     "handler",
+					RequestName:            "ChatRequest",
+					RequestPrefix:          "Chat",
+					WebsocketAppModuleName: "chat",
+					Layout:                 newDefaultLayout(),
+					RequestFile:            buf,
+				}
+			},
+			expectedAdded: []string{"Config", "WesocketApp"},
+			expected:      _EXPECT_FILE_MERGED_CHAT_REQUEST_GO,
+		},
+	}
+
+	for _, c := range cases {
+		filename := path.Join(tmp, normalizeFileName(c.requestName)+".go")
+		assert(t,
+			os.WriteFile(filename, []byte(c.existing), 0644),
+		)
+
+		var buf bytes.Buffer
+		assert(t,
+			c.writer(&buf).Write(),
+		)
+
+		merger := &RequestFileMerger{
+			Filename:    filename,
+			RequestName: c.requestName,
+			Generated:   buf.Bytes(),
+		}
+		added, err := merger.Merge()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(c.expectedAdded, added) {
+			t.Errorf("%s: added expect %v, got %v", c.requestName, c.expectedAdded, added)
+		}
+
+		content, err := os.ReadFile(filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c.expected != string(content) {
+			t.Errorf("%s expect:\n%s\ngot:\n%s\n", filename, c.expected, string(content))
+		}
+
+		// merge again should change nothing
+		added, err = merger.Merge()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(added) != 0 {
+			t.Errorf("%s: should add nothing, got %v", c.requestName, added)
+		}
+	}
+}
@@ -0,0 +1,276 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// the relation of two url patterns.
+const (
+	ROUTE_DISJOINT = iota
+	ROUTE_OVERLAP
+	ROUTE_DUPLICATE
+)
+
+// Route describes the url of RequestManager field and the request type
+// handling it.
+type Route struct {
+	Url         string
+	RequestName string
+	PackageName string
+	Hijack      string
+	// the request methods implemented in the generated request file.
+	Methods []string
+
+	// the request methods routed, which are Methods or the methods planned
+	// if the request file is not generated yet.
+	routeMethods []string
+}
+
+// listRoutes prints the route table of RequestManager and checks the route
+// conflicts.
+func listRoutes(out io.Writer, structType *ast.StructType, handlerDir string) error {
+	routes, err := resolveRoutes(structType, handlerDir)
+	if err != nil {
+		return err
+	}
+	if err := writeRouteTable(out, routes); err != nil {
+		return err
+	}
+	return checkRouteConflicts(routes)
+}
+
+// resolveRoutes resolves the routes declared by the url tags of RequestManager
+// fields by the same plans of generating, the fields with @skip are ignored.
+func resolveRoutes(structType *ast.StructType, handlerDir string) ([]*Route, error) {
+	var routes []*Route
+
+	for _, field := range structType.Fields.List {
+		plan, err := resolveRequestFilePlan(field)
+		if err != nil {
+			return nil, err
+		}
+		if plan == nil {
+			continue
+		}
+
+		route := &Route{
+			Url:         plan.url,
+			RequestName: plan.requestName,
+			PackageName: plan.packageName,
+			Hijack:      plan.hijackType,
+		}
+
+		filename := getHandlerFileName(route.RequestName)
+		if len(filename) == 0 {
+			return nil, fmt.Errorf("cannot resolve request file of '%s'", plan.typename)
+		}
+		methods, err := lookupRequestMethods(path.Join(handlerDir, route.PackageName, filename+".go"), route.RequestName)
+		if err != nil {
+			return nil, err
+		}
+		route.Methods = methods
+
+		// the methods going to be generated if the request file is absent
+		switch {
+		case len(methods) > 0:
+			route.routeMethods = methods
+		case plan.hijackType != HIJACK_NONE:
+			// the hijacked request is upgraded from GET
+			route.routeMethods = []string{"GET"}
+		default:
+			route.routeMethods = plan.methods
+		}
+
+		routes = append(routes, route)
+	}
+	return routes, nil
+}
+
+// lookupRequestMethods finds the request methods, e.g: Get, Post, declared
+// on the request type in gofile. It returns nil if gofile does not exist.
+func lookupRequestMethods(gofile string, requestName string) ([]string, error) {
+	if _, err := os.Stat(gofile); os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, gofile, nil, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	var methods []string
+	for _, node := range f.Decls {
+		funcDecl, ok := node.(*ast.FuncDecl)
+		if !ok || funcDecl.Recv == nil || len(funcDecl.Recv.List) == 0 {
+			continue
+		}
+
+		recvType := funcDecl.Recv.List[0].Type
+		if star, ok := recvType.(*ast.StarExpr); ok {
+			recvType = star.X
+		}
+		if ident, ok := recvType.(*ast.Ident); !ok || ident.Name != requestName {
+			continue
+		}
+
+		method := strings.ToUpper(funcDecl.Name.Name)
+		if _, ok := requestMethodTable[method]; ok && getRequestMethodName(method) == funcDecl.Name.Name {
+			methods = append(methods, method)
+		}
+	}
+	return methods, nil
+}
+
+// writeRouteTable writes the routes as a table.
+func writeRouteTable(out io.Writer, routes []*Route) error {
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "URL\tREQUEST\tPACKAGE\tHIJACK\tMETHODS")
+	for _, route := range routes {
+		var (
+			packageName = layout.HandlerDir
+			hijack      = "-"
+			methods     = "-"
+		)
+		if len(route.PackageName) > 0 {
+			packageName = layout.HandlerDir + "/" + route.PackageName
+		}
+		if len(route.Hijack) > 0 {
+			hijack = route.Hijack
+		}
+		if len(route.Methods) > 0 {
+			methods = strings.Join(route.Methods, ",")
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", route.Url, route.RequestName, packageName, hijack, methods)
+	}
+	return w.Flush()
+}
+
+// checkRouteConflicts reports the duplicate routes, e.g: "/order/{id}" and
+// "/order/{no}", and the shadowing routes, e.g: "/order/{id}" and
+// "/order/latest", which have the request methods in common.
+func checkRouteConflicts(routes []*Route) error {
+	var conflicts []string
+
+	for i, a := range routes {
+		if len(a.Url) == 0 {
+			continue
+		}
+		for _, b := range routes[i+1:] {
+			if len(b.Url) == 0 || !intersectRouteMethods(a, b) {
+				continue
+			}
+			switch compareRoutePatterns(a.Url, b.Url) {
+			case ROUTE_DUPLICATE:
+				conflicts = append(conflicts, fmt.Sprintf("duplicate route '%s' of %s and '%s' of %s",
+					a.Url, a.RequestName, b.Url, b.RequestName))
+			case ROUTE_OVERLAP:
+				conflicts = append(conflicts, fmt.Sprintf("route '%s' of %s shadows '%s' of %s",
+					a.Url, a.RequestName, b.Url, b.RequestName))
+			}
+		}
+	}
+
+	if len(conflicts) > 0 {
+		return fmt.Errorf("found %d route conflict(s):\n  %s", len(conflicts), strings.Join(conflicts, "\n  "))
+	}
+	return nil
+}
+
+// intersectRouteMethods reports whether the routes a and b have the request
+// methods in common. The route of unknown methods has all the methods.
+func intersectRouteMethods(a, b *Route) bool {
+	if len(a.routeMethods) == 0 || len(b.routeMethods) == 0 {
+		return true
+	}
+	for _, x := range a.routeMethods {
+		for _, y := range b.routeMethods {
+			if x == y {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// compareRoutePatterns reports whether the url patterns a and b match the
+// same paths. The patterns are duplicate if they differ only in the names of
+// the path parameters, and overlap if some path matches both. The trailing
+// slash is significant, e.g: "/order" and "/order/" are different routes.
+func compareRoutePatterns(a, b string) int {
+	if strings.HasSuffix(a, "/") != strings.HasSuffix(b, "/") {
+		return ROUTE_DISJOINT
+	}
+
+	var (
+		segmentsA = strings.Split(strings.Trim(a, "/"), "/")
+		segmentsB = strings.Split(strings.Trim(b, "/"), "/")
+	)
+	if len(segmentsA) != len(segmentsB) {
+		return ROUTE_DISJOINT
+	}
+
+	result := ROUTE_DUPLICATE
+	for i := range segmentsA {
+		var (
+			paramA, isParamA = getRouteSegmentParamType(segmentsA[i])
+			paramB, isParamB = getRouteSegmentParamType(segmentsB[i])
+		)
+
+		switch {
+		case !isParamA && !isParamB:
+			if segmentsA[i] != segmentsB[i] {
+				return ROUTE_DISJOINT
+			}
+		case isParamA && isParamB:
+			if paramA != paramB {
+				result = ROUTE_OVERLAP
+			}
+		case isParamA:
+			if !matchRouteSegmentParam(paramA, segmentsB[i]) {
+				return ROUTE_DISJOINT
+			}
+			result = ROUTE_OVERLAP
+		case isParamB:
+			if !matchRouteSegmentParam(paramB, segmentsA[i]) {
+				return ROUTE_DISJOINT
+			}
+			result = ROUTE_OVERLAP
+		}
+	}
+	return result
+}
+
+// getRouteSegmentParamType returns the path parameter type if the url segment
+// has path parameter, e.g: {id} or {id:int}.
+func getRouteSegmentParamType(segment string) (string, bool) {
+	match := pathParamRegexp.FindStringSubmatch(segment)
+	if match == nil {
+		return "", false
+	}
+	// the segment mixes the literal and path parameter, e.g: {name}.json
+	if match[0] != segment {
+		return PATH_PARAM_TYPE_STRING, true
+	}
+	if pos := strings.IndexByte(match[1], ':'); pos >= 0 {
+		return match[1][pos+1:], true
+	}
+	return PATH_PARAM_TYPE_STRING, true
+}
+
+func matchRouteSegmentParam(paramType string, segment string) bool {
+	if paramType == PATH_PARAM_TYPE_INT {
+		_, err := strconv.ParseInt(segment, 10, 64)
+		return err == nil
+	}
+	return true
+}
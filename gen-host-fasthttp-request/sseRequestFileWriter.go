@@ -0,0 +1,60 @@
+package main
+
+import "io"
+
+var _ FileWriter = new(SseRequestFileWriter)
+
+type SseRequestFileWriter struct {
+	AppModuleName            string
+	RequestPackageName       string
+	RequestPackagePath       string
+	RequestName              string
+	RequestPrefix            string
+	IsSubRequestPackage      bool
+	EventSourceAppModuleName string
+	PathParams               []*PathParam
+	Layout                   *Layout
+
+	RequestFile           io.Writer
+	EventSourceAppFile    io.Writer
+	EventSourceBrokerFile io.Writer
+	RequestGetArgvFile    io.Writer
+}
+
+func (w *SseRequestFileWriter) Write() error {
+	var err error
+
+	err = SseRequestFileTemplate.ExecuteTemplate(w.RequestFile, TEMPLATE_NAME_REQUEST_FILE, w)
+	if err != nil {
+		return err
+	}
+
+	if w.RequestGetArgvFile != nil {
+		argv := &HttpRequestMethod{
+			RequestPrefix: w.RequestPrefix,
+			Method:        "Get",
+			PathParams:    w.PathParams,
+			Layout:        w.Layout,
+		}
+		err = writeRequestArgvFile(SseRequestFileTemplate, w.RequestGetArgvFile, TEMPLATE_NAME_REQUEST_QUERY_ARGV_FILE, argv)
+		if err != nil {
+			return err
+		}
+	}
+
+	if w.EventSourceAppFile != nil {
+		err = SseRequestFileTemplate.ExecuteTemplate(w.EventSourceAppFile, TEMPLATE_NAME_EVENT_SOURCE_APP_FILE, w)
+		if err != nil {
+			return err
+		}
+	}
+
+	if w.EventSourceBrokerFile != nil {
+		err = EventSourceBrokerFileTemplate.Execute(w.EventSourceBrokerFile, w)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"fmt"
+	"go/token"
+	"strings"
+	"unicode"
+)
+
+const (
+	WEBSOCKET_PROTOCOL_PREFIX4       string = "prefix4"
+	WEBSOCKET_PROTOCOL_JSON_ENVELOPE string = "json-envelope"
+	WEBSOCKET_PROTOCOL_MSGPACK       string = "msgpack"
+
+	// the length of protocol code of prefix4, e.g: CHAT.
+	WEBSOCKET_PREFIX4_PROTOCOL_LENGTH = 4
+)
+
+// the Module fields of the generated websocket app.
+var reservedWebsocketMessageNames = map[string]bool{
+	"App":                    true,
+	"ModuleOptionCollection": true,
+	"Init":                   true,
+	"DefaultMessageHandler":  true,
+	"DefaultEventHandler":    true,
+}
+
+// WebsocketMessage describes the MessageHandler field of the websocket app
+// Module and the protocol routing to it, e.g: Chat and "chat".
+type WebsocketMessage struct {
+	Name     string
+	Protocol string
+}
+
+// parseWebsocketProtocol validates the @protocol option, the default is
+// prefix4.
+func parseWebsocketProtocol(value string) (string, error) {
+	switch value {
+	case "":
+		return WEBSOCKET_PROTOCOL_PREFIX4, nil
+	case WEBSOCKET_PROTOCOL_PREFIX4, WEBSOCKET_PROTOCOL_JSON_ENVELOPE, WEBSOCKET_PROTOCOL_MSGPACK:
+		return value, nil
+	}
+	return "", fmt.Errorf("unsupported websocket protocol '%s', should be one of %s|%s|%s",
+		value, WEBSOCKET_PROTOCOL_JSON_ENVELOPE, WEBSOCKET_PROTOCOL_PREFIX4, WEBSOCKET_PROTOCOL_MSGPACK)
+}
+
+// parseWebsocketMessages parses the comma-separated message names of
+// @messages option and resolves their protocols, e.g: "Chat,Join" to CHAT
+// and JOIN of prefix4, or chat and join of json-envelope and msgpack.
+func parseWebsocketMessages(value string, protocol string) ([]*WebsocketMessage, error) {
+	var (
+		messages  []*WebsocketMessage
+		names     = make(map[string]bool)
+		protocols = make(map[string]string)
+	)
+
+	for _, name := range strings.Split(value, ",") {
+		name = strings.TrimSpace(name)
+		if len(name) == 0 {
+			continue
+		}
+		if !token.IsIdentifier(name) || !token.IsExported(name) {
+			return nil, fmt.Errorf("invalid websocket message '%s', should be an exported identifier", name)
+		}
+		if protocol == WEBSOCKET_PROTOCOL_PREFIX4 && strings.IndexFunc(name, func(r rune) bool { return r > unicode.MaxASCII }) >= 0 {
+			return nil, fmt.Errorf("invalid websocket message '%s', the %s protocol requires an ASCII name", name, protocol)
+		}
+		if reservedWebsocketMessageNames[name] {
+			return nil, fmt.Errorf("websocket message '%s' conflicts with the websocket app", name)
+		}
+		if names[name] {
+			return nil, fmt.Errorf("duplicated websocket message '%s'", name)
+		}
+		names[name] = true
+
+		message := &WebsocketMessage{
+			Name:     name,
+			Protocol: getWebsocketMessageProtocol(name, protocol),
+		}
+		if other, ok := protocols[message.Protocol]; ok {
+			return nil, fmt.Errorf("websocket messages '%s' and '%s' have the same %s protocol '%s'", other, name, protocol, message.Protocol)
+		}
+		protocols[message.Protocol] = name
+		messages = append(messages, message)
+	}
+
+	if len(messages) == 0 {
+		return nil, fmt.Errorf("no websocket message in '%s'", value)
+	}
+	return messages, nil
+}
+
+// getWebsocketMessageProtocol returns the protocol of message name. The
+// prefix4 protocol is the upper case name truncated or padded with '_' to 4
+// bytes, e.g: Leave to LEAV and Go to GO__. The others are the lower camel
+// case name, e.g: JoinRoom to joinRoom.
+func getWebsocketMessageProtocol(name string, protocol string) string {
+	if protocol == WEBSOCKET_PROTOCOL_PREFIX4 {
+		code := strings.ToUpper(name)
+		if len(code) > WEBSOCKET_PREFIX4_PROTOCOL_LENGTH {
+			return code[:WEBSOCKET_PREFIX4_PROTOCOL_LENGTH]
+		}
+		return code + strings.Repeat("_", WEBSOCKET_PREFIX4_PROTOCOL_LENGTH-len(code))
+	}
+
+	runes := []rune(name)
+	runes[0] = unicode.ToLower(runes[0])
+	return string(runes)
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"go/scanner"
+	"go/token"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseWebsocketMessages(t *testing.T) {
+	cases := []struct {
+		value    string
+		protocol string
+		expected []*WebsocketMessage
+	}{
+		{"Chat, Join,Go", WEBSOCKET_PROTOCOL_PREFIX4, []*WebsocketMessage{
+			{Name: "Chat", Protocol: "CHAT"},
+			{Name: "Join", Protocol: "JOIN"},
+			{Name: "Go", Protocol: "GO__"},
+		}},
+		{"Leave", WEBSOCKET_PROTOCOL_PREFIX4, []*WebsocketMessage{
+			{Name: "Leave", Protocol: "LEAV"},
+		}},
+		{"Chat,JoinRoom", WEBSOCKET_PROTOCOL_JSON_ENVELOPE, []*WebsocketMessage{
+			{Name: "Chat", Protocol: "chat"},
+			{Name: "JoinRoom", Protocol: "joinRoom"},
+		}},
+		{"Chat,", WEBSOCKET_PROTOCOL_MSGPACK, []*WebsocketMessage{
+			{Name: "Chat", Protocol: "chat"},
+		}},
+	}
+
+	for _, c := range cases {
+		messages, err := parseWebsocketMessages(c.value, c.protocol)
+		if err != nil {
+			t.Errorf("%q: %v", c.value, err)
+			continue
+		}
+		if !reflect.DeepEqual(messages, c.expected) {
+			t.Errorf("%q: messages expect %+v, got %+v", c.value, c.expected, messages)
+		}
+	}
+}
+
+func TestParseWebsocketMessages_Errors(t *testing.T) {
+	cases := []struct {
+		value    string
+		protocol string
+		expected string
+	}{
+		{"chat", WEBSOCKET_PROTOCOL_PREFIX4, "invalid websocket message 'chat', should be an exported identifier"},
+		{"Chat-Room", WEBSOCKET_PROTOCOL_JSON_ENVELOPE, "invalid websocket message 'Chat-Room', should be an exported identifier"},
+		{"Élan", WEBSOCKET_PROTOCOL_PREFIX4, "invalid websocket message 'Élan', the prefix4 protocol requires an ASCII name"},
+		{"Init", WEBSOCKET_PROTOCOL_MSGPACK, "websocket message 'Init' conflicts with the websocket app"},
+		{"Chat,Chat", WEBSOCKET_PROTOCOL_JSON_ENVELOPE, "duplicated websocket message 'Chat'"},
+		{"Leave,Leaving", WEBSOCKET_PROTOCOL_PREFIX4, "websocket messages 'Leave' and 'Leaving' have the same prefix4 protocol 'LEAV'"},
+		{" , ", WEBSOCKET_PROTOCOL_PREFIX4, "no websocket message in ' , '"},
+	}
+
+	for _, c := range cases {
+		_, err := parseWebsocketMessages(c.value, c.protocol)
+		if err == nil || err.Error() != c.expected {
+			t.Errorf("%q: error expect %q, got %v", c.value, c.expected, err)
+		}
+	}
+
+	if _, err := parseWebsocketProtocol("protobuf"); err == nil {
+		t.Errorf("protocol 'protobuf' should be unsupported")
+	}
+}
+
+func TestGenerateRequestFiles_WebsocketMessages(t *testing.T) {
+	tmp := t.TempDir()
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert(t, os.Chdir(tmp))
+	defer os.Chdir(wd)
+
+	defaultLayout, defaultAppModuleName := layout, appModuleName
+	defer func() {
+		layout, appModuleName = defaultLayout, defaultAppModuleName
+	}()
+	layout = newDefaultLayout()
+	appModuleName = "host-fasthttp-request-demo"
+
+	// keep gen-host-app-handler away
+	t.Setenv("PATH", tmp)
+
+	source := strings.ReplaceAll(`package main
+
+type RequestManager struct {
+	*ChatRequest   ”url:"/chat"   @hijack:"websocket" @protocol:"json-envelope" @messages:"Chat,JoinRoom"”
+	*LobbyRequest  ”url:"/lobby"  @messages:"Chat"”
+	*RoomRequest   ”url:"/room"   @hijack:"websocket" @protocol:"protobuf"”
+}
+`, "”", "`")
+	n, _, err := generateRequestFiles(token.NewFileSet(), parseRequestManager(t, source), layout.HandlerDir)
+	if n != 1 {
+		t.Errorf("generated requests expect %d, got %d", 1, n)
+	}
+	errs, ok := err.(scanner.ErrorList)
+	if !ok {
+		t.Fatalf("should get scanner.ErrorList, got %v", err)
+	}
+	expectedErrors := []string{
+		"@protocol and @messages require @hijack:\"websocket\"",
+		"unsupported websocket protocol 'protobuf'",
+	}
+	if len(errs) != len(expectedErrors) {
+		t.Fatalf("errors expect %d, got %d:\n%v", len(expectedErrors), len(errs), errs)
+	}
+	for i, expected := range expectedErrors {
+		if !strings.Contains(errs[i].Error(), expected) {
+			t.Errorf("error expect %q, got %q", expected, errs[i].Error())
+		}
+	}
+
+	content, err := readFile(tmp, "app.go", "handler/websocket/chat")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{
+		"\t\"encoding/json\"\n",
+		"\tChat     app.MessageHandler `protocol:\"chat\"`\n",
+		"\tJoinRoom app.MessageHandler `protocol:\"joinRoom\"`\n",
+		"if err := json.Unmarshal(payload, &envelope); err != nil {",
+		"payload, err := json.Marshal(&messageEnvelope{",
+		"\tData json.RawMessage `json:\"data\"`\n",
+	} {
+		if !strings.Contains(string(content), s) {
+			t.Errorf("app.go should contain %q, got:\n%s\n", s, string(content))
+		}
+	}
+	for _, s := range []string{"\"bytes\"", "Echo", "EchoEvent"} {
+		if strings.Contains(string(content), s) {
+			t.Errorf("app.go should not contain %q, got:\n%s\n", s, string(content))
+		}
+	}
+}
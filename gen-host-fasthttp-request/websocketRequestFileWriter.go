@@ -1,22 +1,54 @@
 package main
 
-import "io"
+import (
+	"io"
+	"strings"
+)
 
 var _ FileWriter = new(WebsocketRequestFileWriter)
 
 type WebsocketRequestFileWriter struct {
 	AppModuleName          string
 	RequestPackageName     string
+	RequestPackagePath     string
 	RequestName            string
 	RequestPrefix          string
 	IsSubRequestPackage    bool
 	WebsocketAppModuleName string
+	Protocol               string
+	Messages               []*WebsocketMessage
+	PathParams             []*PathParam
+	Layout                 *Layout
 
 	RequestFile        io.Writer
 	WebsocketAppFile   io.Writer
 	RequestGetArgvFile io.Writer
 }
 
+func (w *WebsocketRequestFileWriter) IsPrefix4Protocol() bool {
+	return len(w.Protocol) == 0 || w.Protocol == WEBSOCKET_PROTOCOL_PREFIX4
+}
+
+func (w *WebsocketRequestFileWriter) IsJsonEnvelopeProtocol() bool {
+	return w.Protocol == WEBSOCKET_PROTOCOL_JSON_ENVELOPE
+}
+
+func (w *WebsocketRequestFileWriter) IsMsgpackProtocol() bool {
+	return w.Protocol == WEBSOCKET_PROTOCOL_MSGPACK
+}
+
+// PadMessageName pads the message name with spaces to align the Module
+// fields like gofmt does.
+func (w *WebsocketRequestFileWriter) PadMessageName(name string) string {
+	var width int
+	for _, message := range w.Messages {
+		if len(message.Name) > width {
+			width = len(message.Name)
+		}
+	}
+	return name + strings.Repeat(" ", width-len(name))
+}
+
 func (w *WebsocketRequestFileWriter) Write() error {
 	var err error
 
@@ -26,12 +58,24 @@ func (w *WebsocketRequestFileWriter) Write() error {
 	}
 
 	if w.RequestGetArgvFile != nil {
-		err = WebsocketRequestFileTemplate.ExecuteTemplate(w.RequestGetArgvFile, TEMPLATE_NAME_REQUEST_GET_ARGV_FILE, w)
+		argv := &HttpRequestMethod{
+			RequestPrefix: w.RequestPrefix,
+			Method:        "Get",
+			PathParams:    w.PathParams,
+			Layout:        w.Layout,
+		}
+		err = writeRequestArgvFile(WebsocketRequestFileTemplate, w.RequestGetArgvFile, TEMPLATE_NAME_REQUEST_QUERY_ARGV_FILE, argv)
+		if err != nil {
+			return err
+		}
+	}
+
+	if w.WebsocketAppFile != nil {
+		err = WebsocketRequestFileTemplate.ExecuteTemplate(w.WebsocketAppFile, TEMPLATE_NAME_WEBSOCKET_APP_FILE, w)
 		if err != nil {
 			return err
 		}
 	}
 
-	err = WebsocketRequestFileTemplate.ExecuteTemplate(w.WebsocketAppFile, TEMPLATE_NAME_WEBSOCKET_APP_FILE, w)
-	return err
+	return nil
 }
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"bytes"
+	"go/format"
+	"io"
+	"strings"
+)
+
+var _ FileWriter = new(CrudResourceFileWriter)
+
+// ResourceCrud describes the CRUD verbs of resource, e.g:
+// @crud:"list,get,create,update,delete".
+type ResourceCrud struct {
+	List   bool
+	Get    bool
+	Create bool
+	Update bool
+	Delete bool
+}
+
+// HasCollection reports whether any verb is handled by the collection
+// resource, i.e. list or create.
+func (c *ResourceCrud) HasCollection() bool {
+	return c.List || c.Create
+}
+
+// HasItem reports whether any verb is handled by the item resource, i.e.
+// get, update or delete.
+func (c *ResourceCrud) HasItem() bool {
+	return c.Get || c.Update || c.Delete
+}
+
+// HasArgv reports whether any verb has argv, i.e. list, create or update.
+func (c *ResourceCrud) HasArgv() bool {
+	return c.List || c.Create || c.Update
+}
+
+// HasReply reports whether any verb replies the entities, i.e. all verbs
+// except delete.
+func (c *ResourceCrud) HasReply() bool {
+	return c.List || c.Get || c.Create || c.Update
+}
+
+type CrudResourceFileWriter struct {
+	AppModuleName       string
+	ResourcePackageName string
+	ResourcePackagePath string
+	ResourceName        string
+	ResourcePrefix      string
+	Url                 string
+	Crud                *ResourceCrud
+
+	ResourceFile         io.Writer
+	ResourceTestFile     io.Writer
+	ItemResourceFile     io.Writer
+	ItemResourceTestFile io.Writer
+	ListArgvFile         io.Writer
+	CreateArgvFile       io.Writer
+	UpdateArgvFile       io.Writer
+	ReplyFile            io.Writer
+	ErrorReplyFile       io.Writer
+	RepositoryFile       io.Writer
+}
+
+// ItemResourceName returns the resource type name handling the items, e.g:
+// OrderItemResource of OrderResource.
+func (w *CrudResourceFileWriter) ItemResourceName() string {
+	return getCrudItemResourceName(w.ResourceName)
+}
+
+// ItemUrl returns the url of the resource item, e.g: /orders/{id}.
+func (w *CrudResourceFileWriter) ItemUrl() string {
+	return getCrudItemUrl(w.Url)
+}
+
+// ItemUrlPrefix returns the url of the resource item without id, e.g:
+// /orders/.
+func (w *CrudResourceFileWriter) ItemUrlPrefix() string {
+	return strings.TrimSuffix(w.Url, "/") + "/"
+}
+
+func (w *CrudResourceFileWriter) IdName() string {
+	return CRUD_ID_PATH_PARAM_NAME
+}
+
+func (w *CrudResourceFileWriter) RepositoryName() string {
+	return getRepositoryName(w.ResourcePrefix)
+}
+
+func (w *CrudResourceFileWriter) EntityName() string {
+	return w.ResourcePrefix + CRUD_ENTITY_TYPE_SUFFIX
+}
+
+func (w *CrudResourceFileWriter) NotFoundErrorName() string {
+	return "Err" + w.ResourcePrefix + "NotFound"
+}
+
+// NotFoundErrorMessage returns the message of the not found error, e.g:
+// "orderItem not found" of OrderItemResource.
+func (w *CrudResourceFileWriter) NotFoundErrorMessage() string {
+	return strings.TrimSuffix(resolveResourceFileName(w.ResourceName), RESOURCE_TYPE_SUFFIX) + " not found"
+}
+
+func (w *CrudResourceFileWriter) DefaultPageSize() int {
+	return CRUD_DEFAULT_PAGE_SIZE
+}
+
+func (w *CrudResourceFileWriter) MaxPageSize() int {
+	return CRUD_MAX_PAGE_SIZE
+}
+
+func (w *CrudResourceFileWriter) Write() error {
+	type bodyArgv struct {
+		*CrudResourceFileWriter
+		Verb string
+	}
+
+	for _, f := range []struct {
+		writer       io.Writer
+		templateName string
+		data         interface{}
+	}{
+		{w.ResourceFile, TEMPLATE_NAME_RESOURCE_FILE, w},
+		{w.ResourceTestFile, TEMPLATE_NAME_RESOURCE_TEST_FILE, w},
+		{w.ItemResourceFile, TEMPLATE_NAME_ITEM_RESOURCE_FILE, w},
+		{w.ItemResourceTestFile, TEMPLATE_NAME_ITEM_RESOURCE_TEST_FILE, w},
+		{w.ListArgvFile, TEMPLATE_NAME_LIST_ARGV_FILE, w},
+		{w.CreateArgvFile, TEMPLATE_NAME_BODY_ARGV_FILE, &bodyArgv{w, "Create"}},
+		{w.UpdateArgvFile, TEMPLATE_NAME_BODY_ARGV_FILE, &bodyArgv{w, "Update"}},
+		{w.ReplyFile, TEMPLATE_NAME_REPLY_FILE, w},
+		{w.ErrorReplyFile, TEMPLATE_NAME_ERROR_REPLY_FILE, w},
+		{w.RepositoryFile, TEMPLATE_NAME_REPOSITORY_FILE, w},
+	} {
+		if f.writer == nil {
+			continue
+		}
+		err := writeFormattedFile(f.writer, f.templateName, f.data)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeFormattedFile formats the generated file to align the struct fields.
+func writeFormattedFile(w io.Writer, templateName string, data interface{}) error {
+	var buf bytes.Buffer
+
+	err := CrudResourceFileTemplate.ExecuteTemplate(&buf, templateName, data)
+	if err != nil {
+		return err
+	}
+
+	content, err := format.Source(buf.Bytes())
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(content)
+	return err
+}
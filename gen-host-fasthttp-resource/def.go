@@ -0,0 +1,738 @@
+package main
+
+import (
+	"html/template"
+	"strings"
+	texttemplate "text/template"
+)
+
+var (
+	TEMPLATE_NAME_RESOURCE_FILE           string = "ResourceFile"
+	TEMPLATE_NAME_RESOURCE_TEST_FILE      string = "ResourceTestFile"
+	TEMPLATE_NAME_ITEM_RESOURCE_FILE      string = "ItemResourceFile"
+	TEMPLATE_NAME_ITEM_RESOURCE_TEST_FILE string = "ItemResourceTestFile"
+	TEMPLATE_NAME_LIST_ARGV_FILE          string = "ListArgvFile"
+	TEMPLATE_NAME_BODY_ARGV_FILE          string = "BodyArgvFile"
+	TEMPLATE_NAME_REPLY_FILE              string = "ReplyFile"
+	TEMPLATE_NAME_ERROR_REPLY_FILE        string = "ErrorReplyFile"
+	TEMPLATE_NAME_REPOSITORY_FILE         string = "RepositoryFile"
+
+	RESOURCE_FILE_TEMPLATE string = `package {{.ResourcePackageName}}
+
+import (
+	"github.com/Bofry/host-fasthttp/response"
+	"github.com/valyala/fasthttp"
+)
+
+type {{.ResourceName}} struct{}
+{{- range .Methods}}
+
+// {{.Name}} handles {{.HttpMethod}} {{$.Url}}.
+func (r *{{$.ResourceName}}) {{.Name}}(ctx *fasthttp.RequestCtx) {
+	response.Success(ctx, "text/plain", []byte("OK"))
+}
+{{- end}}
+`
+
+	RESOURCE_TEST_FILE_TEMPLATE string = strings.ReplaceAll(`package {{.ResourcePackageName}}
+
+import (
+	"testing"
+
+	"github.com/valyala/fasthttp"
+)
+{{- range .Methods}}
+
+func Test{{$.ResourceName}}_{{.Name}}(t *testing.T) {
+	r := &{{$.ResourceName}}{}
+
+	ctx := new(fasthttp.RequestCtx)
+	ctx.Request.Header.SetMethod(fasthttp.Method{{.Name}})
+	ctx.Request.SetRequestURI("{{$.Url}}")
+
+	r.{{.Name}}(ctx)
+
+	if code := ctx.Response.StatusCode(); code != fasthttp.StatusOK {
+		t.Errorf("status code expect %d, got %d", fasthttp.StatusOK, code)
+	}
+	if body := string(ctx.Response.Body()); body != "OK" {
+		t.Errorf("body expect %q, got %q", "OK", body)
+	}
+}
+{{- end}}
+`, "”", "`")
+
+	CRUD_RESOURCE_FILE_TEMPLATE string = `package {{.ResourcePackageName}}
+
+import (
+	"encoding/json"
+	"errors"
+{{- if .Crud.HasCollection}}
+	"{{.AppModuleName}}/{{.ResourcePackagePath}}/args"
+{{- end}}
+	"{{.AppModuleName}}/{{.ResourcePackagePath}}/reply"
+	. "{{.AppModuleName}}/internal"
+
+	"github.com/Bofry/host-fasthttp/response"
+{{- if .Crud.HasCollection}}
+	"github.com/Bofry/httparg"
+{{- end}}
+	"github.com/valyala/fasthttp"
+)
+
+// {{.ResourceName}} handles the collection {{.Url}}, the items are handled
+// by {{.ItemResourceName}}.
+type {{.ResourceName}} struct {
+	ServiceProvider *ServiceProvider
+}
+{{- if .Crud.List}}
+
+// Get lists the entities, handles GET {{.Url}}.
+func (r *{{.ResourceName}}) Get(ctx *fasthttp.RequestCtx) {
+	argv := args.{{.ResourcePrefix}}ListArgv{}
+
+	httparg.Args(&argv).
+		ProcessQueryString(ctx.QueryArgs().String()).
+		Validate()
+
+	entities, total, err := r.ServiceProvider.{{.RepositoryName}}.List(argv.Filter(), argv.Offset(), argv.Limit())
+	if err != nil {
+		reply{{.ResourcePrefix}}Error(ctx, err)
+		return
+	}
+
+	result := reply.{{.ResourcePrefix}}ListReply{
+		Items: make([]*reply.{{.ResourcePrefix}}Reply, 0, len(entities)),
+		Total: total,
+	}
+	for _, entity := range entities {
+		result.Items = append(result.Items, to{{.ResourcePrefix}}Reply(entity))
+	}
+	reply{{.ResourcePrefix}}Json(ctx, fasthttp.StatusOK, &result)
+}
+{{- end}}
+{{- if .Crud.Create}}
+
+// Post creates the entity, handles POST {{.Url}}.
+func (r *{{.ResourceName}}) Post(ctx *fasthttp.RequestCtx) {
+	argv := args.{{.ResourcePrefix}}CreateArgv{}
+
+	httparg.Args(&argv).
+		ProcessContent(ctx.PostBody(), string(ctx.Request.Header.ContentType())).
+		Validate()
+
+	entity, err := r.ServiceProvider.{{.RepositoryName}}.Create(&{{.EntityName}}{
+		Name: argv.Name,
+	})
+	if err != nil {
+		reply{{.ResourcePrefix}}Error(ctx, err)
+		return
+	}
+	reply{{.ResourcePrefix}}Json(ctx, fasthttp.StatusCreated, to{{.ResourcePrefix}}Reply(entity))
+}
+{{- end}}
+
+func to{{.ResourcePrefix}}Reply(entity *{{.EntityName}}) *reply.{{.ResourcePrefix}}Reply {
+	return &reply.{{.ResourcePrefix}}Reply{
+		Id:   entity.Id,
+		Name: entity.Name,
+	}
+}
+
+func reply{{.ResourcePrefix}}Json(ctx *fasthttp.RequestCtx, statusCode int, v interface{}) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		reply{{.ResourcePrefix}}Error(ctx, err)
+		return
+	}
+	response.Success(ctx, "application/json", body)
+	ctx.SetStatusCode(statusCode)
+}
+
+func reply{{.ResourcePrefix}}Error(ctx *fasthttp.RequestCtx, err error) {
+	statusCode := fasthttp.StatusInternalServerError
+	if errors.Is(err, {{.NotFoundErrorName}}) {
+		statusCode = fasthttp.StatusNotFound
+	}
+	body, _ := json.Marshal(&reply.ErrorReply{
+		Message: err.Error(),
+	})
+	response.Failure(ctx, "application/json", body, statusCode)
+}
+`
+
+	CRUD_ITEM_RESOURCE_FILE_TEMPLATE string = `package {{.ResourcePackageName}}
+
+import (
+{{- if .Crud.Update}}
+	"{{.AppModuleName}}/{{.ResourcePackagePath}}/args"
+{{- end}}
+	. "{{.AppModuleName}}/internal"
+
+{{if .Crud.Update}}	"github.com/Bofry/httparg"
+{{end}}	"github.com/valyala/fasthttp"
+)
+
+// {{.ItemResourceName}} handles the items {{.ItemUrl}} of {{.ResourceName}}.
+type {{.ItemResourceName}} struct {
+	ServiceProvider *ServiceProvider
+}
+{{- if .Crud.Get}}
+
+// Get handles GET {{.ItemUrl}}.
+func (r *{{.ItemResourceName}}) Get(ctx *fasthttp.RequestCtx) {
+	id, _ := ctx.UserValue("{{.IdName}}").(string)
+
+	entity, err := r.ServiceProvider.{{.RepositoryName}}.Get(id)
+	if err != nil {
+		reply{{.ResourcePrefix}}Error(ctx, err)
+		return
+	}
+	reply{{.ResourcePrefix}}Json(ctx, fasthttp.StatusOK, to{{.ResourcePrefix}}Reply(entity))
+}
+{{- end}}
+{{- if .Crud.Update}}
+
+// Put updates the entity, handles PUT {{.ItemUrl}}.
+func (r *{{.ItemResourceName}}) Put(ctx *fasthttp.RequestCtx) {
+	id, _ := ctx.UserValue("{{.IdName}}").(string)
+
+	argv := args.{{.ResourcePrefix}}UpdateArgv{}
+
+	httparg.Args(&argv).
+		ProcessContent(ctx.PostBody(), string(ctx.Request.Header.ContentType())).
+		Validate()
+
+	entity, err := r.ServiceProvider.{{.RepositoryName}}.Update(id, &{{.EntityName}}{
+		Name: argv.Name,
+	})
+	if err != nil {
+		reply{{.ResourcePrefix}}Error(ctx, err)
+		return
+	}
+	reply{{.ResourcePrefix}}Json(ctx, fasthttp.StatusOK, to{{.ResourcePrefix}}Reply(entity))
+}
+{{- end}}
+{{- if .Crud.Delete}}
+
+// Delete handles DELETE {{.ItemUrl}}.
+func (r *{{.ItemResourceName}}) Delete(ctx *fasthttp.RequestCtx) {
+	id, _ := ctx.UserValue("{{.IdName}}").(string)
+
+	err := r.ServiceProvider.{{.RepositoryName}}.Delete(id)
+	if err != nil {
+		reply{{.ResourcePrefix}}Error(ctx, err)
+		return
+	}
+	ctx.SetStatusCode(fasthttp.StatusNoContent)
+}
+{{- end}}
+`
+
+	CRUD_RESOURCE_TEST_FILE_TEMPLATE string = `package {{.ResourcePackageName}}
+
+import (
+{{- if .Crud.HasCollection}}
+	"encoding/json"
+	"testing"
+	"{{.AppModuleName}}/{{.ResourcePackagePath}}/reply"
+{{- end}}
+	. "{{.AppModuleName}}/internal"
+{{- if .Crud.HasCollection}}
+
+	"github.com/valyala/fasthttp"
+{{- end}}
+)
+
+func newTest{{.ResourcePrefix}}ServiceProvider() (*ServiceProvider, *Memory{{.RepositoryName}}) {
+	repository := NewMemory{{.RepositoryName}}()
+	return &ServiceProvider{
+		{{.RepositoryName}}: repository,
+	}, repository
+}
+{{- if .Crud.List}}
+
+func Test{{.ResourceName}}_Get(t *testing.T) {
+	sp, repository := newTest{{.ResourcePrefix}}ServiceProvider()
+	repository.Create(&{{.EntityName}}{Name: "hello"})
+	repository.Create(&{{.EntityName}}{Name: "world"})
+	r := &{{.ResourceName}}{ServiceProvider: sp}
+
+	ctx := new(fasthttp.RequestCtx)
+	ctx.Request.Header.SetMethod(fasthttp.MethodGet)
+	ctx.Request.SetRequestURI("{{.Url}}?page=1&page_size=1&keyword=hello")
+
+	r.Get(ctx)
+
+	if code := ctx.Response.StatusCode(); code != fasthttp.StatusOK {
+		t.Fatalf("status code expect %d, got %d", fasthttp.StatusOK, code)
+	}
+	var result reply.{{.ResourcePrefix}}ListReply
+	if err := json.Unmarshal(ctx.Response.Body(), &result); err != nil {
+		t.Fatal(err)
+	}
+	if result.Total != 1 || len(result.Items) != 1 || result.Items[0].Name != "hello" {
+		t.Errorf("reply expect 1 item named %q, got %+v", "hello", result)
+	}
+}
+{{- end}}
+{{- if .Crud.Create}}
+
+func Test{{.ResourceName}}_Post(t *testing.T) {
+	sp, repository := newTest{{.ResourcePrefix}}ServiceProvider()
+	r := &{{.ResourceName}}{ServiceProvider: sp}
+
+	ctx := new(fasthttp.RequestCtx)
+	ctx.Request.Header.SetMethod(fasthttp.MethodPost)
+	ctx.Request.SetRequestURI("{{.Url}}")
+	ctx.Request.Header.SetContentType("application/json")
+	ctx.Request.SetBodyString(` + "`" + `{"name":"hello"}` + "`" + `)
+
+	r.Post(ctx)
+
+	if code := ctx.Response.StatusCode(); code != fasthttp.StatusCreated {
+		t.Fatalf("status code expect %d, got %d", fasthttp.StatusCreated, code)
+	}
+	var result reply.{{.ResourcePrefix}}Reply
+	if err := json.Unmarshal(ctx.Response.Body(), &result); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repository.Get(result.Id); err != nil {
+		t.Errorf("%s should be created: %v", result.Id, err)
+	}
+}
+{{- end}}
+`
+
+	CRUD_ITEM_RESOURCE_TEST_FILE_TEMPLATE string = `package {{.ResourcePackageName}}
+
+import (
+{{- if or .Crud.Get .Crud.Update}}
+	"encoding/json"
+{{- end}}
+{{- if .Crud.Delete}}
+	"errors"
+{{- end}}
+	"testing"
+{{- if or .Crud.Get .Crud.Update}}
+	"{{.AppModuleName}}/{{.ResourcePackagePath}}/reply"
+{{- end}}
+	. "{{.AppModuleName}}/internal"
+
+	"github.com/valyala/fasthttp"
+)
+{{- if .Crud.Get}}
+
+func Test{{.ItemResourceName}}_Get(t *testing.T) {
+	sp, repository := newTest{{.ResourcePrefix}}ServiceProvider()
+	entity, err := repository.Create(&{{.EntityName}}{Name: "hello"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := &{{.ItemResourceName}}{ServiceProvider: sp}
+
+	ctx := new(fasthttp.RequestCtx)
+	ctx.Request.Header.SetMethod(fasthttp.MethodGet)
+	ctx.Request.SetRequestURI("{{.ItemUrlPrefix}}" + entity.Id)
+	ctx.SetUserValue("{{.IdName}}", entity.Id)
+
+	r.Get(ctx)
+
+	if code := ctx.Response.StatusCode(); code != fasthttp.StatusOK {
+		t.Fatalf("status code expect %d, got %d", fasthttp.StatusOK, code)
+	}
+	var result reply.{{.ResourcePrefix}}Reply
+	if err := json.Unmarshal(ctx.Response.Body(), &result); err != nil {
+		t.Fatal(err)
+	}
+	if result.Id != entity.Id || result.Name != "hello" {
+		t.Errorf("reply expect %+v, got %+v", entity, result)
+	}
+
+	// not found
+	ctx = new(fasthttp.RequestCtx)
+	ctx.Request.Header.SetMethod(fasthttp.MethodGet)
+	ctx.Request.SetRequestURI("{{.ItemUrlPrefix}}0")
+	ctx.SetUserValue("{{.IdName}}", "0")
+
+	r.Get(ctx)
+
+	if code := ctx.Response.StatusCode(); code != fasthttp.StatusNotFound {
+		t.Errorf("status code expect %d, got %d", fasthttp.StatusNotFound, code)
+	}
+}
+{{- end}}
+{{- if .Crud.Update}}
+
+func Test{{.ItemResourceName}}_Put(t *testing.T) {
+	sp, repository := newTest{{.ResourcePrefix}}ServiceProvider()
+	entity, err := repository.Create(&{{.EntityName}}{Name: "hello"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := &{{.ItemResourceName}}{ServiceProvider: sp}
+
+	ctx := new(fasthttp.RequestCtx)
+	ctx.Request.Header.SetMethod(fasthttp.MethodPut)
+	ctx.Request.SetRequestURI("{{.ItemUrlPrefix}}" + entity.Id)
+	ctx.SetUserValue("{{.IdName}}", entity.Id)
+	ctx.Request.Header.SetContentType("application/json")
+	ctx.Request.SetBodyString(` + "`" + `{"name":"world"}` + "`" + `)
+
+	r.Put(ctx)
+
+	if code := ctx.Response.StatusCode(); code != fasthttp.StatusOK {
+		t.Fatalf("status code expect %d, got %d", fasthttp.StatusOK, code)
+	}
+	var result reply.{{.ResourcePrefix}}Reply
+	if err := json.Unmarshal(ctx.Response.Body(), &result); err != nil {
+		t.Fatal(err)
+	}
+	if result.Id != entity.Id || result.Name != "world" {
+		t.Errorf("reply expect name %q, got %+v", "world", result)
+	}
+}
+{{- end}}
+{{- if .Crud.Delete}}
+
+func Test{{.ItemResourceName}}_Delete(t *testing.T) {
+	sp, repository := newTest{{.ResourcePrefix}}ServiceProvider()
+	entity, err := repository.Create(&{{.EntityName}}{Name: "hello"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := &{{.ItemResourceName}}{ServiceProvider: sp}
+
+	ctx := new(fasthttp.RequestCtx)
+	ctx.Request.Header.SetMethod(fasthttp.MethodDelete)
+	ctx.Request.SetRequestURI("{{.ItemUrlPrefix}}" + entity.Id)
+	ctx.SetUserValue("{{.IdName}}", entity.Id)
+
+	r.Delete(ctx)
+
+	if code := ctx.Response.StatusCode(); code != fasthttp.StatusNoContent {
+		t.Fatalf("status code expect %d, got %d", fasthttp.StatusNoContent, code)
+	}
+	if _, err := repository.Get(entity.Id); !errors.Is(err, {{.NotFoundErrorName}}) {
+		t.Errorf("%s should be deleted, got %v", entity.Id, err)
+	}
+}
+{{- end}}
+`
+
+	CRUD_LIST_ARGV_FILE_TEMPLATE string = strings.ReplaceAll(`package args
+
+import (
+	"github.com/Bofry/arg"
+	"github.com/Bofry/httparg"
+)
+
+var (
+	_ httparg.Validatable = new({{.ResourcePrefix}}ListArgv)
+)
+
+//go:generate gen-bofry-arg-assertor
+type {{.ResourcePrefix}}ListArgv struct /* tag=query */ {
+	Page     *int    ”query:"page"”
+	PageSize *int    ”query:"page_size"”
+	Keyword  *string ”query:"keyword"”
+}
+
+// Validate implements httparg.Validatable.
+func (argv *{{.ResourcePrefix}}ListArgv) Validate() error {
+	v := argv.Assertor()
+
+	err := arg.Assert(
+		v.Page(arg.IntPtr.GreaterOrEqual(1)),
+		v.PageSize(arg.IntPtr.GreaterOrEqual(1), arg.IntPtr.LessOrEqual({{.MaxPageSize}})),
+	)
+	return err
+}
+
+// Offset returns the offset of the page, the first page is 1.
+func (argv *{{.ResourcePrefix}}ListArgv) Offset() int {
+	if argv.Page == nil {
+		return 0
+	}
+	return (*argv.Page - 1) * argv.Limit()
+}
+
+// Limit returns the page size, the default is {{.DefaultPageSize}}.
+func (argv *{{.ResourcePrefix}}ListArgv) Limit() int {
+	if argv.PageSize == nil {
+		return {{.DefaultPageSize}}
+	}
+	return *argv.PageSize
+}
+
+// Filter returns the keyword filtering the names.
+func (argv *{{.ResourcePrefix}}ListArgv) Filter() string {
+	if argv.Keyword == nil {
+		return ""
+	}
+	return *argv.Keyword
+}
+`, "”", "`")
+
+	CRUD_BODY_ARGV_FILE_TEMPLATE string = strings.ReplaceAll(`package args
+
+import (
+	"github.com/Bofry/arg"
+	"github.com/Bofry/httparg"
+)
+
+var (
+	_ httparg.Validatable = new({{.ResourcePrefix}}{{.Verb}}Argv)
+)
+
+//go:generate gen-bofry-arg-assertor
+type {{.ResourcePrefix}}{{.Verb}}Argv struct /* tag=json */ {
+	Name string ”json:"*name"”
+}
+
+// Validate implements httparg.Validatable.
+func (argv *{{.ResourcePrefix}}{{.Verb}}Argv) Validate() error {
+	v := argv.Assertor()
+
+	err := arg.Assert(
+		v.Name(arg.Strings.NonEmpty),
+	)
+	return err
+}
+`, "”", "`")
+
+	CRUD_REPLY_FILE_TEMPLATE string = strings.ReplaceAll(`package reply
+
+// {{.ResourcePrefix}}Reply is the reply of {{.ResourceName}}.
+type {{.ResourcePrefix}}Reply struct {
+	Id   string ”json:"id"”
+	Name string ”json:"name"”
+}
+{{- if .Crud.List}}
+
+// {{.ResourcePrefix}}ListReply is the page of {{.ResourcePrefix}}Reply.
+type {{.ResourcePrefix}}ListReply struct {
+	Items []*{{.ResourcePrefix}}Reply ”json:"items"”
+	Total int ”json:"total"”
+}
+{{- end}}
+`, "”", "`")
+
+	CRUD_ERROR_REPLY_FILE_TEMPLATE string = strings.ReplaceAll(`package reply
+
+// ErrorReply is the reply of the failed resource methods.
+type ErrorReply struct {
+	Message string ”json:"message"”
+}
+`, "”", "`")
+
+	CRUD_REPOSITORY_FILE_TEMPLATE string = `package internal
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var (
+	_ {{.RepositoryName}} = new(Memory{{.RepositoryName}})
+
+	{{.NotFoundErrorName}} = errors.New("{{.NotFoundErrorMessage}}")
+)
+
+// {{.EntityName}} is the entity stored by {{.RepositoryName}}.
+type {{.EntityName}} struct {
+	Id   string
+	Name string
+}
+
+// {{.RepositoryName}} is the storage of {{.ResourceName}}, replace the
+// Memory{{.RepositoryName}} in ServiceProvider with your real storage.
+type {{.RepositoryName}} interface {
+{{- if .Crud.List}}
+	List(keyword string, offset, limit int) ([]*{{.EntityName}}, int, error)
+{{- end}}
+{{- if .Crud.Get}}
+	Get(id string) (*{{.EntityName}}, error)
+{{- end}}
+{{- if .Crud.Create}}
+	Create(entity *{{.EntityName}}) (*{{.EntityName}}, error)
+{{- end}}
+{{- if .Crud.Update}}
+	Update(id string, entity *{{.EntityName}}) (*{{.EntityName}}, error)
+{{- end}}
+{{- if .Crud.Delete}}
+	Delete(id string) error
+{{- end}}
+}
+
+// Memory{{.RepositoryName}} is the in-memory {{.RepositoryName}} for
+// development and tests, the entities are lost when the app stopped.
+type Memory{{.RepositoryName}} struct {
+	mutex    sync.RWMutex
+	entities map[string]*{{.EntityName}}
+	// the entity ids in the created order
+	ids []string
+	seq int64
+}
+
+func NewMemory{{.RepositoryName}}() *Memory{{.RepositoryName}} {
+	return &Memory{{.RepositoryName}}{
+		entities: make(map[string]*{{.EntityName}}),
+	}
+}
+
+// List returns the entities whose name contains keyword in the range of
+// offset and limit, and the total number of the matched entities.
+func (r *Memory{{.RepositoryName}}) List(keyword string, offset, limit int) ([]*{{.EntityName}}, int, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	var matched []*{{.EntityName}}
+	for _, id := range r.ids {
+		entity := r.entities[id]
+		if strings.Contains(entity.Name, keyword) {
+			copied := *entity
+			matched = append(matched, &copied)
+		}
+	}
+
+	total := len(matched)
+	if offset > total {
+		offset = total
+	}
+	end := offset + limit
+	if end > total {
+		end = total
+	}
+	return matched[offset:end], total, nil
+}
+
+func (r *Memory{{.RepositoryName}}) Get(id string) (*{{.EntityName}}, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	entity, ok := r.entities[id]
+	if !ok {
+		return nil, {{.NotFoundErrorName}}
+	}
+	copied := *entity
+	return &copied, nil
+}
+
+func (r *Memory{{.RepositoryName}}) Create(entity *{{.EntityName}}) (*{{.EntityName}}, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.seq++
+	stored := *entity
+	stored.Id = strconv.FormatInt(r.seq, 10)
+	r.entities[stored.Id] = &stored
+	r.ids = append(r.ids, stored.Id)
+
+	copied := stored
+	return &copied, nil
+}
+
+func (r *Memory{{.RepositoryName}}) Update(id string, entity *{{.EntityName}}) (*{{.EntityName}}, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, ok := r.entities[id]; !ok {
+		return nil, {{.NotFoundErrorName}}
+	}
+	stored := *entity
+	stored.Id = id
+	r.entities[id] = &stored
+
+	copied := stored
+	return &copied, nil
+}
+
+func (r *Memory{{.RepositoryName}}) Delete(id string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, ok := r.entities[id]; !ok {
+		return {{.NotFoundErrorName}}
+	}
+	delete(r.entities, id)
+	for i, v := range r.ids {
+		if v == id {
+			r.ids = append(r.ids[:i], r.ids[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+`
+)
+
+var (
+	ResourceFileTemplate *template.Template
+
+	// NOTE: use text/template since html/template escapes the quotes of
+	// the struct tags and the operators '<' and '&'.
+	CrudResourceFileTemplate *texttemplate.Template
+)
+
+type (
+	FileWriter interface {
+		Write() error
+	}
+)
+
+func init() {
+	{
+		tmpl, err := template.New(TEMPLATE_NAME_RESOURCE_FILE).Parse(RESOURCE_FILE_TEMPLATE)
+		if err != nil {
+			panic(err)
+		}
+		tmpl, err = tmpl.New(TEMPLATE_NAME_RESOURCE_TEST_FILE).Parse(RESOURCE_TEST_FILE_TEMPLATE)
+		if err != nil {
+			panic(err)
+		}
+		ResourceFileTemplate = tmpl
+	}
+
+	{
+		tmpl, err := texttemplate.New(TEMPLATE_NAME_RESOURCE_FILE).Parse(CRUD_RESOURCE_FILE_TEMPLATE)
+		if err != nil {
+			panic(err)
+		}
+		tmpl, err = tmpl.New(TEMPLATE_NAME_RESOURCE_TEST_FILE).Parse(CRUD_RESOURCE_TEST_FILE_TEMPLATE)
+		if err != nil {
+			panic(err)
+		}
+		tmpl, err = tmpl.New(TEMPLATE_NAME_ITEM_RESOURCE_FILE).Parse(CRUD_ITEM_RESOURCE_FILE_TEMPLATE)
+		if err != nil {
+			panic(err)
+		}
+		tmpl, err = tmpl.New(TEMPLATE_NAME_ITEM_RESOURCE_TEST_FILE).Parse(CRUD_ITEM_RESOURCE_TEST_FILE_TEMPLATE)
+		if err != nil {
+			panic(err)
+		}
+		tmpl, err = tmpl.New(TEMPLATE_NAME_LIST_ARGV_FILE).Parse(CRUD_LIST_ARGV_FILE_TEMPLATE)
+		if err != nil {
+			panic(err)
+		}
+		tmpl, err = tmpl.New(TEMPLATE_NAME_BODY_ARGV_FILE).Parse(CRUD_BODY_ARGV_FILE_TEMPLATE)
+		if err != nil {
+			panic(err)
+		}
+		tmpl, err = tmpl.New(TEMPLATE_NAME_REPLY_FILE).Parse(CRUD_REPLY_FILE_TEMPLATE)
+		if err != nil {
+			panic(err)
+		}
+		tmpl, err = tmpl.New(TEMPLATE_NAME_ERROR_REPLY_FILE).Parse(CRUD_ERROR_REPLY_FILE_TEMPLATE)
+		if err != nil {
+			panic(err)
+		}
+		tmpl, err = tmpl.New(TEMPLATE_NAME_REPOSITORY_FILE).Parse(CRUD_REPOSITORY_FILE_TEMPLATE)
+		if err != nil {
+			panic(err)
+		}
+		CrudResourceFileTemplate = tmpl
+	}
+}
@@ -1,15 +1,22 @@
 package main
 
 import (
+	"bufio"
+	"flag"
 	"fmt"
 	"go/ast"
 	"go/parser"
 	"go/printer"
+	"go/scanner"
 	"go/token"
-	"io/ioutil"
+	"io"
 	"os"
 	"os/exec"
+	"path"
 	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
 	"strings"
 	"unicode"
 
@@ -19,77 +26,149 @@ import (
 
 const (
 	RESOURCE_MANAGER_TYPE_NAME string = "ResourceManager"
-	RESOURCE_SUBMODULE_NAME    string = "resource"
+	RESOURCE_MODULE_NAME       string = "resource"
 	RESOURCE_TYPE_SUFFIX       string = "Resource"
-
-	RESOURCE_FILE_TEMPLATE string = `package resource
-
-import (
-	"github.com/valyala/fasthttp"
-	"github.com/Bofry/host-fasthttp/response"
-)
-
-type %[1]s struct {}
-
-func (r *%[1]s) Ping(ctx *fasthttp.RequestCtx) {
-	response.Success(ctx, "text/plain", []byte("PONG"))
-}
-`
+	TEST_FILE_SUFFIX           string = "_test"
+	INTERNAL_MODULE_NAME       string = "internal"
+	ARGS_DIR_PATH              string = "args"
+	REPLY_DIR_PATH             string = "reply"
+	ERROR_REPLY_FILE_NAME      string = "error"
+	REPOSITORY_TYPE_SUFFIX     string = "Repository"
+	CRUD_ENTITY_TYPE_SUFFIX    string = "Entity"
+	CRUD_ITEM_RESOURCE_SUFFIX  string = "ItemResource"
+	CRUD_ID_PATH_PARAM_NAME    string = "id"
+	CRUD_DEFAULT_PAGE_SIZE     int    = 20
+	CRUD_MAX_PAGE_SIZE         int    = 100
+
+	GENERATE_STATUS_OK      string = "ok"
+	GENERATE_STATUS_SKIPPED string = "skipped"
+
+	TAG_URL_NAME         string = "url"
+	TAG_SKIP_OPT_NAME    string = "@skip"
+	TAG_METHODS_OPT_NAME string = "@methods"
+	TAG_CRUD_OPT_NAME    string = "@crud"
+
+	CRUD_LIST   string = "list"
+	CRUD_GET    string = "get"
+	CRUD_CREATE string = "create"
+	CRUD_UPDATE string = "update"
+	CRUD_DELETE string = "delete"
+
+	DEFAULT_RESOURCE_METHODS string = "GET"
 )
 
 var (
-	gofile string
+	osExit        func(int) = os.Exit
+	gofile        string
+	workdir       string
+	appModuleName string
+	methods       string
+
+	defaultResourceMethods []string
+
+	// the supported resource methods and their method names.
+	resourceMethodTable = map[string]string{
+		"GET":    "Get",
+		"HEAD":   "Head",
+		"DELETE": "Delete",
+		"POST":   "Post",
+		"PUT":    "Put",
+		"PATCH":  "Patch",
+	}
 )
 
+func init() {
+	flag.StringVar(&gofile, "file", "", "input file")
+	flag.StringVar(&methods, "methods", DEFAULT_RESOURCE_METHODS, "default resource methods")
+}
+
 func main() {
+	var (
+		err error
+	)
+	flag.Parse()
+
+	if dir, file := path.Split(gofile); dir != "." {
+		workdir, err = os.Getwd()
+		if err != nil {
+			throw("Cannot get work directory.")
+			exit(1)
+		}
+		os.Chdir(dir)
+		gofile = file
+	}
+
 	if gofile == "" {
 		gofile = os.Getenv("GOFILE")
 		if gofile == "" {
 			throw("No file to parse.")
-			os.Exit(1)
+			exit(1)
 		}
 	}
 
+	// parse default resource methods
+	defaultResourceMethods, err = parseResourceMethods(methods)
+	if err != nil {
+		throw(err.Error())
+		exit(1)
+	}
+
+	// get module name
+	appModuleName, err = getAppModuleName()
+	if err != nil {
+		throw(err.Error())
+		exit(1)
+	}
+
+	// parse app.go to AST
 	fset := token.NewFileSet()
 	f, err := parser.ParseFile(fset, gofile, nil, parser.ParseComments)
 	if err != nil {
 		throw(err.Error())
-		os.Exit(1)
+		exit(1)
 	}
 
+	// resolve AST
+	var generateErr error
 	for _, node := range f.Decls {
-		switch node.(type) {
-
+		switch realDecl := node.(type) {
 		case *ast.GenDecl:
-			genDecl := node.(*ast.GenDecl)
-			for _, spec := range genDecl.Specs {
+			for _, spec := range realDecl.Specs {
 				switch spec.(type) {
 				case *ast.TypeSpec:
-					typeSpec := spec.(*ast.TypeSpec)
+					var (
+						typeSpec       = spec.(*ast.TypeSpec)
+						structTypeName = typeSpec.Name.Name
+						structType     *ast.StructType
+					)
 
-					structTypeName := typeSpec.Name.Name
+					// find ResourceManager type
 					if structTypeName == RESOURCE_MANAGER_TYPE_NAME {
 						var (
-							count int
-							err   error
+							count    int
+							packages []string
 						)
 
 						switch typeSpec.Type.(type) {
 						case *ast.StructType:
-							structType := typeSpec.Type.(*ast.StructType)
-							count, err = generateResourceFiles(structType, RESOURCE_SUBMODULE_NAME)
-							if err != nil {
-								throw(err.Error())
-								os.Exit(1)
-							}
+							structType = typeSpec.Type.(*ast.StructType)
+							// report the errors after importing the generated resources
+							count, packages, generateErr = generateResourceFiles(fset, structType, RESOURCE_MODULE_NAME)
 						}
 
+						// register the item routes of CRUD resources
+						shouldUpdate := registerCrudItemResources(structType)
 						if count > 0 {
 							// import resource module path
-							err := importResourceModulePath(fset, f)
+							if importResourceModulePath(fset, f, packages) {
+								shouldUpdate = true
+							}
+						}
+						if shouldUpdate {
+							err := writeGoFile(fset, f)
 							if err != nil {
 								throw(err.Error())
-								os.Exit(1)
+								exit(1)
 							}
 						}
 						break
@@ -99,73 +178,453 @@ func main() {
 		}
 	}
 
+	if generateErr != nil {
+		scanner.PrintError(os.Stderr, generateErr)
+		exit(1)
+		return
+	}
+
 	if err := execCmd("go", "mod", "tidy"); err != nil {
 		throw(err.Error())
-		os.Exit(1)
+		exit(1)
 	}
 
 	if err := execCmd("gofmt", "-w", gofile); err != nil {
 		throw(err.Error())
-		os.Exit(1)
+		exit(1)
 	}
+	exit(0)
 }
 
 func throw(err string) {
 	fmt.Fprintln(os.Stderr, err)
 }
 
-func generateResourceFiles(structType *ast.StructType, resourceDir string) (n int, err error) {
+func exit(code int) {
+	if len(workdir) > 0 {
+		os.Chdir(workdir)
+	}
+	osExit(code)
+}
+
+// resourceFilePlan describes the resource resolved from the ResourceManager
+// field, and how its files are generated.
+type resourceFilePlan struct {
+	// the position of ResourceManager field, e.g: app.go:12:2.
+	pos token.Position
+	// the resource type name, e.g: order.EchoResource.
+	typename     string
+	packageName  string
+	resourceName string
+	filename     string
+	url          string
+	methods      []string
+	// the CRUD verbs of RESTful resource, nil if @crud is not specified.
+	crud *ResourceCrud
+}
+
+// generateResourceFiles plans the resource files of ResourceManager fields
+// and generates them in the order of resource type names. It keeps generating
+// the other resources when a resource failed, and returns all the errors as
+// scanner.ErrorList. The packages of the generated resources are returned
+// relative to resourceDir, the root resource package is "".
+func generateResourceFiles(fset *token.FileSet, structType *ast.StructType, resourceDir string) (n int, packages []string, err error) {
+	plans, errs := resolveResourceFilePlans(fset, structType)
+
 	var (
-		resourceFileNames = make(map[string]string, len(structType.Fields.List))
+		count              int
+		resourcePackageMap = make(map[string]bool)
+	)
+	for _, plan := range plans {
+		fmt.Printf("generating '%s' ...", plan.typename)
+
+		status, err := generateResourceFile(plan, resourceDir)
+		if err != nil {
+			fmt.Println("failed")
+			errs.Add(plan.pos, fmt.Sprintf("cannot generate '%s': %v", plan.typename, err))
+			continue
+		}
+		fmt.Println(status)
+		if status != GENERATE_STATUS_SKIPPED {
+			count++
+		}
+
+		resourcePackageMap[plan.packageName] = true
+	}
+
+	// export packages
+	if len(resourcePackageMap) > 0 {
+		packages = make([]string, 0, len(resourcePackageMap))
+		for k := range resourcePackageMap {
+			packages = append(packages, k)
+		}
+		sort.Strings(packages)
+	}
+
+	errs.Sort()
+	return count, packages, errs.Err()
+}
+
+// resolveResourceFilePlans resolves the plans of ResourceManager fields
+// sorted by the resource type names. The fields with @skip and the duplicated
+// resources are ignored.
+func resolveResourceFilePlans(fset *token.FileSet, structType *ast.StructType) ([]*resourceFilePlan, scanner.ErrorList) {
+	var (
+		plans = make([]*resourceFilePlan, 0, len(structType.Fields.List))
+		// the resource type names by output file
+		resourceFileMap = make(map[string]string)
+		// the CRUD resource type names by repository name
+		repositoryMap = make(map[string]string)
+		errs          scanner.ErrorList
 	)
 
 	for _, field := range structType.Fields.List {
-		switch field.Type.(type) {
-		case *ast.StarExpr:
-			star := field.Type.(*ast.StarExpr)
-			i, ok := star.X.(*ast.Ident)
-			if ok {
-				typename := i.Name
-
-				filename := resolveResourceFileName(typename)
-				if len(filename) > 0 {
-					if resourceType, ok := resourceFileNames[filename]; ok {
-						throw(fmt.Sprintf("output file '%s' is ambiguous on resource type name '%s' and '%s'",
-							filename,
-							resourceType,
-							typename))
-						os.Exit(1)
-					}
-					resourceFileNames[filename] = typename
+		plan, err := resolveResourceFilePlan(field)
+		if err != nil {
+			errs.Add(fset.Position(field.Pos()), err.Error())
+			continue
+		}
+		if plan == nil {
+			continue
+		}
+
+		output := path.Join(plan.packageName, plan.filename)
+		if typename, ok := resourceFileMap[output]; ok {
+			// duplicated resource name?
+			if typename != plan.typename {
+				errs.Add(fset.Position(field.Pos()), fmt.Sprintf("output file '%s' is ambiguous on resource type name '%s' and '%s'",
+					output+".go",
+					typename,
+					plan.typename))
+			}
+			continue
+		}
+		resourceFileMap[output] = plan.typename
+
+		// the repositories of CRUD resources are in the same package
+		if plan.crud != nil {
+			if plan.crud.HasItem() {
+				var (
+					itemResourceName = getCrudItemResourceName(plan.resourceName)
+					itemTypename     = getResourceTypename(plan.packageName, itemResourceName)
+					itemOutput       = path.Join(plan.packageName, resolveResourceFileName(itemResourceName))
+				)
+				if typename, ok := resourceFileMap[itemOutput]; ok {
+					errs.Add(fset.Position(field.Pos()), fmt.Sprintf("output file '%s' is ambiguous on resource type name '%s' and '%s'",
+						itemOutput+".go",
+						typename,
+						itemTypename))
+					continue
 				}
+				resourceFileMap[itemOutput] = itemTypename
 			}
+
+			repositoryName := getRepositoryName(getResourcePrefix(plan.resourceName))
+			if typename, ok := repositoryMap[repositoryName]; ok {
+				errs.Add(fset.Position(field.Pos()), fmt.Sprintf("repository '%s' is ambiguous on resource type name '%s' and '%s'",
+					repositoryName,
+					typename,
+					plan.typename))
+				continue
+			}
+			repositoryMap[repositoryName] = plan.typename
 		}
+
+		plan.pos = fset.Position(field.Pos())
+		plans = append(plans, plan)
 	}
 
-	var count int = 0
-	if len(resourceFileNames) > 0 {
-		if _, err := os.Stat(resourceDir); os.IsNotExist(err) {
-			os.Mkdir(resourceDir, os.ModePerm)
-		}
+	sort.Slice(plans, func(i, j int) bool {
+		return plans[i].typename < plans[j].typename
+	})
+	return plans, errs
+}
 
-		for filename, typename := range resourceFileNames {
+// resolveResourceFilePlan resolves the plan of ResourceManager field. It
+// returns nil if the field should not be generated.
+func resolveResourceFilePlan(field *ast.Field) (*resourceFilePlan, error) {
+	plan := &resourceFilePlan{
+		methods: defaultResourceMethods,
+	}
 
-			ok, err := writeResourceFile(filename, typename, resourceDir)
-			if err != nil {
-				return count, err
+	// resolve tag
+	if field.Tag != nil && field.Tag.Kind == token.STRING {
+		tagLiteral, err := strconv.Unquote(field.Tag.Value)
+		if err != nil {
+			tagLiteral = field.Tag.Value
+		}
+		tag := reflect.StructTag(tagLiteral)
+
+		// has @skip
+		{
+			val, ok := tag.Lookup(TAG_SKIP_OPT_NAME)
+			if ok {
+				if len(val) == 0 || val == "on" {
+					return nil, nil
+				}
 			}
+		}
+		// has url?
+		{
+			val, ok := tag.Lookup(TAG_URL_NAME)
 			if ok {
-				count++
+				if !strings.HasPrefix(val, "/") {
+					return nil, fmt.Errorf("invalid url '%s', should start with '/'", val)
+				}
+				plan.url = val
 			}
 		}
+		// has @methods?
+		{
+			val, ok := tag.Lookup(TAG_METHODS_OPT_NAME)
+			if ok {
+				plan.methods, err = parseResourceMethods(val)
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+		// has @crud?
+		{
+			val, ok := tag.Lookup(TAG_CRUD_OPT_NAME)
+			if ok {
+				if _, ok := tag.Lookup(TAG_METHODS_OPT_NAME); ok {
+					return nil, fmt.Errorf("%s and %s cannot be used together", TAG_CRUD_OPT_NAME, TAG_METHODS_OPT_NAME)
+				}
+				plan.crud, err = parseResourceCrud(val)
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	star, ok := field.Type.(*ast.StarExpr)
+	if !ok {
+		return nil, nil
 	}
-	return count, nil
+	switch x := star.X.(type) {
+	case *ast.SelectorExpr:
+		ident, ok := x.X.(*ast.Ident)
+		if !ok {
+			return nil, fmt.Errorf("cannot resolve resource package name of '%s'", x.Sel.Name)
+		}
+		plan.resourceName = x.Sel.Name
+		plan.packageName = ident.Name
+		plan.typename = plan.packageName + "." + plan.resourceName
+	case *ast.Ident:
+		plan.resourceName = x.Name
+		plan.typename = x.Name
+	default:
+		return nil, nil
+	}
+
+	plan.filename = resolveResourceFileName(plan.resourceName)
+	if len(plan.filename) == 0 {
+		return nil, nil
+	}
+	if len(plan.url) == 0 {
+		return nil, fmt.Errorf("missing url of resource '%s'", plan.typename)
+	}
+	return plan, nil
+}
+
+// generateResourceFile generates the resource file and its test file of the
+// resource plan. It returns the generating status, e.g: ok or skipped.
+func generateResourceFile(plan *resourceFilePlan, resourceDir string) (string, error) {
+	var (
+		packageDir  = resourceDir
+		packageName = RESOURCE_MODULE_NAME
+	)
+
+	if len(plan.packageName) > 0 {
+		packageDir = path.Join(resourceDir, plan.packageName)
+		packageName = plan.packageName
+	}
+	if err := os.MkdirAll(packageDir, os.ModePerm); err != nil {
+		return "", err
+	}
+
+	file, err := createFile(plan.filename, packageDir)
+	if err != nil {
+		if os.IsExist(err) {
+			return GENERATE_STATUS_SKIPPED, nil
+		}
+		return "", err
+	}
+	defer file.Close()
+
+	if plan.crud != nil {
+		return generateCrudResourceFile(plan, file, packageDir, packageName, resourceDir)
+	}
+
+	writer := &ResourceFileWriter{
+		ResourcePackageName: packageName,
+		ResourceName:        plan.resourceName,
+		Url:                 plan.url,
+		Methods:             make([]*ResourceMethod, 0, len(plan.methods)),
+		ResourceFile:        file,
+	}
+	for _, method := range plan.methods {
+		writer.Methods = append(writer.Methods, &ResourceMethod{
+			Name:       resourceMethodTable[method],
+			HttpMethod: method,
+		})
+	}
+
+	// the existing test file is kept
+	testFile, err := createFile(plan.filename+TEST_FILE_SUFFIX, packageDir)
+	if err != nil {
+		if !os.IsExist(err) {
+			return "", err
+		}
+	} else {
+		defer testFile.Close()
+		writer.ResourceTestFile = testFile
+	}
+
+	err = writer.Write()
+	if err != nil {
+		return "", err
+	}
+	return GENERATE_STATUS_OK, nil
+}
+
+// generateCrudResourceFile generates the files of the CRUD resource besides
+// the resource file, i.e. the test file, argv, reply and repository files,
+// and adds the repository to ServiceProvider. The existing files are kept.
+func generateCrudResourceFile(plan *resourceFilePlan, file io.Writer, packageDir, packageName, resourceDir string) (string, error) {
+	var (
+		internalDir = filepath.Join(filepath.Dir(resourceDir), INTERNAL_MODULE_NAME)
+		argsDir     = path.Join(packageDir, ARGS_DIR_PATH)
+		replyDir    = path.Join(packageDir, REPLY_DIR_PATH)
+		prefix      = getResourcePrefix(plan.resourceName)
+		filePrefix  = strings.TrimSuffix(plan.filename, RESOURCE_TYPE_SUFFIX)
+		// the items are handled by the item resource in its own file
+		itemFilename = resolveResourceFileName(getCrudItemResourceName(plan.resourceName))
+	)
+
+	writer := &CrudResourceFileWriter{
+		AppModuleName:       appModuleName,
+		ResourcePackageName: packageName,
+		ResourcePackagePath: filepath.ToSlash(path.Join(RESOURCE_MODULE_NAME, plan.packageName)),
+		ResourceName:        plan.resourceName,
+		ResourcePrefix:      prefix,
+		Url:                 plan.url,
+		Crud:                plan.crud,
+		ResourceFile:        file,
+	}
+
+	for _, f := range []struct {
+		enabled  bool
+		dir      string
+		filename string
+		writer   *io.Writer
+	}{
+		{true, packageDir, plan.filename + TEST_FILE_SUFFIX, &writer.ResourceTestFile},
+		{plan.crud.HasItem(), packageDir, itemFilename, &writer.ItemResourceFile},
+		{plan.crud.HasItem(), packageDir, itemFilename + TEST_FILE_SUFFIX, &writer.ItemResourceTestFile},
+		{plan.crud.List, argsDir, filePrefix + "ListArgv", &writer.ListArgvFile},
+		{plan.crud.Create, argsDir, filePrefix + "CreateArgv", &writer.CreateArgvFile},
+		{plan.crud.Update, argsDir, filePrefix + "UpdateArgv", &writer.UpdateArgvFile},
+		{true, replyDir, filePrefix + "Reply", &writer.ReplyFile},
+		{true, replyDir, ERROR_REPLY_FILE_NAME, &writer.ErrorReplyFile},
+		{true, internalDir, resolveRepositoryFileName(prefix), &writer.RepositoryFile},
+	} {
+		if !f.enabled {
+			continue
+		}
+		if err := os.MkdirAll(f.dir, os.ModePerm); err != nil {
+			return "", err
+		}
+		out, err := createFile(f.filename, f.dir)
+		if err != nil {
+			if os.IsExist(err) {
+				continue
+			}
+			return "", err
+		}
+		defer out.Close()
+		*f.writer = out
+	}
+
+	err := writer.Write()
+	if err != nil {
+		return "", err
+	}
+
+	err = addServiceProviderRepository(internalDir, writer.RepositoryName())
+	if err != nil {
+		return "", err
+	}
+	return GENERATE_STATUS_OK, nil
+}
+
+// parseResourceCrud parses the comma-separated CRUD verbs, e.g:
+// "list,get,create,update,delete".
+func parseResourceCrud(value string) (*ResourceCrud, error) {
+	var (
+		crud    = new(ResourceCrud)
+		visited bool
+	)
+
+	for _, v := range strings.Split(value, ",") {
+		verb := strings.ToLower(strings.TrimSpace(v))
+		switch verb {
+		case "":
+			continue
+		case CRUD_LIST:
+			crud.List = true
+		case CRUD_GET:
+			crud.Get = true
+		case CRUD_CREATE:
+			crud.Create = true
+		case CRUD_UPDATE:
+			crud.Update = true
+		case CRUD_DELETE:
+			crud.Delete = true
+		default:
+			return nil, fmt.Errorf("unsupported crud verb '%s'", v)
+		}
+		visited = true
+	}
+	if !visited {
+		return nil, fmt.Errorf("no crud verb specified in '%s'", value)
+	}
+	return crud, nil
+}
+
+// parseResourceMethods parses the comma-separated resource methods, e.g:
+// "GET,PUT,DELETE".
+func parseResourceMethods(value string) ([]string, error) {
+	var (
+		methods []string
+		visited = make(map[string]bool)
+	)
+
+	for _, v := range strings.Split(value, ",") {
+		method := strings.ToUpper(strings.TrimSpace(v))
+		if len(method) == 0 {
+			continue
+		}
+		if _, ok := resourceMethodTable[method]; !ok {
+			return nil, fmt.Errorf("unsupported resource method '%s'", v)
+		}
+		if !visited[method] {
+			visited[method] = true
+			methods = append(methods, method)
+		}
+	}
+	if len(methods) == 0 {
+		return nil, fmt.Errorf("no resource method specified in '%s'", value)
+	}
+	return methods, nil
 }
 
 // Resolve the resource type name to file name.
 // e.g: EchoResource to echoResource, XMLResource to xmlResource.
 func resolveResourceFileName(typename string) string {
-	if strings.HasSuffix(typename, RESOURCE_TYPE_SUFFIX) {
+	if strings.HasSuffix(typename, RESOURCE_TYPE_SUFFIX) && len(typename) > len(RESOURCE_TYPE_SUFFIX) {
 		var (
 			runes  = []rune(typename)
 			length = len(runes)
@@ -173,7 +632,7 @@ func resolveResourceFileName(typename string) string {
 
 		if ch := runes[0]; unicode.IsUpper(rune(ch)) && unicode.IsLetter(ch) {
 			var pos int = 0
-			for i := 0; i < length; i++ {
+			for i := 0; i < length-1; i++ {
 				if unicode.IsUpper(runes[i]) && unicode.IsLower(runes[i+1]) {
 					pos = i
 					break
@@ -188,53 +647,158 @@ func resolveResourceFileName(typename string) string {
 	return ""
 }
 
-func writeResourceFile(filename, typename string, resourceDir string) (bool, error) {
-	path := filepath.Join(resourceDir, filename+".go")
+// getResourcePrefix returns the resource type name without suffix, e.g:
+// Order of OrderResource.
+func getResourcePrefix(resourceName string) string {
+	return strings.TrimSuffix(resourceName, RESOURCE_TYPE_SUFFIX)
+}
 
-	if _, err := os.Stat(path); os.IsNotExist(err) {
-		f, err := os.Create(path)
-		if err != nil {
-			return false, err
-		}
-		err = writeResouceContent(f, typename)
-		if err != nil {
-			return false, err
-		}
-		return true, nil
+// getCrudItemResourceName returns the resource type name handling the items
+// of the CRUD resource, e.g: OrderItemResource of OrderResource.
+func getCrudItemResourceName(resourceName string) string {
+	return getResourcePrefix(resourceName) + CRUD_ITEM_RESOURCE_SUFFIX
+}
+
+// getCrudItemUrl returns the url of the CRUD resource items, e.g:
+// /orders/{id} of /orders.
+func getCrudItemUrl(url string) string {
+	return strings.TrimSuffix(url, "/") + "/{" + CRUD_ID_PATH_PARAM_NAME + "}"
+}
+
+// getResourceTypename returns the resource type name qualified by package,
+// e.g: order.OrderResource.
+func getResourceTypename(packageName, resourceName string) string {
+	if len(packageName) > 0 {
+		return packageName + "." + resourceName
 	}
-	return false, nil
+	return resourceName
 }
 
-func writeResouceContent(file *os.File, typename string) error {
-	_, err := fmt.Fprintf(file,
-		RESOURCE_FILE_TEMPLATE, typename)
+// getRepositoryName returns the repository type name of the resource prefix,
+// e.g: OrderRepository of Order.
+func getRepositoryName(resourcePrefix string) string {
+	return resourcePrefix + REPOSITORY_TYPE_SUFFIX
+}
 
-	return err
+// Resolve the repository file name of the resource prefix.
+// e.g: Order to orderRepository, XML to xmlRepository.
+func resolveRepositoryFileName(resourcePrefix string) string {
+	filename := resolveResourceFileName(resourcePrefix + RESOURCE_TYPE_SUFFIX)
+	return strings.TrimSuffix(filename, RESOURCE_TYPE_SUFFIX) + REPOSITORY_TYPE_SUFFIX
 }
 
-func importResourceModulePath(fset *token.FileSet, f *ast.File) error {
-	modulePath, err := getModulePath()
-	if err != nil {
-		throw(err.Error())
-		os.Exit(1)
+func createFile(filename string, dir string) (*os.File, error) {
+	return os.OpenFile(filepath.Join(dir, filename+".go"), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+}
+
+// registerCrudItemResources adds the item resources of the CRUD resources to
+// ResourceManager after them, so that the items are routed by the item url,
+// e.g:
+//
+//	*OrderItemResource `url:"/orders/{id}" @skip:"on"`
+//
+// The item resources are generated with the CRUD resources, so they are
+// declared with @skip. It reports whether any field is added.
+func registerCrudItemResources(structType *ast.StructType) bool {
+	if structType == nil {
+		return false
 	}
-	resourceModulePath := modulePath + "/" + RESOURCE_SUBMODULE_NAME
-	ok := astutil.AddNamedImport(fset, f, ".", resourceModulePath)
-	if ok {
-		stream, err := os.OpenFile(gofile, os.O_WRONLY|os.O_TRUNC, os.ModePerm)
-		if err != nil {
-			return err
+
+	declared := make(map[string]bool)
+	for _, field := range structType.Fields.List {
+		if typename := getFieldTypeName(field); len(typename) > 0 {
+			declared[typename] = true
 		}
-		err = printer.Fprint(stream, fset, f)
-		if err != nil {
-			return err
+	}
+
+	var (
+		fields = make([]*ast.Field, 0, len(structType.Fields.List))
+		added  bool
+	)
+	for _, field := range structType.Fields.List {
+		fields = append(fields, field)
+
+		plan, err := resolveResourceFilePlan(field)
+		if err != nil || plan == nil || plan.crud == nil || !plan.crud.HasItem() {
+			continue
+		}
+
+		itemResourceName := getCrudItemResourceName(plan.resourceName)
+		if declared[getResourceTypename(plan.packageName, itemResourceName)] {
+			continue
 		}
+
+		var typeExpr ast.Expr = ast.NewIdent(itemResourceName)
+		if len(plan.packageName) > 0 {
+			typeExpr = &ast.SelectorExpr{
+				X:   ast.NewIdent(plan.packageName),
+				Sel: ast.NewIdent(itemResourceName),
+			}
+		}
+		fields = append(fields, &ast.Field{
+			Type: &ast.StarExpr{X: typeExpr},
+			Tag: &ast.BasicLit{
+				Kind:  token.STRING,
+				Value: fmt.Sprintf("`%s:%q %s:\"on\"`", TAG_URL_NAME, getCrudItemUrl(plan.url), TAG_SKIP_OPT_NAME),
+			},
+		})
+		added = true
 	}
-	return nil
+	structType.Fields.List = fields
+	return added
 }
 
-func getModulePath() (string, error) {
-	goModBytes, err := ioutil.ReadFile("go.mod")
+// getFieldTypeName returns the type name of the embedded pointer field, e.g:
+// order.OrderResource of *order.OrderResource.
+func getFieldTypeName(field *ast.Field) string {
+	star, ok := field.Type.(*ast.StarExpr)
+	if !ok {
+		return ""
+	}
+	switch x := star.X.(type) {
+	case *ast.SelectorExpr:
+		if ident, ok := x.X.(*ast.Ident); ok {
+			return getResourceTypename(ident.Name, x.Sel.Name)
+		}
+	case *ast.Ident:
+		return x.Name
+	}
+	return ""
+}
+
+// importResourceModulePath imports the resource packages into f. It reports
+// whether any import is added.
+func importResourceModulePath(fset *token.FileSet, f *ast.File, packages []string) bool {
+	var (
+		shouldUpdateImport bool
+	)
+
+	for _, name := range packages {
+		var ok bool
+		if len(name) == 0 {
+			ok = astutil.AddNamedImport(fset, f, ".", appModuleName+"/"+RESOURCE_MODULE_NAME)
+		} else {
+			ok = astutil.AddImport(fset, f, appModuleName+"/"+RESOURCE_MODULE_NAME+"/"+name)
+		}
+		if ok {
+			shouldUpdateImport = ok
+		}
+	}
+	return shouldUpdateImport
+}
+
+func writeGoFile(fset *token.FileSet, f *ast.File) error {
+	stream, err := os.OpenFile(gofile, os.O_WRONLY|os.O_TRUNC, os.ModePerm)
+	if err != nil {
+		return err
+	}
+	defer stream.Close()
+
+	return printer.Fprint(stream, fset, f)
+}
+
+func getAppModuleName() (string, error) {
+	goModBytes, err := os.ReadFile("go.mod")
 	if err != nil {
 		return "", err
 	}
@@ -244,10 +808,33 @@ func getModulePath() (string, error) {
 	return modName, nil
 }
 
-func execCmd(name string, arg ...string) error {
-	cmd := exec.Command(name, arg...)
+func execCmd(name string, args ...string) error {
+	cmd := exec.Command(name, args...)
 	cmd.Stdin = os.Stdin
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	return cmd.Run()
+
+	var (
+		stdout io.ReadCloser
+		stderr io.ReadCloser
+
+		err error
+	)
+
+	if stdout, err = cmd.StdoutPipe(); err != nil {
+		return err
+	}
+	if stderr, err = cmd.StderrPipe(); err != nil {
+		return err
+	}
+	reader := io.MultiReader(stdout, stderr)
+	scanner := bufio.NewScanner(reader)
+	go func() {
+		for scanner.Scan() {
+			fmt.Println(scanner.Text())
+		}
+	}()
+
+	if err = cmd.Start(); err != nil {
+		return err
+	}
+	return cmd.Wait()
 }
@@ -0,0 +1,632 @@
+package main
+
+import (
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+const _EXPECT_FILE_ECHO_RESOURCE_GO string = `package resource
+
+import (
+	"github.com/Bofry/host-fasthttp/response"
+	"github.com/valyala/fasthttp"
+)
+
+type EchoResource struct{}
+
+// Get handles GET /echo.
+func (r *EchoResource) Get(ctx *fasthttp.RequestCtx) {
+	response.Success(ctx, "text/plain", []byte("OK"))
+}
+
+// Post handles POST /echo.
+func (r *EchoResource) Post(ctx *fasthttp.RequestCtx) {
+	response.Success(ctx, "text/plain", []byte("OK"))
+}
+`
+
+const _EXPECT_FILE_ECHO_RESOURCE_TEST_GO string = `package resource
+
+import (
+	"testing"
+
+	"github.com/valyala/fasthttp"
+)
+
+func TestEchoResource_Get(t *testing.T) {
+	r := &EchoResource{}
+
+	ctx := new(fasthttp.RequestCtx)
+	ctx.Request.Header.SetMethod(fasthttp.MethodGet)
+	ctx.Request.SetRequestURI("/echo")
+
+	r.Get(ctx)
+
+	if code := ctx.Response.StatusCode(); code != fasthttp.StatusOK {
+		t.Errorf("status code expect %d, got %d", fasthttp.StatusOK, code)
+	}
+	if body := string(ctx.Response.Body()); body != "OK" {
+		t.Errorf("body expect %q, got %q", "OK", body)
+	}
+}
+
+func TestEchoResource_Post(t *testing.T) {
+	r := &EchoResource{}
+
+	ctx := new(fasthttp.RequestCtx)
+	ctx.Request.Header.SetMethod(fasthttp.MethodPost)
+	ctx.Request.SetRequestURI("/echo")
+
+	r.Post(ctx)
+
+	if code := ctx.Response.StatusCode(); code != fasthttp.StatusOK {
+		t.Errorf("status code expect %d, got %d", fasthttp.StatusOK, code)
+	}
+	if body := string(ctx.Response.Body()); body != "OK" {
+		t.Errorf("body expect %q, got %q", "OK", body)
+	}
+}
+`
+
+func TestGenerateResourceFiles(t *testing.T) {
+	tmp := t.TempDir()
+	resourceDir := path.Join(tmp, RESOURCE_MODULE_NAME)
+
+	defaultResourceMethods = []string{"GET"}
+	defer func() { defaultResourceMethods = nil }()
+
+	source := strings.ReplaceAll(`package main
+
+type ResourceManager struct {
+	*EchoResource        ”url:"/echo"         @methods:"GET,POST"”
+	*order.OrderResource ”url:"/order/{id}"   @methods:"GET,DELETE"”
+	*HealthResource      ”url:"/health"”
+	*LegacyResource      ”url:"/legacy"       @skip:"on"”
+	*EchoResource        ”url:"/echo2"”
+}
+`, "”", "`")
+	fset := token.NewFileSet()
+
+	n, packages, err := generateResourceFiles(fset, parseResourceManager(t, fset, source), resourceDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 3 {
+		t.Errorf("generated resources expect %d, got %d", 3, n)
+	}
+	if expected := []string{"", "order"}; !reflect.DeepEqual(packages, expected) {
+		t.Errorf("packages expect %v, got %v", expected, packages)
+	}
+
+	for _, c := range []struct {
+		filename string
+		expected string
+	}{
+		{"echoResource.go", _EXPECT_FILE_ECHO_RESOURCE_GO},
+		{"echoResource_test.go", _EXPECT_FILE_ECHO_RESOURCE_TEST_GO},
+	} {
+		content, err := os.ReadFile(path.Join(resourceDir, c.filename))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(content) != c.expected {
+			t.Errorf("%s expect:\n%s\ngot:\n%s\n", c.filename, c.expected, string(content))
+		}
+	}
+
+	content, err := os.ReadFile(path.Join(resourceDir, "order", "orderResource.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{
+		"package order\n",
+		"// Delete handles DELETE /order/{id}.\nfunc (r *OrderResource) Delete(ctx *fasthttp.RequestCtx) {",
+	} {
+		if !strings.Contains(string(content), s) {
+			t.Errorf("orderResource.go should contain %q, got:\n%s\n", s, string(content))
+		}
+	}
+	for _, filename := range []string{"healthResource.go", "healthResource_test.go", "order/orderResource_test.go"} {
+		if _, err := os.Stat(path.Join(resourceDir, filename)); err != nil {
+			t.Errorf("%s should be generated", filename)
+		}
+	}
+	if _, err := os.Stat(path.Join(resourceDir, "legacyResource.go")); !os.IsNotExist(err) {
+		t.Errorf("legacyResource.go should be skipped")
+	}
+
+	// the existing resources are not overwritten
+	assert(t, os.WriteFile(path.Join(resourceDir, "echoResource.go"), []byte("package resource\n"), 0644))
+	n, _, err = generateResourceFiles(fset, parseResourceManager(t, fset, source), resourceDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 0 {
+		t.Errorf("generated resources expect %d, got %d", 0, n)
+	}
+	content, err = os.ReadFile(path.Join(resourceDir, "echoResource.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "package resource\n" {
+		t.Errorf("echoResource.go should not be overwritten, got:\n%s\n", string(content))
+	}
+}
+
+func TestGenerateResourceFiles_Errors(t *testing.T) {
+	tmp := t.TempDir()
+	resourceDir := path.Join(tmp, RESOURCE_MODULE_NAME)
+
+	defaultResourceMethods = []string{"GET"}
+	defer func() { defaultResourceMethods = nil }()
+
+	source := strings.ReplaceAll(`package main
+
+type ResourceManager struct {
+	*OrderResource  ”url:"/order"    @methods:"GET,FETCH"”
+	*HealthResource ”url:"/health"”
+	*XMLResource    ”url:"/xml"”
+	*XmlResource    ”url:"/xml2"”
+	*EchoResource   ”url:"echo"”
+	*PingResource
+}
+`, "”", "`")
+	fset := token.NewFileSet()
+	n, _, err := generateResourceFiles(fset, parseResourceManager(t, fset, source), resourceDir)
+	if n != 2 {
+		t.Errorf("generated resources expect %d, got %d", 2, n)
+	}
+	errs, ok := err.(scanner.ErrorList)
+	if !ok {
+		t.Fatalf("should get scanner.ErrorList, got %v", err)
+	}
+	expectedErrors := []string{
+		"app.go:4:2: unsupported resource method 'FETCH'",
+		"app.go:7:2: output file 'xmlResource.go' is ambiguous on resource type name 'XMLResource' and 'XmlResource'",
+		"app.go:8:2: invalid url 'echo', should start with '/'",
+		"app.go:9:2: missing url of resource 'PingResource'",
+	}
+	if len(errs) != len(expectedErrors) {
+		t.Fatalf("errors expect %d, got %d:\n%v", len(expectedErrors), len(errs), errs)
+	}
+	for i, expected := range expectedErrors {
+		if errs[i].Error() != expected {
+			t.Errorf("error expect %q, got %q", expected, errs[i].Error())
+		}
+	}
+}
+
+func TestParseResourceMethods(t *testing.T) {
+	methods, err := parseResourceMethods(" get, Delete,GET,")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{"GET", "DELETE"}; !reflect.DeepEqual(methods, expected) {
+		t.Errorf("methods expect %v, got %v", expected, methods)
+	}
+	if _, err := parseResourceMethods(","); err == nil {
+		t.Errorf("should get error of no resource method")
+	}
+}
+
+func TestResolveResourceFileName(t *testing.T) {
+	cases := map[string]string{
+		"EchoResource": "echoResource",
+		"XMLResource":  "xmlResource",
+		"Resource":     "",
+		"echoResource": "",
+		"EchoHandler":  "",
+	}
+	for typename, expected := range cases {
+		if filename := resolveResourceFileName(typename); filename != expected {
+			t.Errorf("%s: file name expect %q, got %q", typename, expected, filename)
+		}
+	}
+}
+
+func parseResourceManager(t *testing.T, fset *token.FileSet, source string) *ast.StructType {
+	f, err := parser.ParseFile(fset, "app.go", source, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return f.Decls[0].(*ast.GenDecl).Specs[0].(*ast.TypeSpec).Type.(*ast.StructType)
+}
+
+func assert(t *testing.T, err ...error) {
+	for _, e := range err {
+		if e != nil {
+			t.Fatal(e)
+		}
+	}
+}
+
+func TestGenerateResourceFiles_Crud(t *testing.T) {
+	tmp := t.TempDir()
+	resourceDir := path.Join(tmp, RESOURCE_MODULE_NAME)
+
+	defaultAppModuleName := appModuleName
+	defaultResourceMethods = []string{"GET"}
+	defer func() {
+		appModuleName = defaultAppModuleName
+		defaultResourceMethods = nil
+	}()
+	appModuleName = "host-fasthttp-resource-demo"
+
+	assert(t,
+		os.MkdirAll(path.Join(tmp, INTERNAL_MODULE_NAME), os.ModePerm),
+		os.WriteFile(path.Join(tmp, INTERNAL_MODULE_NAME, "serviceProvider.go"), []byte(`package internal
+
+type ServiceProvider struct {}
+
+func (p *ServiceProvider) Init(conf *Config, app *App) {
+	// initialize service provider components
+}
+`), 0644),
+	)
+
+	source := strings.ReplaceAll(`package main
+
+type ResourceManager struct {
+	*OrderResource     ”url:"/orders"  @crud:"list,get,create,update,delete"”
+	*shop.ItemResource ”url:"/items/"  @crud:"get,delete"”
+}
+`, "”", "`")
+	fset := token.NewFileSet()
+
+	n, packages, err := generateResourceFiles(fset, parseResourceManager(t, fset, source), resourceDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 {
+		t.Errorf("generated resources expect %d, got %d", 2, n)
+	}
+	if expected := []string{"", "shop"}; !reflect.DeepEqual(packages, expected) {
+		t.Errorf("packages expect %v, got %v", expected, packages)
+	}
+
+	for _, c := range []struct {
+		filename string
+		contains []string
+	}{
+		{"resource/orderResource.go", []string{
+			"\t\"host-fasthttp-resource-demo/resource/args\"\n",
+			"// Get lists the entities, handles GET /orders.\nfunc (r *OrderResource) Get(ctx *fasthttp.RequestCtx) {",
+			"// Post creates the entity, handles POST /orders.\nfunc (r *OrderResource) Post(ctx *fasthttp.RequestCtx) {",
+			"r.ServiceProvider.OrderRepository.List(argv.Filter(), argv.Offset(), argv.Limit())",
+			"if errors.Is(err, ErrOrderNotFound) {",
+		}},
+		{"resource/orderItemResource.go", []string{
+			"type OrderItemResource struct {",
+			"// Get handles GET /orders/{id}.\nfunc (r *OrderItemResource) Get(ctx *fasthttp.RequestCtx) {",
+			"// Put updates the entity, handles PUT /orders/{id}.\nfunc (r *OrderItemResource) Put(ctx *fasthttp.RequestCtx) {",
+			"// Delete handles DELETE /orders/{id}.\nfunc (r *OrderItemResource) Delete(ctx *fasthttp.RequestCtx) {",
+		}},
+		{"resource/orderResource_test.go", []string{
+			"func TestOrderResource_Get(t *testing.T) {",
+			"func TestOrderResource_Post(t *testing.T) {",
+		}},
+		{"resource/orderItemResource_test.go", []string{
+			"func TestOrderItemResource_Put(t *testing.T) {",
+			"func TestOrderItemResource_Delete(t *testing.T) {",
+			`ctx.Request.SetRequestURI("/orders/" + entity.Id)`,
+		}},
+		{"resource/args/orderListArgv.go", []string{
+			"type OrderListArgv struct /* tag=query */ {",
+			"v.PageSize(arg.IntPtr.GreaterOrEqual(1), arg.IntPtr.LessOrEqual(100)),",
+		}},
+		{"resource/args/orderCreateArgv.go", []string{"type OrderCreateArgv struct /* tag=json */ {"}},
+		{"resource/args/orderUpdateArgv.go", []string{"type OrderUpdateArgv struct /* tag=json */ {"}},
+		{"resource/reply/orderReply.go", []string{
+			"type OrderReply struct {",
+			"\tItems []*OrderReply `json:\"items\"`\n\tTotal int           `json:\"total\"`\n",
+		}},
+		{"resource/reply/error.go", []string{"type ErrorReply struct {"}},
+		{"internal/orderRepository.go", []string{
+			"\tList(keyword string, offset, limit int) ([]*OrderEntity, int, error)\n",
+			`ErrOrderNotFound = errors.New("order not found")`,
+		}},
+		{"resource/shop/itemResource.go", []string{
+			"package shop\n",
+			"type ItemResource struct {",
+		}},
+		{"resource/shop/itemItemResource.go", []string{
+			"package shop\n",
+			"// Delete handles DELETE /items/{id}.",
+		}},
+		{"resource/shop/itemItemResource_test.go", []string{`ctx.Request.SetRequestURI("/items/" + entity.Id)`}},
+		{"internal/itemRepository.go", []string{
+			"type ItemRepository interface {\n\tGet(id string) (*ItemEntity, error)\n\tDelete(id string) error\n}\n",
+		}},
+	} {
+		content, err := os.ReadFile(path.Join(tmp, c.filename))
+		if err != nil {
+			t.Errorf("%s should be generated", c.filename)
+			continue
+		}
+		for _, s := range c.contains {
+			if !strings.Contains(string(content), s) {
+				t.Errorf("%s should contain %q, got:\n%s\n", c.filename, s, string(content))
+			}
+		}
+	}
+	// get and delete have no argv
+	if _, err := os.Stat(path.Join(resourceDir, "shop", ARGS_DIR_PATH)); !os.IsNotExist(err) {
+		t.Errorf("resource/shop/args should not be generated")
+	}
+
+	expectedServiceProvider := `package internal
+
+type ServiceProvider struct {
+	OrderRepository OrderRepository
+	ItemRepository  ItemRepository
+}
+
+func (p *ServiceProvider) Init(conf *Config, app *App) {
+	// initialize service provider components
+	p.OrderRepository = NewMemoryOrderRepository()
+	p.ItemRepository = NewMemoryItemRepository()
+}
+`
+	content, err := os.ReadFile(path.Join(tmp, INTERNAL_MODULE_NAME, "serviceProvider.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != expectedServiceProvider {
+		t.Errorf("serviceProvider.go expect:\n%s\ngot:\n%s\n", expectedServiceProvider, string(content))
+	}
+
+	// the repository is added once
+	assert(t,
+		os.Remove(path.Join(resourceDir, "orderResource.go")),
+	)
+	_, _, err = generateResourceFiles(fset, parseResourceManager(t, fset, source), resourceDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, err = os.ReadFile(path.Join(tmp, INTERNAL_MODULE_NAME, "serviceProvider.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != expectedServiceProvider {
+		t.Errorf("serviceProvider.go expect:\n%s\ngot:\n%s\n", expectedServiceProvider, string(content))
+	}
+}
+
+func TestGenerateResourceFiles_CrudRoutes(t *testing.T) {
+	tmp := t.TempDir()
+	resourceDir := path.Join(tmp, RESOURCE_MODULE_NAME)
+
+	defaultAppModuleName := appModuleName
+	defer func() {
+		appModuleName = defaultAppModuleName
+	}()
+	appModuleName = "host-fasthttp-resource-demo"
+
+	assert(t,
+		os.MkdirAll(path.Join(tmp, INTERNAL_MODULE_NAME), os.ModePerm),
+		os.WriteFile(path.Join(tmp, INTERNAL_MODULE_NAME, "serviceProvider.go"), []byte(`package internal
+
+type ServiceProvider struct {}
+
+func (p *ServiceProvider) Init(conf *Config, app *App) {
+}
+`), 0644),
+	)
+
+	source := strings.ReplaceAll(`package main
+
+type ResourceManager struct {
+	*OrderResource     ”url:"/orders"  @crud:"list,get,create,update,delete"”
+	*shop.ItemResource ”url:"/items/"  @crud:"get,delete"”
+}
+`, "”", "`")
+	fset := token.NewFileSet()
+	structType := parseResourceManager(t, fset, source)
+
+	_, _, err := generateResourceFiles(fset, structType, resourceDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !registerCrudItemResources(structType) {
+		t.Errorf("the item resources should be registered")
+	}
+	if registerCrudItemResources(structType) {
+		t.Errorf("the item resources should be registered once")
+	}
+
+	var buf strings.Builder
+	assert(t, format.Node(&buf, fset, structType))
+	expectedResourceManager := strings.ReplaceAll(`struct {
+	*OrderResource         ”url:"/orders"  @crud:"list,get,create,update,delete"”
+	*OrderItemResource     ”url:"/orders/{id}" @skip:"on"”
+	*shop.ItemResource     ”url:"/items/"  @crud:"get,delete"”
+	*shop.ItemItemResource ”url:"/items/{id}" @skip:"on"”
+}`, "”", "`")
+	if buf.String() != expectedResourceManager {
+		t.Errorf("ResourceManager expect:\n%s\ngot:\n%s\n", expectedResourceManager, buf.String())
+	}
+
+	// the generated resources should not be generated again
+	n, _, err := generateResourceFiles(fset, structType, resourceDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 0 {
+		t.Errorf("generated resources expect %d, got %d", 0, n)
+	}
+
+	// route the requests as host-fasthttp, by the url of ResourceManager
+	// field and the resource method named by the HTTP method
+	router := newTestResourceRouter(t, structType, resourceDir)
+	for _, c := range []struct {
+		method   string
+		uri      string
+		expected string
+	}{
+		{"GET", "/orders", "OrderResource.Get"},
+		{"POST", "/orders", "OrderResource.Post"},
+		{"GET", "/orders/1", "OrderItemResource.Get"},
+		{"PUT", "/orders/1", "OrderItemResource.Put"},
+		{"DELETE", "/orders/1", "OrderItemResource.Delete"},
+		{"DELETE", "/orders", ""},
+		{"POST", "/orders/1", ""},
+		{"GET", "/items/1", "shop.ItemItemResource.Get"},
+		{"DELETE", "/items/1", "shop.ItemItemResource.Delete"},
+		{"PUT", "/items/1", ""},
+	} {
+		if handler := router.route(c.method, c.uri); handler != c.expected {
+			t.Errorf("%s %s should be handled by %q, got %q", c.method, c.uri, c.expected, handler)
+		}
+	}
+}
+
+type testResourceRouter struct {
+	routes []*testResourceRoute
+	// the method names of generated resource types
+	methods map[string]map[string]bool
+}
+
+type testResourceRoute struct {
+	segments []string
+	typename string
+}
+
+func newTestResourceRouter(t *testing.T, structType *ast.StructType, resourceDir string) *testResourceRouter {
+	router := &testResourceRouter{
+		methods: make(map[string]map[string]bool),
+	}
+
+	for _, field := range structType.Fields.List {
+		tag, err := strconv.Unquote(field.Tag.Value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		router.routes = append(router.routes, &testResourceRoute{
+			segments: strings.Split(reflect.StructTag(tag).Get(TAG_URL_NAME), "/"),
+			typename: getFieldTypeName(field),
+		})
+	}
+
+	err := filepath.Walk(resourceDir, func(filename string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() || strings.HasSuffix(filename, TEST_FILE_SUFFIX+".go") {
+			return err
+		}
+		f, err := parser.ParseFile(token.NewFileSet(), filename, nil, 0)
+		if err != nil {
+			return err
+		}
+		var packageName string
+		if f.Name.Name != RESOURCE_MODULE_NAME {
+			packageName = f.Name.Name
+		}
+		for _, decl := range f.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Recv == nil {
+				continue
+			}
+			recv := fn.Recv.List[0].Type.(*ast.StarExpr).X.(*ast.Ident).Name
+			typename := getResourceTypename(packageName, recv)
+			if router.methods[typename] == nil {
+				router.methods[typename] = make(map[string]bool)
+			}
+			router.methods[typename][fn.Name.Name] = true
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return router
+}
+
+// route returns the resource method handling the request, e.g:
+// OrderResource.Get, or "" if the method is not allowed.
+func (r *testResourceRouter) route(method, uri string) string {
+	segments := strings.Split(uri, "/")
+	for _, route := range r.routes {
+		if len(route.segments) != len(segments) {
+			continue
+		}
+		matched := true
+		for i, s := range route.segments {
+			if strings.HasPrefix(s, "{") && strings.HasSuffix(s, "}") {
+				matched = len(segments[i]) > 0
+			} else {
+				matched = s == segments[i]
+			}
+			if !matched {
+				break
+			}
+		}
+		if !matched {
+			continue
+		}
+		name := resourceMethodTable[method]
+		if r.methods[route.typename][name] {
+			return route.typename + "." + name
+		}
+		return ""
+	}
+	return ""
+}
+
+func TestGenerateResourceFiles_CrudErrors(t *testing.T) {
+	tmp := t.TempDir()
+	resourceDir := path.Join(tmp, RESOURCE_MODULE_NAME)
+
+	source := strings.ReplaceAll(`package main
+
+type ResourceManager struct {
+	*OrderResource     ”url:"/orders"  @crud:"list,patch"”
+	*ItemResource      ”url:"/items"   @crud:"get" @methods:"GET"”
+	*shop.UserResource ”url:"/users"   @crud:"get"”
+	*auth.UserResource ”url:"/users2"  @crud:"get"”
+}
+`, "”", "`")
+	fset := token.NewFileSet()
+	n, _, err := generateResourceFiles(fset, parseResourceManager(t, fset, source), resourceDir)
+	if n != 0 {
+		t.Errorf("generated resources expect %d, got %d", 0, n)
+	}
+	errs, ok := err.(scanner.ErrorList)
+	if !ok {
+		t.Fatalf("should get scanner.ErrorList, got %v", err)
+	}
+	expectedErrors := []string{
+		"app.go:4:2: unsupported crud verb 'patch'",
+		"app.go:5:2: @crud and @methods cannot be used together",
+		"app.go:6:2: cannot generate 'shop.UserResource': cannot find ServiceProvider in '" + path.Join(tmp, INTERNAL_MODULE_NAME) + "'",
+		"app.go:7:2: repository 'UserRepository' is ambiguous on resource type name 'shop.UserResource' and 'auth.UserResource'",
+	}
+	if len(errs) != len(expectedErrors) {
+		t.Fatalf("errors expect %d, got %d:\n%v", len(expectedErrors), len(errs), errs)
+	}
+	for i, expected := range expectedErrors {
+		if errs[i].Error() != expected {
+			t.Errorf("error expect %q, got %q", expected, errs[i].Error())
+		}
+	}
+}
+
+func TestParseResourceCrud(t *testing.T) {
+	crud, err := parseResourceCrud(" List, get,DELETE,")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := (&ResourceCrud{List: true, Get: true, Delete: true}); !reflect.DeepEqual(crud, expected) {
+		t.Errorf("crud expect %+v, got %+v", expected, crud)
+	}
+	if _, err := parseResourceCrud(","); err == nil {
+		t.Errorf("should get error of no crud verb")
+	}
+}
@@ -0,0 +1,39 @@
+package main
+
+import "io"
+
+var _ FileWriter = new(ResourceFileWriter)
+
+type ResourceFileWriter struct {
+	ResourcePackageName string
+	ResourceName        string
+	Url                 string
+	Methods             []*ResourceMethod
+
+	ResourceFile     io.Writer
+	ResourceTestFile io.Writer
+}
+
+// ResourceMethod describes the route method of resource, e.g: Get handles
+// GET /echo.
+type ResourceMethod struct {
+	Name       string
+	HttpMethod string
+}
+
+func (w *ResourceFileWriter) Write() error {
+	var err error
+
+	err = ResourceFileTemplate.ExecuteTemplate(w.ResourceFile, TEMPLATE_NAME_RESOURCE_FILE, w)
+	if err != nil {
+		return err
+	}
+
+	if w.ResourceTestFile != nil {
+		err = ResourceFileTemplate.ExecuteTemplate(w.ResourceTestFile, TEMPLATE_NAME_RESOURCE_TEST_FILE, w)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
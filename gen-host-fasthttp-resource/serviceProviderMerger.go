@@ -0,0 +1,147 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	SERVICE_PROVIDER_TYPE_NAME   string = "ServiceProvider"
+	SERVICE_PROVIDER_INIT_METHOD string = "Init"
+)
+
+// addServiceProviderRepository adds the repository field to ServiceProvider
+// of the internal package, and initializes it with the in-memory repository
+// in ServiceProvider.Init, e.g:
+//
+//	type ServiceProvider struct {
+//		OrderRepository OrderRepository
+//	}
+//
+//	func (p *ServiceProvider) Init(conf *Config, app *App) {
+//		p.OrderRepository = NewMemoryOrderRepository()
+//	}
+//
+// The ServiceProvider having the field is kept.
+func addServiceProviderRepository(internalDir string, repositoryName string) error {
+	filenames, err := filepath.Glob(filepath.Join(internalDir, "*.go"))
+	if err != nil {
+		return err
+	}
+
+	for _, filename := range filenames {
+		if strings.HasSuffix(filename, TEST_FILE_SUFFIX+".go") {
+			continue
+		}
+		src, err := os.ReadFile(filename)
+		if err != nil {
+			return err
+		}
+
+		fset := token.NewFileSet()
+		f, err := parser.ParseFile(fset, filename, src, parser.ParseComments)
+		if err != nil {
+			return err
+		}
+
+		structType := lookupServiceProviderStruct(f)
+		if structType == nil {
+			continue
+		}
+		for _, field := range structType.Fields.List {
+			for _, name := range field.Names {
+				if name.Name == repositoryName {
+					return nil
+				}
+			}
+		}
+
+		initFunc := lookupServiceProviderInit(f)
+		if initFunc == nil || initFunc.Body == nil {
+			return fmt.Errorf("cannot find %s.%s in '%s'", SERVICE_PROVIDER_TYPE_NAME, SERVICE_PROVIDER_INIT_METHOD, filename)
+		}
+		recv := initFunc.Recv.List[0]
+		if len(recv.Names) == 0 || recv.Names[0].Name == "_" {
+			return fmt.Errorf("cannot initialize %s without the receiver name of %s.%s in '%s'",
+				repositoryName, SERVICE_PROVIDER_TYPE_NAME, SERVICE_PROVIDER_INIT_METHOD, filename)
+		}
+
+		// insert the statement and the field before their closing braces
+		type insertion struct {
+			offset int
+			text   string
+		}
+		insertions := []insertion{
+			{
+				offset: fset.Position(structType.Fields.Closing).Offset,
+				text:   fmt.Sprintf("%s %s", repositoryName, repositoryName),
+			},
+			{
+				offset: fset.Position(initFunc.Body.Rbrace).Offset,
+				text:   fmt.Sprintf("%s.%s = NewMemory%s()", recv.Names[0].Name, repositoryName, repositoryName),
+			},
+		}
+		sort.Slice(insertions, func(i, j int) bool {
+			return insertions[i].offset > insertions[j].offset
+		})
+
+		content := string(src)
+		for _, v := range insertions {
+			text := "\t" + v.text + "\n"
+			if before := strings.TrimRight(content[:v.offset], " \t"); !strings.HasSuffix(before, "\n") {
+				text = "\n" + text
+			}
+			content = content[:v.offset] + text + content[v.offset:]
+		}
+
+		formatted, err := format.Source([]byte(content))
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(filename, formatted, 0644)
+	}
+	return fmt.Errorf("cannot find %s in '%s'", SERVICE_PROVIDER_TYPE_NAME, internalDir)
+}
+
+func lookupServiceProviderStruct(f *ast.File) *ast.StructType {
+	for _, decl := range f.Decls {
+		genDecl, ok := decl.(*ast.GenDecl)
+		if !ok || genDecl.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range genDecl.Specs {
+			typeSpec := spec.(*ast.TypeSpec)
+			if typeSpec.Name.Name != SERVICE_PROVIDER_TYPE_NAME {
+				continue
+			}
+			if structType, ok := typeSpec.Type.(*ast.StructType); ok {
+				return structType
+			}
+		}
+	}
+	return nil
+}
+
+func lookupServiceProviderInit(f *ast.File) *ast.FuncDecl {
+	for _, decl := range f.Decls {
+		funcDecl, ok := decl.(*ast.FuncDecl)
+		if !ok || funcDecl.Recv == nil || funcDecl.Name.Name != SERVICE_PROVIDER_INIT_METHOD {
+			continue
+		}
+		star, ok := funcDecl.Recv.List[0].Type.(*ast.StarExpr)
+		if !ok {
+			continue
+		}
+		if ident, ok := star.X.(*ast.Ident); ok && ident.Name == SERVICE_PROVIDER_TYPE_NAME {
+			return funcDecl
+		}
+	}
+	return nil
+}
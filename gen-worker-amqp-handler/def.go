@@ -0,0 +1,77 @@
+package main
+
+const (
+	MESSAGE_SOURCE_TAG_NAME string = "queue"
+
+	REQUEST_FILE_TEMPLATE string = `package {{.HandlerModuleName}}
+
+import (
+	"log"
+	"reflect"
+
+	. "{{.AppModuleName}}/internal"
+
+	"github.com/Bofry/trace"
+	amqp "github.com/Bofry/worker-amqp"
+)
+
+var (
+	_ amqp.MessageHandler        = new({{.HandlerName}})
+	_ amqp.MessageObserverAffair = new({{.HandlerName}})
+)
+
+type {{.HandlerName}} struct {
+	ServiceProvider *ServiceProvider
+}
+
+func (h *{{.HandlerName}}) Init() {
+	h.ServiceProvider.ConfigureLogger(log.Default())
+}
+
+// ProcessMessage implements MessageHandler.
+func (h *{{.HandlerName}}) ProcessMessage(ctx *amqp.Context, message *amqp.Message) {
+	sp := trace.SpanFromContext(ctx)
+	_ = sp
+
+	// settle the message with one of the following:
+	//   message.Ack()       - the message has been processed.
+	//   message.Nack(true)  - reject the message and put it back to the queue.
+	//   message.Nack(false) - reject the message and discard it, or route it
+	//                         to the dead letter exchange of the queue.
+	message.Ack()
+}
+
+// MessageObserverTypes implements MessageObserverAffair.
+func (*{{.HandlerName}}) MessageObserverTypes() []reflect.Type {
+	return []reflect.Type{
+		// put your observer type here
+	}
+}
+`
+
+	REQUEST_TEST_FILE_TEMPLATE string = `package {{.HandlerModuleName}}
+
+import (
+	"testing"
+
+	. "{{.AppModuleName}}/internal"
+	workertesting "{{.AppModuleName}}/{{.TestingModuleName}}"
+)
+
+func Test{{.HandlerName}}_ProcessMessage(t *testing.T) {
+	h := &{{.HandlerName}}{
+		ServiceProvider: &ServiceProvider{},
+	}
+	h.Init()
+
+	ctx := workertesting.NewContext()
+	message, recorder := workertesting.NewMessage({{printf "%q" .MessageSource}}).
+		JSON(map[string]interface{}{"key": "value"}).
+		Build()
+
+	h.ProcessMessage(ctx, message)
+
+	recorder.AssertAcked(t)
+}
+`
+)
@@ -0,0 +1,7 @@
+package main
+
+import "github.com/Bofry/go-tools/internal/workergen"
+
+func main() {
+	workergen.RunHandler(REQUEST_FILE_TEMPLATE, REQUEST_TEST_FILE_TEMPLATE, MESSAGE_SOURCE_TAG_NAME)
+}
@@ -0,0 +1,47 @@
+package main
+
+const (
+	MESSAGE_OBSERVER_FILE_TEMPLATE string = `package {{.ObserverModuleName}}
+
+import (
+	"log"
+	"reflect"
+
+	. "{{.AppModuleName}}/internal"
+
+	amqp "github.com/Bofry/worker-amqp"
+	"github.com/Bofry/worker-amqp/tracing"
+)
+
+var _ amqp.MessageObserver = new({{.ObserverName}})
+
+type {{.ObserverName}} struct {
+	ServiceProvider *ServiceProvider
+}
+
+func (obs *{{.ObserverName}}) Init() {
+	obs.ServiceProvider.ConfigureLogger(log.Default())
+}
+
+// OnAck implements amqp.MessageObserver.
+func (obs *{{.ObserverName}}) OnAck(ctx *amqp.Context, message *amqp.Message) {
+	tr := tracing.GetTracer(obs)
+	sp := tr.Start(ctx, "OnAck()")
+	defer sp.End()
+
+}
+
+// OnNack implements amqp.MessageObserver.
+func (obs *{{.ObserverName}}) OnNack(ctx *amqp.Context, message *amqp.Message, requeue bool) {
+	tr := tracing.GetTracer(obs)
+	sp := tr.Start(ctx, "OnNack()")
+	defer sp.End()
+
+}
+
+// Type implements amqp.MessageObserver.
+func (obs *{{.ObserverName}}) Type() reflect.Type {
+	return reflect.TypeOf(obs)
+}
+`
+)
@@ -0,0 +1,72 @@
+package main
+
+const (
+	MESSAGE_SOURCE_TAG_NAME string = "topic"
+
+	REQUEST_FILE_TEMPLATE string = `package {{.HandlerModuleName}}
+
+import (
+	"log"
+	"reflect"
+
+	. "{{.AppModuleName}}/internal"
+
+	"github.com/Bofry/trace"
+	kafka "github.com/Bofry/worker-kafka"
+)
+
+var (
+	_ kafka.MessageHandler        = new({{.HandlerName}})
+	_ kafka.MessageObserverAffair = new({{.HandlerName}})
+)
+
+type {{.HandlerName}} struct {
+	ServiceProvider *ServiceProvider
+}
+
+func (h *{{.HandlerName}}) Init() {
+	h.ServiceProvider.ConfigureLogger(log.Default())
+}
+
+// ProcessMessage implements MessageHandler.
+func (h *{{.HandlerName}}) ProcessMessage(ctx *kafka.Context, message *kafka.Message) {
+	sp := trace.SpanFromContext(ctx)
+	_ = sp
+
+	message.Commit()
+}
+
+// MessageObserverTypes implements MessageObserverAffair.
+func (*{{.HandlerName}}) MessageObserverTypes() []reflect.Type {
+	return []reflect.Type{
+		// put your observer type here
+	}
+}
+`
+
+	REQUEST_TEST_FILE_TEMPLATE string = `package {{.HandlerModuleName}}
+
+import (
+	"testing"
+
+	. "{{.AppModuleName}}/internal"
+	workertesting "{{.AppModuleName}}/{{.TestingModuleName}}"
+)
+
+func Test{{.HandlerName}}_ProcessMessage(t *testing.T) {
+	h := &{{.HandlerName}}{
+		ServiceProvider: &ServiceProvider{},
+	}
+	h.Init()
+
+	ctx := workertesting.NewContext()
+	message, recorder := workertesting.NewMessage({{printf "%q" .MessageSource}}).
+		JSON(map[string]interface{}{"key": "value"}).
+		Build()
+
+	h.ProcessMessage(ctx, message)
+
+	recorder.AssertCommitted(t)
+}
+`
+)
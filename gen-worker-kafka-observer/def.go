@@ -0,0 +1,39 @@
+package main
+
+const (
+	MESSAGE_OBSERVER_FILE_TEMPLATE string = `package {{.ObserverModuleName}}
+
+import (
+	"log"
+	"reflect"
+
+	. "{{.AppModuleName}}/internal"
+
+	kafka "github.com/Bofry/worker-kafka"
+	"github.com/Bofry/worker-kafka/tracing"
+)
+
+var _ kafka.MessageObserver = new({{.ObserverName}})
+
+type {{.ObserverName}} struct {
+	ServiceProvider *ServiceProvider
+}
+
+func (obs *{{.ObserverName}}) Init() {
+	obs.ServiceProvider.ConfigureLogger(log.Default())
+}
+
+// OnCommit implements kafka.MessageObserver.
+func (obs *{{.ObserverName}}) OnCommit(ctx *kafka.Context, message *kafka.Message) {
+	tr := tracing.GetTracer(obs)
+	sp := tr.Start(ctx, "OnCommit()")
+	defer sp.End()
+
+}
+
+// Type implements kafka.MessageObserver.
+func (obs *{{.ObserverName}}) Type() reflect.Type {
+	return reflect.TypeOf(obs)
+}
+`
+)
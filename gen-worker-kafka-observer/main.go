@@ -0,0 +1,7 @@
+package main
+
+import "github.com/Bofry/go-tools/internal/workergen"
+
+func main() {
+	workergen.RunObserver(MESSAGE_OBSERVER_FILE_TEMPLATE)
+}
@@ -1,6 +1,8 @@
 package main
 
 const (
+	MESSAGE_SOURCE_TAG_NAME string = "topic"
+
 	REQUEST_FILE_TEMPLATE string = `package {{.HandlerModuleName}}
 
 import (
@@ -41,10 +43,33 @@ func (*{{.HandlerName}}) MessageObserverTypes() []reflect.Type {
 	}
 }
 `
+
+	REQUEST_TEST_FILE_TEMPLATE string = `package {{.HandlerModuleName}}
+
+import (
+	"testing"
+
+	. "{{.AppModuleName}}/internal"
+	workertesting "{{.AppModuleName}}/{{.TestingModuleName}}"
 )
 
-type FileMetadata struct {
-	AppModuleName     string
-	HandlerModuleName string
-	HandlerName       string
+func Test{{.HandlerName}}_ProcessMessage(t *testing.T) {
+	h := &{{.HandlerName}}{
+		ServiceProvider: &ServiceProvider{},
+	}
+	h.Init()
+
+	ctx := workertesting.NewContext()
+	message, recorder := workertesting.NewMessage({{printf "%q" .MessageSource}}).
+		JSON(map[string]interface{}{"key": "value"}).
+		Build()
+
+	err := h.ProcessMessage(ctx, message)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	recorder.AssertNotRequeued(t)
 }
+`
+)
@@ -53,9 +53,3 @@ func (obs *{{.ObserverName}}) Type() reflect.Type {
 }
 `
 )
-
-type FileMetadata struct {
-	AppModuleName      string
-	ObserverModuleName string
-	ObserverName       string
-}
@@ -1,6 +1,8 @@
 package main
 
 const (
+	MESSAGE_SOURCE_TAG_NAME string = "stream"
+
 	REQUEST_FILE_TEMPLATE string = `package {{.HandlerModuleName}}
 
 import (
@@ -42,10 +44,31 @@ func (*{{.HandlerName}}) MessageObserverTypes() []reflect.Type {
 	}
 }
 `
+
+	REQUEST_TEST_FILE_TEMPLATE string = `package {{.HandlerModuleName}}
+
+import (
+	"testing"
+
+	. "{{.AppModuleName}}/internal"
+	workertesting "{{.AppModuleName}}/{{.TestingModuleName}}"
 )
 
-type FileMetadata struct {
-	AppModuleName     string
-	HandlerModuleName string
-	HandlerName       string
+func Test{{.HandlerName}}_ProcessMessage(t *testing.T) {
+	h := &{{.HandlerName}}{
+		ServiceProvider: &ServiceProvider{},
+	}
+	h.Init()
+
+	ctx := workertesting.NewContext()
+	message, recorder := workertesting.NewMessage({{printf "%q" .MessageSource}}).
+		Value("key", "value").
+		Build()
+
+	h.ProcessMessage(ctx, message)
+
+	recorder.AssertAcked(t)
+	recorder.AssertDeleted(t)
 }
+`
+)
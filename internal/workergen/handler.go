@@ -0,0 +1,258 @@
+package workergen
+
+import (
+	"fmt"
+	"go/ast"
+	"go/printer"
+	"go/token"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"text/template"
+
+	"golang.org/x/tools/go/ast/astutil"
+)
+
+const (
+	MESSAGE_MANAGER_TYPE_NAME string = "MessageManager"
+	MESSAGE_TYPE_SUFFIX       string = "Handler"
+	HANDLER_MODULE_NAME       string = "handler"
+	TESTING_MODULE_NAME       string = "testing"
+	MESSAGE_INVALID_SOURCE    string = "?"
+)
+
+// HandlerFileMetadata is the data of the handler file and handler test
+// file templates.
+type HandlerFileMetadata struct {
+	AppModuleName     string
+	HandlerModuleName string
+	HandlerName       string
+	TestingModuleName string
+	MessageSource     string
+}
+
+// RunHandler generates the handler files of the MessageManager fields in
+// the handler package by fileTemplate, and imports the handler package into
+// the go file. If the testing package exists, the example test of each
+// handler is generated by testFileTemplate as well, the message source is
+// resolved from the sourceTagName tag of the field, e.g: topic.
+func RunHandler(fileTemplate, testFileTemplate, sourceTagName string) {
+	tmpl, err := template.New("").Parse(fileTemplate)
+	if err != nil {
+		throw(err.Error())
+		exit(1)
+		return
+	}
+	testTmpl, err := template.New("").Parse(testFileTemplate)
+	if err != nil {
+		throw(err.Error())
+		exit(1)
+		return
+	}
+
+	fset, f := parseFile()
+
+	// resolve AST
+	for _, node := range f.Decls {
+		switch realDecl := node.(type) {
+		case *ast.GenDecl:
+			for _, spec := range realDecl.Specs {
+				switch spec.(type) {
+				case *ast.TypeSpec:
+					var (
+						typeSpec       = spec.(*ast.TypeSpec)
+						structTypeName = typeSpec.Name.Name
+					)
+
+					// find MessageManager type
+					if structTypeName == MESSAGE_MANAGER_TYPE_NAME {
+						var (
+							count int
+							err   error
+						)
+
+						switch typeSpec.Type.(type) {
+						case *ast.StructType:
+							structType := typeSpec.Type.(*ast.StructType)
+							count, err = generateHandlerFiles(tmpl, testTmpl, sourceTagName, structType, HANDLER_MODULE_NAME)
+							if err != nil {
+								throw(err.Error())
+								exit(1)
+							}
+						}
+
+						if count > 0 {
+							// import handler module path
+							err := importHandlerModulePath(fset, f)
+							if err != nil {
+								throw(err.Error())
+								exit(1)
+							}
+						}
+						break
+					}
+				}
+			}
+		}
+	}
+
+	if err := execCmd("go", "mod", "tidy"); err != nil {
+		throw(err.Error())
+		exit(1)
+	}
+
+	if err := execCmd("gofmt", "-w", gofile); err != nil {
+		throw(err.Error())
+		exit(1)
+	}
+	exit(0)
+}
+
+func generateHandlerFiles(tmpl, testTmpl *template.Template, sourceTagName string, structType *ast.StructType, handlerDir string) (n int, err error) {
+	var (
+		fileTypeNameMap = make(map[string]string, len(structType.Fields.List))
+		typeSourceMap   = make(map[string]string, len(structType.Fields.List))
+	)
+
+	for _, field := range structType.Fields.List {
+		switch field.Type.(type) {
+		case *ast.StarExpr:
+			star := field.Type.(*ast.StarExpr)
+			ident, ok := star.X.(*ast.Ident)
+			if ok {
+				typename := ident.Name
+
+				filename := resolveFileName(typename, MESSAGE_TYPE_SUFFIX)
+				if len(filename) > 0 {
+					if existedTypeName, ok := fileTypeNameMap[filename]; ok {
+						// NOTE: it have not to be happen.
+						throw(fmt.Sprintf("output file '%s' is ambiguous on handler type name '%s' and '%s'",
+							filename,
+							existedTypeName,
+							typename))
+						exit(1)
+					}
+					fileTypeNameMap[filename] = typename
+					typeSourceMap[typename] = resolveMessageSource(field, sourceTagName)
+				}
+			}
+		}
+	}
+
+	var count int = 0
+	if len(fileTypeNameMap) > 0 {
+		if _, err := os.Stat(handlerDir); os.IsNotExist(err) {
+			os.Mkdir(handlerDir, os.ModePerm)
+		}
+
+		for filename, typename := range fileTypeNameMap {
+			fmt.Printf("generating '%s' ...", filename)
+
+			file, err := createFile(filename, handlerDir)
+			if err != nil {
+				if os.IsExist(err) {
+					fmt.Println("skipped")
+					continue
+				} else {
+					return count, err
+				}
+			}
+			defer file.Close()
+
+			metadata := HandlerFileMetadata{
+				AppModuleName:     appModuleName,
+				HandlerModuleName: HANDLER_MODULE_NAME,
+				HandlerName:       typename,
+			}
+
+			err = tmpl.Execute(file, metadata)
+			if err != nil {
+				fmt.Println("failed")
+			} else {
+				fmt.Println("ok")
+				count++
+			}
+		}
+
+		// generate the example tests if the testing package exists
+		testingDir := filepath.Join(filepath.Dir(handlerDir), TESTING_MODULE_NAME)
+		if _, err := os.Stat(testingDir); err == nil && testTmpl != nil {
+			for filename, typename := range fileTypeNameMap {
+				err = generateHandlerTestFile(testTmpl, filename, typename, typeSourceMap[typename], handlerDir)
+				if err != nil {
+					return count, err
+				}
+			}
+		}
+	}
+	return count, nil
+}
+
+func generateHandlerTestFile(tmpl *template.Template, filename, typename, source string, handlerDir string) error {
+	testFilename := filename + "_test"
+
+	fmt.Printf("generating '%s' ...", testFilename)
+
+	file, err := createFile(testFilename, handlerDir)
+	if err != nil {
+		if os.IsExist(err) {
+			fmt.Println("skipped")
+			return nil
+		}
+		return err
+	}
+	defer file.Close()
+
+	metadata := HandlerFileMetadata{
+		AppModuleName:     appModuleName,
+		HandlerModuleName: HANDLER_MODULE_NAME,
+		HandlerName:       typename,
+		TestingModuleName: TESTING_MODULE_NAME,
+		MessageSource:     source,
+	}
+
+	err = tmpl.Execute(file, metadata)
+	if err != nil {
+		fmt.Println("failed")
+		return err
+	}
+	fmt.Println("ok")
+	return nil
+}
+
+// Resolve the message source, e.g: stream or topic, from the field tag.
+func resolveMessageSource(field *ast.Field, tagName string) string {
+	if field.Tag == nil {
+		return ""
+	}
+	tagLiteral, err := strconv.Unquote(field.Tag.Value)
+	if err != nil {
+		return ""
+	}
+	source := reflect.StructTag(tagLiteral).Get(tagName)
+	if source == MESSAGE_INVALID_SOURCE {
+		return ""
+	}
+	return source
+}
+
+func importHandlerModulePath(fset *token.FileSet, f *ast.File) error {
+
+	handlerModulePath := appModuleName + "/" + HANDLER_MODULE_NAME
+
+	ok := astutil.AddNamedImport(fset, f, ".", handlerModulePath)
+	if ok {
+		stream, err := os.OpenFile(gofile, os.O_WRONLY|os.O_TRUNC, os.ModePerm)
+		if err != nil {
+			return err
+		}
+		defer stream.Close()
+
+		err = printer.Fprint(stream, fset, f)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+package workergen
+
+import (
+	"fmt"
+	"go/ast"
+	"os"
+	"text/template"
+)
+
+const (
+	MESSAGE_OBSERVER_MANAGER_TYPE_NAME string = "MessageObserverManager"
+	MESSAGE_OBSERVER_TYPE_SUFFIX       string = "MessageObserver"
+)
+
+// ObserverFileMetadata is the data of the MessageObserver file template.
+type ObserverFileMetadata struct {
+	AppModuleName      string
+	ObserverModuleName string
+	ObserverName       string
+}
+
+// RunObserver generates the MessageObserver files of the
+// MessageObserverManager variable fields in the package of the go file by
+// fileTemplate.
+func RunObserver(fileTemplate string) {
+	tmpl, err := template.New("").Parse(fileTemplate)
+	if err != nil {
+		throw(err.Error())
+		exit(1)
+		return
+	}
+
+	_, f := parseFile()
+
+	moduleName := f.Name.Name
+
+	// resolve AST
+	for _, node := range f.Decls {
+		switch realDecl := node.(type) {
+		case *ast.GenDecl:
+			for _, spec := range realDecl.Specs {
+				switch realSpec := spec.(type) {
+				case *ast.ValueSpec:
+					structExpr := lookupMessageObserverManager(realSpec)
+					if structExpr != nil {
+						_, err := generateMessageObserverFiles(tmpl, structExpr, moduleName)
+						if err != nil {
+							throw(err.Error())
+							exit(1)
+						}
+					}
+				}
+			}
+		}
+	}
+
+	if err := execCmd("gofmt", "-w", gofile); err != nil {
+		throw(err.Error())
+		exit(1)
+	}
+	exit(0)
+}
+
+func lookupMessageObserverManager(spec *ast.ValueSpec) *ast.StructType {
+	// find variant name from var list
+	var index int = -1
+	for i, ident := range spec.Names {
+		if ident.Name == MESSAGE_OBSERVER_MANAGER_TYPE_NAME {
+			index = i
+			break
+		}
+	}
+
+	// not found
+	if index < 0 || index >= len(spec.Values) {
+		return nil
+	}
+
+	// get value
+	expr := spec.Values[index]
+	definition, ok := expr.(*ast.CompositeLit)
+	if !ok {
+		return nil
+	}
+
+	structExpr, ok := definition.Type.(*ast.StructType)
+	if !ok {
+		return nil
+	}
+	return structExpr
+}
+
+func generateMessageObserverFiles(tmpl *template.Template, structType *ast.StructType, moduleName string) (n int, err error) {
+	var (
+		fileTypeNameMap = make(map[string]string, len(structType.Fields.List))
+	)
+
+	for _, field := range structType.Fields.List {
+		switch field.Type.(type) {
+		case *ast.StarExpr:
+			star := field.Type.(*ast.StarExpr)
+			ident, ok := star.X.(*ast.Ident)
+			if ok {
+				typename := ident.Name
+
+				filename := resolveFileName(typename, MESSAGE_OBSERVER_TYPE_SUFFIX)
+				if len(filename) > 0 {
+					if existedTypeName, ok := fileTypeNameMap[filename]; ok {
+						// NOTE: it have not to be happen.
+						throw(fmt.Sprintf("output file '%s' is ambiguous on MessageObserver type name '%s' and '%s'",
+							filename,
+							existedTypeName,
+							typename))
+						exit(1)
+					}
+					fileTypeNameMap[filename] = typename
+				}
+			}
+		}
+	}
+
+	var count int = 0
+	for filename, typename := range fileTypeNameMap {
+		fmt.Printf("generating '%s' ...", filename)
+
+		file, err := createFile(filename, "")
+		if err != nil {
+			if os.IsExist(err) {
+				fmt.Println("skipped")
+				continue
+			} else {
+				return count, err
+			}
+		}
+		defer file.Close()
+
+		metadata := ObserverFileMetadata{
+			AppModuleName:      appModuleName,
+			ObserverModuleName: moduleName,
+			ObserverName:       typename,
+		}
+
+		err = tmpl.Execute(file, metadata)
+		if err != nil {
+			fmt.Println("failed")
+		} else {
+			fmt.Println("ok")
+			count++
+		}
+	}
+	return count, nil
+}
@@ -0,0 +1,185 @@
+// Package workergen is the driver shared by the gen-worker-xxx-handler and
+// gen-worker-xxx-observer commands. It parses the go file, resolves the
+// MessageManager or MessageObserverManager declaration and writes the files
+// of the broker specific templates given by the commands.
+package workergen
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"golang.org/x/mod/modfile"
+)
+
+const (
+	GO_MOD_FILE           string = "go.mod"
+	MAX_GO_MOD_FILE_DEPTH int    = 3
+)
+
+var (
+	osExit        func(int) = os.Exit
+	gofile        string
+	workdir       string
+	appModuleName string
+)
+
+// parseFile parses the flags and the go file specified by the -file flag or
+// $GOFILE, and resolves the app module name.
+func parseFile() (*token.FileSet, *ast.File) {
+	var (
+		err error
+	)
+	flag.StringVar(&gofile, "file", "", "input file")
+	flag.Parse()
+
+	if dir, file := path.Split(gofile); dir != "." {
+		workdir, err = os.Getwd()
+		if err != nil {
+			throw("Cannot get work directory.")
+			exit(1)
+		}
+		os.Chdir(dir)
+		gofile = file
+	}
+
+	if gofile == "" {
+		gofile = os.Getenv("GOFILE")
+		if gofile == "" {
+			throw("No file to parse.")
+			exit(1)
+		}
+	}
+
+	// get module name
+	appModuleName, err = getAppModuleName()
+	if err != nil {
+		throw(err.Error())
+		exit(1)
+	}
+
+	// parse app.go to AST
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, gofile, nil, parser.ParseComments)
+	if err != nil {
+		throw(err.Error())
+		exit(1)
+	}
+	return fset, f
+}
+
+func throw(err string) {
+	fmt.Fprintln(os.Stderr, err)
+}
+
+func exit(code int) {
+	if len(workdir) > 0 {
+		os.Chdir(workdir)
+	}
+	osExit(code)
+}
+
+func getAppModuleName() (string, error) {
+	var (
+		path         = filepath.Join(GO_MOD_FILE)
+		attempts int = 0
+	)
+
+again:
+	goModBytes, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			if attempts < MAX_GO_MOD_FILE_DEPTH {
+				path = filepath.Join("../", path)
+				attempts++
+				goto again
+			}
+		}
+		return "", err
+	}
+
+	modName := modfile.ModulePath(goModBytes)
+
+	return modName, nil
+}
+
+func execCmd(name string, args ...string) error {
+	cmd := exec.Command(name, args...)
+	cmd.Stdin = os.Stdin
+
+	var (
+		stdout io.ReadCloser
+		stderr io.ReadCloser
+
+		err error
+	)
+
+	if stdout, err = cmd.StdoutPipe(); err != nil {
+		return err
+	}
+	if stderr, err = cmd.StderrPipe(); err != nil {
+		return err
+	}
+	reader := io.MultiReader(stdout, stderr)
+	scanner := bufio.NewScanner(reader)
+	go func() {
+		for scanner.Scan() {
+			fmt.Println(scanner.Text())
+		}
+	}()
+
+	if err = cmd.Start(); err != nil {
+		return err
+	}
+	return cmd.Wait()
+}
+
+// Resolve the type name with suffix to file name.
+// e.g: EchoHandler to echoHandler, XMLHandler to xmlHandler.
+func resolveFileName(typename, suffix string) string {
+	if strings.HasSuffix(typename, suffix) {
+		var (
+			runes  = []rune(typename)
+			length = len(runes)
+		)
+
+		if ch := runes[0]; unicode.IsUpper(rune(ch)) && unicode.IsLetter(ch) {
+			var pos int = 0
+			for i := 0; i < length-1; i++ {
+				if unicode.IsUpper(runes[i]) && unicode.IsLower(runes[i+1]) {
+					pos = i
+					break
+				}
+			}
+			if pos == 0 {
+				pos++
+			}
+			return strings.ToLower(string(runes[:pos])) + string(runes[pos:])
+		}
+	}
+	return ""
+}
+
+func createFile(filename string, dir string) (*os.File, error) {
+	path := filepath.Join(dir, filename+".go")
+
+	_, err := os.Stat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return os.Create(path)
+		}
+		return nil, err
+	}
+	return nil, os.ErrExist
+}
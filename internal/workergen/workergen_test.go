@@ -0,0 +1,115 @@
+package workergen
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"testing"
+	"text/template"
+)
+
+func TestResolveFileName(t *testing.T) {
+	for _, c := range []struct {
+		typename string
+		suffix   string
+		expected string
+	}{
+		{"EchoHandler", MESSAGE_TYPE_SUFFIX, "echoHandler"},
+		{"XMLHandler", MESSAGE_TYPE_SUFFIX, "xmlHandler"},
+		{"EchoMessageObserver", MESSAGE_OBSERVER_TYPE_SUFFIX, "echoMessageObserver"},
+		{"echoHandler", MESSAGE_TYPE_SUFFIX, ""},
+		{"EchoService", MESSAGE_TYPE_SUFFIX, ""},
+	} {
+		if filename := resolveFileName(c.typename, c.suffix); filename != c.expected {
+			t.Errorf("%s expect %q, got %q", c.typename, c.expected, filename)
+		}
+	}
+}
+
+func TestGenerateHandlerFiles(t *testing.T) {
+	handlerDir := filepath.Join(t.TempDir(), HANDLER_MODULE_NAME)
+
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "app.go", "package main\n\ntype MessageManager struct {\n\t*EchoHandler `topic:\"echo\"`\n\t*Config\n}\n", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	structType := f.Decls[0].(*ast.GenDecl).Specs[0].(*ast.TypeSpec).Type.(*ast.StructType)
+	tmpl := template.Must(template.New("").Parse("package {{.HandlerModuleName}}\n\ntype {{.HandlerName}} struct{}\n"))
+
+	n, err := generateHandlerFiles(tmpl, nil, "", structType, handlerDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Errorf("generated handlers expect %d, got %d", 1, n)
+	}
+	content, err := os.ReadFile(filepath.Join(handlerDir, "echoHandler.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "package handler\n\ntype EchoHandler struct{}\n"; string(content) != expected {
+		t.Errorf("echoHandler.go expect:\n%s\ngot:\n%s\n", expected, string(content))
+	}
+
+	// the existing files are skipped
+	n, err = generateHandlerFiles(tmpl, nil, "", structType, handlerDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 0 {
+		t.Errorf("generated handlers expect %d, got %d", 0, n)
+	}
+}
+
+func TestGenerateHandlerFiles_Testing(t *testing.T) {
+	dir := t.TempDir()
+	handlerDir := filepath.Join(dir, HANDLER_MODULE_NAME)
+	if err := os.Mkdir(filepath.Join(dir, TESTING_MODULE_NAME), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "app.go", "package main\n\ntype MessageManager struct {\n\t*EchoHandler `queue:\"echo\"`\n\t*InvalidMessageHandler `queue:\"?\"`\n}\n", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	structType := f.Decls[0].(*ast.GenDecl).Specs[0].(*ast.TypeSpec).Type.(*ast.StructType)
+	tmpl := template.Must(template.New("").Parse("package {{.HandlerModuleName}}\n"))
+	testTmpl := template.Must(template.New("").Parse("{{.HandlerName}} {{.TestingModuleName}} {{printf \"%q\" .MessageSource}}\n"))
+
+	_, err = generateHandlerFiles(tmpl, testTmpl, "queue", structType, handlerDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for filename, expected := range map[string]string{
+		"echoHandler_test.go":           "EchoHandler testing \"echo\"\n",
+		"invalidMessageHandler_test.go": "InvalidMessageHandler testing \"\"\n",
+	} {
+		content, err := os.ReadFile(filepath.Join(handlerDir, filename))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(content) != expected {
+			t.Errorf("%s expect %q, got %q", filename, expected, string(content))
+		}
+	}
+}
+
+func TestLookupMessageObserverManager(t *testing.T) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "observer.go", "package handler\n\nvar Others, MessageObserverManager = 1, struct {\n\t*EchoMessageObserver\n}{}\n\nvar MessageObserverManager2 struct{}\n", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	structType := lookupMessageObserverManager(f.Decls[0].(*ast.GenDecl).Specs[0].(*ast.ValueSpec))
+	if structType == nil || len(structType.Fields.List) != 1 {
+		t.Fatalf("MessageObserverManager should be resolved, got %v", structType)
+	}
+	if structType := lookupMessageObserverManager(f.Decls[1].(*ast.GenDecl).Specs[0].(*ast.ValueSpec)); structType != nil {
+		t.Errorf("MessageObserverManager2 should not be resolved")
+	}
+}
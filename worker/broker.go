@@ -0,0 +1,174 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	TEMPLATE_NAME_BROKER_ENV               string = "BrokerEnv"
+	TEMPLATE_NAME_BROKER_ENV_SAMPLE        string = "BrokerEnvSample"
+	TEMPLATE_NAME_BROKER_CONFIG_YAML       string = "BrokerConfigYaml"
+	TEMPLATE_NAME_BROKER_CONFIG_LOCAL_YAML string = "BrokerConfigLocalYaml"
+	TEMPLATE_NAME_BROKER_CONFIG_FIELDS     string = "BrokerConfigFields"
+	TEMPLATE_NAME_BROKER_HOST_INIT         string = "BrokerHostInit"
+	TEMPLATE_NAME_BROKER_MESSAGE_MANAGER   string = "BrokerMessageManager"
+	TEMPLATE_NAME_BROKER_HEALTH            string = "BrokerHealth"
+
+	TEMPLATE_NAME_BROKER_DEAD_LETTER_CONFIG_YAML   string = "BrokerDeadLetterConfigYaml"
+	TEMPLATE_NAME_BROKER_DEAD_LETTER_CONFIG_FIELDS string = "BrokerDeadLetterConfigFields"
+	TEMPLATE_NAME_BROKER_DEAD_LETTER_PRODUCER      string = "BrokerDeadLetterProducer"
+
+	TEMPLATE_NAME_BROKER_TESTING string = "BrokerTesting"
+
+	TEMPLATE_NAME_BROKER_PRODUCER_CONFIG_FIELDS string = "BrokerProducerConfigFields"
+	TEMPLATE_NAME_BROKER_PRODUCER               string = "BrokerProducer"
+)
+
+var (
+	__BROKERS = map[string]Broker{}
+)
+
+type (
+	// Broker contributes the broker specific parts of a worker project.
+	// The fragments returned by the template methods are parsed as
+	// associated templates and executed with *AppMetadata.
+	Broker interface {
+		// Name returns the name used by the --broker option, e.g: nsq.
+		Name() string
+		// PackagePath returns the worker package path, e.g: github.com/Bofry/worker-nsq.
+		PackagePath() string
+		// PackageAlias returns the import alias of the worker package.
+		PackageAlias() string
+		// Imports returns the extra standard packages internal/def.go needs.
+		Imports() []string
+		// MessageTagName returns the MessageManager field tag declaring the
+		// message source, e.g: topic.
+		MessageTagName() string
+
+		// EnvTemplate returns the .env variables of the broker.
+		EnvTemplate() string
+		// EnvSampleTemplate returns the .env.sample variables of the broker.
+		EnvSampleTemplate() string
+		// ConfigYamlTemplate returns the config.yaml settings of the broker.
+		ConfigYamlTemplate() string
+		// ConfigLocalYamlTemplate returns the config.local.yaml settings of the broker.
+		ConfigLocalYamlTemplate() string
+		// ConfigFieldsTemplate returns the Config struct fields of the broker.
+		ConfigFieldsTemplate() string
+		// HostInitTemplate returns the body of Host.Init().
+		HostInitTemplate() string
+		// MessageManagerTemplate returns the MessageManager tag example.
+		MessageManagerTemplate() string
+		// HealthTemplate returns internal/healthBroker.go for the --health
+		// option, which should declare checkConsumer() reporting whether the
+		// consumer has joined the broker for /readyz.
+		HealthTemplate() string
+	}
+
+	// DeadLetterBroker is implemented by the brokers supporting the
+	// --dead-letter option.
+	DeadLetterBroker interface {
+		Broker
+
+		// DeadLetterConfigYamlTemplate returns the config.yaml settings of the dead-letter queue.
+		DeadLetterConfigYamlTemplate() string
+		// DeadLetterConfigFieldsTemplate returns the Config struct fields of the dead-letter queue.
+		DeadLetterConfigFieldsTemplate() string
+		// DeadLetterProducerTemplate returns internal/deadLetterProducer.go, which
+		// should declare deadLetterProducer, newDeadLetterProducer(), newDeadLetter(),
+		// messageID(), retryMessage() and discardMessage(). newDeadLetterProducer()
+		// should share ServiceProvider.Producer with the --producer option.
+		DeadLetterProducerTemplate() string
+	}
+
+	// TestingBroker is implemented by the brokers providing the testing
+	// package for the handler unit tests.
+	TestingBroker interface {
+		Broker
+
+		// TestingTemplate returns testing/testing.go, which should declare
+		// NewContext(), NewMessage() and the message recorder assertions.
+		TestingTemplate() string
+	}
+
+	// ProducerBroker is implemented by the brokers supporting the
+	// --producer option.
+	ProducerBroker interface {
+		Broker
+
+		// ProducerConfigFieldsTemplate returns the Config struct fields of the producer.
+		ProducerConfigFieldsTemplate() string
+		// ProducerTemplate returns internal/producer.go, which should declare
+		// Producer and NewProducer().
+		ProducerTemplate() string
+	}
+)
+
+func registerBroker(broker Broker) {
+	name := broker.Name()
+	if _, ok := __BROKERS[name]; ok {
+		panic(fmt.Sprintf("broker '%s' has been registered", name))
+	}
+	__BROKERS[name] = broker
+}
+
+func lookupBroker(name string) (Broker, error) {
+	broker, ok := __BROKERS[strings.ToLower(name)]
+	if !ok {
+		return nil, fmt.Errorf("unknown broker '%s', should be one of %s", name, strings.Join(getBrokerNames(), "|"))
+	}
+	return broker, nil
+}
+
+func getBrokerNames() []string {
+	names := make([]string, 0, len(__BROKERS))
+	for name := range __BROKERS {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func supportDeadLetter(broker Broker) bool {
+	_, ok := broker.(DeadLetterBroker)
+	return ok
+}
+
+func supportTesting(broker Broker) bool {
+	_, ok := broker.(TestingBroker)
+	return ok
+}
+
+func supportProducer(broker Broker) bool {
+	_, ok := broker.(ProducerBroker)
+	return ok
+}
+
+func brokerTemplates(broker Broker) map[string]string {
+	templates := map[string]string{
+		TEMPLATE_NAME_BROKER_ENV:               broker.EnvTemplate(),
+		TEMPLATE_NAME_BROKER_ENV_SAMPLE:        broker.EnvSampleTemplate(),
+		TEMPLATE_NAME_BROKER_CONFIG_YAML:       broker.ConfigYamlTemplate(),
+		TEMPLATE_NAME_BROKER_CONFIG_LOCAL_YAML: broker.ConfigLocalYamlTemplate(),
+		TEMPLATE_NAME_BROKER_CONFIG_FIELDS:     broker.ConfigFieldsTemplate(),
+		TEMPLATE_NAME_BROKER_HOST_INIT:         broker.HostInitTemplate(),
+		TEMPLATE_NAME_BROKER_MESSAGE_MANAGER:   broker.MessageManagerTemplate(),
+		TEMPLATE_NAME_BROKER_HEALTH:            broker.HealthTemplate(),
+	}
+
+	if broker, ok := broker.(DeadLetterBroker); ok {
+		templates[TEMPLATE_NAME_BROKER_DEAD_LETTER_CONFIG_YAML] = broker.DeadLetterConfigYamlTemplate()
+		templates[TEMPLATE_NAME_BROKER_DEAD_LETTER_CONFIG_FIELDS] = broker.DeadLetterConfigFieldsTemplate()
+		templates[TEMPLATE_NAME_BROKER_DEAD_LETTER_PRODUCER] = broker.DeadLetterProducerTemplate()
+	}
+	if broker, ok := broker.(ProducerBroker); ok {
+		templates[TEMPLATE_NAME_BROKER_PRODUCER_CONFIG_FIELDS] = broker.ProducerConfigFieldsTemplate()
+		templates[TEMPLATE_NAME_BROKER_PRODUCER] = broker.ProducerTemplate()
+	}
+	if broker, ok := broker.(TestingBroker); ok {
+		templates[TEMPLATE_NAME_BROKER_TESTING] = broker.TestingTemplate()
+	}
+	return templates
+}
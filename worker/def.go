@@ -0,0 +1,879 @@
+package main
+
+import (
+	"path"
+	"strings"
+)
+
+var (
+	DIR_CONF = ".conf"
+
+	FILE_ENV          = ".env"
+	FILE_ENV_TEMPLATE = `Environment=local
+{{template "BrokerEnv" .}}JAEGER_TRACE_URL=
+`
+
+	FILE_ENV_SAMPLE          = ".env.sample"
+	FILE_ENV_SAMPLE_TEMPLATE = `Environment=local
+{{template "BrokerEnvSample" .}}JAEGER_TRACE_URL=http://localhost:14268/api/traces
+`
+
+	FILE_GITIGNORE          = ".gitignore"
+	FILE_GITIGNORE_TEMPLATE = `.vscode
+.env
+
+.VERSION
+
+# local environment shell script
+env.bat
+env.sh
+env.*.bat
+env.*.sh
+`
+
+	FILE_SERVICE_NAME          = ".SERVICE_NAME"
+	FILE_SERVICE_NAME_TEMPLATE = `{{.AppModuleName}}
+`
+
+	FILE_LOAD_ENV_SH          = "loadenv.sh"
+	FILE_LOAD_ENV_SH_TEMPLATE = `#!/usr/bin/env bash
+
+ENV_FILE=.env
+
+if [ ! -f "$ENV_FILE" ]; then
+	echo "can not find $ENV_FILE file"
+	exit 1
+fi
+# load .env file
+while IFS='' read -r setting || [[ -n "$setting" ]]; do
+	if [ "${setting:0:1}" != "#" ]; then
+		export ${setting}
+	fi
+done < $ENV_FILE
+`
+
+	FILE_LOAD_ENV_BAT          = "loadenv.bat"
+	FILE_LOAD_ENV_BAT_TEMPLATE = `@ECHO OFF
+SETLOCAL EnableDelayedExpansion
+
+SET ENV_FILE=.env
+
+IF NOT EXIST %ENV_FILE% (
+	ECHO "can not find %ENV_FILE% file"
+	EXIT /B 1
+)
+REM load .env file
+FOR /F "tokens=*" %%i in ('type %ENV_FILE%') DO (
+	SET LINE=%%i
+	IF [!LINE!] NEQ [] (
+		IF "!LINE:~0,1!" NEQ "#" (
+			SET %%i
+		)
+	)
+)
+
+SETLOCAL DisableDelayedExpansion
+`
+
+	FILE_DOCKERFILE          = "Dockerfile"
+	FILE_DOCKERFILE_TEMPLATE = `
+FROM golang:{{.RuntimeVersion}}-alpine
+
+ENV GOPATH /go
+ENV PATH $GOPATH/bin:/usr/local/go/bin:$PATH
+
+# Fix CVEs vulnerabilities
+RUN sed -i 's/v3.15/v3.16/g' /etc/apk/repositories \
+&& apk update \
+&& apk upgrade
+
+RUN mkdir -p "$GOPATH/src" "$GOPATH/bin" && \
+	chmod -R 777 "$GOPATH" && \
+	apk add --no-cache gcc musl-dev && \
+	apk add git && \
+	apk update && \
+	apk upgrade
+
+# RUN go version
+ADD . $GOPATH/src/app
+
+WORKDIR $GOPATH/src/app
+
+# docker build
+RUN go mod tidy
+RUN go build -tags musl -o {{.AppExeName}} $GOPATH/src/app/app.go
+
+RUN apk del git && \
+	rm -rf $GOPATH/src/github.com/
+
+CMD ./{{.AppExeName}}
+`
+
+	FILE_CONFIG_LOCAL_YAML          = "config.local.yaml"
+	FILE_CONFIG_LOCAL_YAML_TEMPLATE = `
+{{template "BrokerConfigLocalYaml" .}}`
+
+	FILE_CONFIG_YAML          = "config.yaml"
+	FILE_CONFIG_YAML_TEMPLATE = `
+{{template "BrokerConfigYaml" .}}
+{{- if .DeadLetter}}{{template "BrokerDeadLetterConfigYaml" .}}{{end}}
+{{- if .Health}}HealthAddress: :8080
+{{end}}`
+
+	FILE_INTERNAL_DEF_GO          = path.Join("internal", "def.go")
+	FILE_INTERNAL_DEF_GO_TEMPLATE = strings.ReplaceAll(`package internal
+
+import (
+	"log"
+{{- range .Broker.Imports}}
+	{{printf "%q" .}}
+{{- end}}
+
+	{{.Broker.PackageAlias}} "{{.Broker.PackagePath}}"
+)
+
+var (
+	defaultLogger *log.Logger = log.New(log.Writer(), {{printf "%q" (print "[" .AppModuleName "] ")}}, log.LstdFlags|log.Lmsgprefix|log.LUTC)
+)
+
+type (
+	Host {{.Broker.PackageAlias}}.Worker
+
+	Config struct {
+		Environment string ”env:"Environment"”
+
+		// app
+		Version     string ”resource:".VERSION"”
+		Signature   string ”resource:".SIGNATURE"”
+		ServiceName string ”resource:".SERVICE_NAME"”
+
+{{template "BrokerConfigFields" .}}
+{{- if .DeadLetter}}
+{{template "BrokerDeadLetterConfigFields" .}}
+{{- end}}
+{{- if .Producer}}{{template "BrokerProducerConfigFields" .}}{{end}}
+{{- if .Health}}
+		// health
+		HealthAddress string ”env:"-"   yaml:"HealthAddress"”
+{{end}}
+		// tracing
+		JaegerTraceUrl string ”env:"JAEGER_TRACE_URL"”
+
+		// put your configuration here
+	}
+)
+
+func (h *Host) Init(conf *Config) {
+{{template "BrokerHostInit" .}}}
+
+func (h *Host) OnError(err error) (disposed bool) {
+	return false
+}
+`, "”", "`")
+
+	FILE_INTERNAL_SERVICE_PROVIDER_GO          = path.Join("internal", "serviceProvider.go")
+	FILE_INTERNAL_SERVICE_PROVIDER_GO_TEMPLATE = `package internal
+
+import (
+	"log"
+
+	"github.com/Bofry/trace"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+{{if .Producer -}}
+type ServiceProvider struct {
+	Producer *Producer
+}
+{{- else -}}
+type ServiceProvider struct {}
+{{- end}}
+
+func (p *ServiceProvider) Init(conf *Config) {
+	// initialize service provider components
+{{- if .Producer}}
+	producer, err := NewProducer(conf, p.TextMapPropagator)
+	if err != nil {
+		defaultLogger.Fatal(err)
+	}
+	p.Producer = producer
+{{- end}}
+}
+
+func (p *ServiceProvider) TracerProvider() *trace.SeverityTracerProvider {
+	return trace.GetTracerProvider()
+}
+
+func (p *ServiceProvider) TextMapPropagator() propagation.TextMapPropagator {
+	return trace.GetTextMapPropagator()
+}
+
+func (p *ServiceProvider) Logger() *log.Logger {
+	return defaultLogger
+}
+
+func (p *ServiceProvider) ConfigureLogger(l *log.Logger) {
+	l.SetOutput(p.Logger().Writer())
+	l.SetPrefix(p.Logger().Prefix())
+	l.SetFlags(p.Logger().Flags())
+}
+`
+
+	FILE_INTERNAL_APP_GO          = path.Join("internal", "app.go")
+	FILE_INTERNAL_APP_GO_TEMPLATE = `package internal
+
+import (
+	"context"
+	"log"
+
+	"github.com/Bofry/host"
+	"github.com/Bofry/trace"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+var (
+	_ host.App                    = new(App)
+	_ host.AppStaterConfigurator  = new(App)
+	_ host.AppTracingConfigurator = new(App)
+)
+
+type App struct {
+	Host            *Host
+	Config          *Config
+	ServiceProvider *ServiceProvider
+{{- if .DeadLetter}}
+	DeadLetter      *DeadLetterHandler
+{{- end}}
+{{- if .Health}}
+
+	MessageManager interface{}
+	health         *HealthServer
+{{- end}}
+}
+
+func (app *App) Init() {
+	// initialize daemon components
+{{- if .DeadLetter}}
+	err := app.DeadLetter.Init(app.Config, app.ServiceProvider)
+	if err != nil {
+		defaultLogger.Fatal(err)
+	}
+{{- end}}
+{{- if .Health}}
+	app.health = NewHealthServer(app.Config, app.MessageManager)
+{{- end}}
+}
+
+func (app *App) OnInit() {
+}
+
+func (app *App) OnInitComplete() {
+}
+
+func (app *App) OnStart(ctx context.Context) {
+{{- if .Health}}
+	app.health.Start()
+{{- end}}
+}
+
+func (app *App) OnStop(ctx context.Context) {
+{{- if .Health}}
+	{
+		defaultLogger.Printf("stoping HealthServer")
+		err := app.health.Shutdown(ctx)
+		if err != nil {
+			defaultLogger.Printf("stoping HealthServer error: %+v", err)
+		}
+	}
+{{- end}}
+{{- if .DeadLetter}}
+	{
+		defaultLogger.Printf("stoping DeadLetterHandler")
+		app.DeadLetter.Close()
+	}
+{{- end}}
+{{- if .Producer}}
+	{
+		defaultLogger.Printf("stoping Producer")
+		app.ServiceProvider.Producer.Close()
+	}
+{{- end}}
+	{
+		defaultLogger.Printf("stoping TracerProvider")
+		tp := trace.GetTracerProvider()
+		err := tp.Shutdown(ctx)
+		if err != nil {
+			defaultLogger.Printf("stoping TracerProvider error: %+v", err)
+		}
+	}
+}
+
+func (app *App) ConfigureLogger(l *log.Logger) {
+	l.SetFlags(defaultLogger.Flags())
+	l.SetOutput(defaultLogger.Writer())
+}
+
+func (app *App) Logger() *log.Logger {
+	return defaultLogger
+}
+
+func (app *App) ConfigureTracerProvider() {
+	if len(app.Config.JaegerTraceUrl) == 0 {
+		tp, _ := trace.NoopProvider()
+		trace.SetTracerProvider(tp)
+		return
+	}
+
+	tp, err := trace.JaegerProvider(app.Config.JaegerTraceUrl,
+		trace.ServiceName(app.Config.ServiceName),
+		trace.Signature(app.Config.Signature),
+		trace.Version(app.Config.Version),
+		trace.Environment(app.Config.Environment),
+		trace.OS(),
+		trace.Pid(),
+	)
+	if err != nil {
+		defaultLogger.Fatal(err)
+	}
+
+	trace.SetTracerProvider(tp)
+}
+
+func (app *App) TracerProvider() *trace.SeverityTracerProvider {
+	return trace.GetTracerProvider()
+}
+
+func (app *App) ConfigureTextMapPropagator() {
+	trace.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+}
+
+func (app *App) TextMapPropagator() propagation.TextMapPropagator {
+	return trace.GetTextMapPropagator()
+}
+`
+
+	FILE_INTERNAL_EVENT_LOG_GO          = path.Join("internal", "eventLog.go")
+	FILE_INTERNAL_EVENT_LOG_GO_TEMPLATE = `package internal
+
+import (
+	"log"
+
+	{{.Broker.PackageAlias}} "{{.Broker.PackagePath}}"
+)
+
+var _ {{.Broker.PackageAlias}}.EventLog = EventLog{}
+
+type EventLog struct {
+	logger   *log.Logger
+	evidence {{.Broker.PackageAlias}}.EventEvidence
+{{- if .DeadLetter}}
+	failed   *bool
+{{- end}}
+}
+
+// AfterProcessMessage implements middleware.EventLog.
+func (l EventLog) OnProcessMessageComplete(message *{{.Broker.PackageAlias}}.Message, reply {{.Broker.PackageAlias}}.ReplyCode) {
+{{- if .Health}}
+	workerMetrics.OnProcessMessageComplete()
+{{- end}}
+{{- if .DeadLetter}}
+	if !*l.failed {
+		// forget the failed attempts of the message once it succeeds
+		deadLetterAttempts.Remove(messageID(message))
+	}
+{{- end}}
+}
+
+// BeforeProcessMessage implements middleware.EventLog.
+func (l EventLog) OnProcessMessage(message *{{.Broker.PackageAlias}}.Message) {
+{{- if .Health}}
+	workerMetrics.OnProcessMessage()
+{{- end}}
+}
+
+// LogError implements middleware.EventLog.
+func (l EventLog) OnError(message *{{.Broker.PackageAlias}}.Message, err interface{}, stackTrace []byte) {
+{{- if .Health}}
+	workerMetrics.OnError()
+{{- end}}
+{{- if .DeadLetter}}
+	*l.failed = true
+{{- end}}
+}
+
+// Flush implements middleware.EventLog.
+func (l EventLog) Flush() {
+}
+`
+
+	FILE_INTERNAL_LOGGING_SERVICE_GO          = path.Join("internal", "loggingService.go")
+	FILE_INTERNAL_LOGGING_SERVICE_GO_TEMPLATE = `package internal
+
+import (
+	"log"
+
+	{{.Broker.PackageAlias}} "{{.Broker.PackagePath}}"
+)
+
+var _ {{.Broker.PackageAlias}}.LoggingService = new(LoggingService)
+
+type LoggingService struct {
+	logger *log.Logger
+}
+
+// CreateEventLog implements middleware.LoggingService.
+func (s *LoggingService) CreateEventLog(ev {{.Broker.PackageAlias}}.EventEvidence) {{.Broker.PackageAlias}}.EventLog {
+	return EventLog{
+		logger:   s.logger,
+		evidence: ev,
+{{- if .DeadLetter}}
+		failed:   new(bool),
+{{- end}}
+	}
+}
+
+// ConfigureLogger implements middleware.LoggingService.
+func (s *LoggingService) ConfigureLogger(l *log.Logger) {
+	s.logger = l
+}
+`
+
+	FILE_APP_GO          = "app.go"
+	FILE_APP_GO_TEMPLATE = strings.ReplaceAll(`package main
+
+import (
+	. "{{.AppModuleName}}/internal"
+	. "{{.AppModuleName}}/observer"
+
+	"github.com/Bofry/config"
+	{{.Broker.PackageAlias}} "{{.Broker.PackagePath}}"
+)
+
+//go:generate gen-worker-{{.Broker.Name}}-handler
+type MessageManager struct {
+	/* put your message handler below */
+{{template "BrokerMessageManager" .}}}
+
+func main() {
+{{- if or .DeadLetter .Health}}
+	app := App{
+{{- if .Health}}
+		MessageManager: &MessageManager{},
+{{- end}}
+{{- if .DeadLetter}}
+		DeadLetter: &DeadLetterHandler{
+			Observer: &DeadLetterMessageObserver{},
+		},
+{{- end}}
+	}
+{{- else}}
+	app := App{}
+{{- end}}
+	{{.Broker.PackageAlias}}.Startup(&app).
+		Middlewares(
+{{- if .Health}}
+			{{.Broker.PackageAlias}}.UseMessageManager(app.MessageManager),
+{{- else}}
+			{{.Broker.PackageAlias}}.UseMessageManager(&MessageManager{}),
+{{- end}}
+			{{.Broker.PackageAlias}}.UseLogging(&LoggingService{}),
+			{{.Broker.PackageAlias}}.UseTracing(true),
+{{- if .DeadLetter}}
+			{{.Broker.PackageAlias}}.UseErrorHandler(app.DeadLetter.ProcessError),
+{{- else}}
+			{{.Broker.PackageAlias}}.UseErrorHandler(func(ctx *{{.Broker.PackageAlias}}.Context, message *{{.Broker.PackageAlias}}.Message, err interface{}) {
+				ctx.Logger().Fatalf("catch err: %v", err)
+			}),
+{{- end}}
+			{{.Broker.PackageAlias}}.UseMessageObserverManager(&MessageObserverManager),
+		).
+		ConfigureConfiguration(func(service *config.ConfigurationService) {
+			service.
+				LoadYamlFile("config.yaml").
+				LoadYamlFile("config.${Environment}.yaml").
+				LoadEnvironmentVariables("").
+				LoadResource(".").
+				LoadResource(".conf/${Environment}").
+				LoadCommandArguments()
+		}).
+		Run()
+}
+`, "”", "`")
+
+	FILE_OBSERVER_DEF_GO          = path.Join("observer", "def.go")
+	FILE_OBSERVER_DEF_GO_TEMPLATE = `package observer
+
+//go:generate gen-worker-{{.Broker.Name}}-observer
+var MessageObserverManager = struct {
+	/* put your message observer below */
+	// *XxxMessageObserver
+}{}
+`
+
+	FILE_INTERNAL_DEAD_LETTER_GO          = path.Join("internal", "deadLetter.go")
+	FILE_INTERNAL_DEAD_LETTER_GO_TEMPLATE = strings.ReplaceAll(`package internal
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	{{.Broker.PackageAlias}} "{{.Broker.PackagePath}}"
+)
+
+var deadLetterAttempts = &deadLetterAttemptCounter{
+	attempts: make(map[string]int),
+}
+
+// DeadLetter represents the failed message published to the dead-letter queue.
+type DeadLetter struct {
+	ID        string    ”json:"id"”
+	Source    string    ”json:"source"”
+	Payload   string    ”json:"payload"”
+	Attempts  int       ”json:"attempts"”
+	Error     string    ”json:"error"”
+	Timestamp time.Time ”json:"timestamp"”
+}
+
+// DeadLetterObserver reports the events of DeadLetterHandler.
+type DeadLetterObserver interface {
+	Init(sp *ServiceProvider)
+	OnRetry(ctx context.Context, letter *DeadLetter)
+	OnDeadLetter(ctx context.Context, letter *DeadLetter)
+	OnDeadLetterError(ctx context.Context, letter *DeadLetter, err error)
+}
+
+// DeadLetterHandler publishes the failed message to the dead-letter queue
+// once it reaches DeadLetterMaxAttempts, instead of crashing the worker.
+type DeadLetterHandler struct {
+	Observer DeadLetterObserver
+
+	maxAttempts int
+	producer    *deadLetterProducer
+}
+
+func (h *DeadLetterHandler) Init(conf *Config, sp *ServiceProvider) error {
+	producer, err := newDeadLetterProducer(conf, sp)
+	if err != nil {
+		return err
+	}
+
+	h.producer = producer
+	h.maxAttempts = conf.DeadLetterMaxAttempts
+	if h.maxAttempts < 1 {
+		h.maxAttempts = 1
+	}
+
+	if h.Observer != nil {
+		h.Observer.Init(sp)
+	}
+	return nil
+}
+
+// ProcessError implements the error handler of UseErrorHandler().
+func (h *DeadLetterHandler) ProcessError(ctx *{{.Broker.PackageAlias}}.Context, message *{{.Broker.PackageAlias}}.Message, err interface{}) {
+	letter := newDeadLetter(message)
+	letter.Error = fmt.Sprintf("%v", err)
+	letter.Timestamp = time.Now().UTC()
+
+	letter.Attempts = deadLetterAttempts.Add(letter.ID, letter.Attempts)
+	if letter.Attempts < h.maxAttempts {
+		retryMessage(message)
+		if h.Observer != nil {
+			h.Observer.OnRetry(ctx, letter)
+		}
+		return
+	}
+
+	if err := h.producer.Publish(letter); err != nil {
+		// keep the message on the broker, it will be delivered again
+		retryMessage(message)
+		if h.Observer != nil {
+			h.Observer.OnDeadLetterError(ctx, letter, err)
+		}
+		return
+	}
+	deadLetterAttempts.Remove(letter.ID)
+	discardMessage(message)
+	if h.Observer != nil {
+		h.Observer.OnDeadLetter(ctx, letter)
+	}
+}
+
+func (h *DeadLetterHandler) Close() {
+	if h.producer != nil {
+		h.producer.Close()
+	}
+}
+
+// deadLetterAttemptCounter counts the failed attempts of the messages. The
+// entry is removed once the message is completed by EventLog or published
+// to the dead-letter queue.
+type deadLetterAttemptCounter struct {
+	mutex    sync.Mutex
+	attempts map[string]int
+}
+
+// Add increases the failed attempts of the message and returns it, the
+// delivered is the delivery count reported by the broker if any.
+func (c *deadLetterAttemptCounter) Add(id string, delivered int) int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	attempts := c.attempts[id] + 1
+	if attempts < delivered {
+		attempts = delivered
+	}
+	c.attempts[id] = attempts
+	return attempts
+}
+
+func (c *deadLetterAttemptCounter) Remove(id string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	delete(c.attempts, id)
+}
+`, "”", "`")
+
+	FILE_INTERNAL_DEAD_LETTER_PRODUCER_GO          = path.Join("internal", "deadLetterProducer.go")
+	FILE_INTERNAL_DEAD_LETTER_PRODUCER_GO_TEMPLATE = `{{template "BrokerDeadLetterProducer" .}}`
+
+	FILE_INTERNAL_HEALTH_GO          = path.Join("internal", "health.go")
+	FILE_INTERNAL_HEALTH_GO_TEMPLATE = `package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"reflect"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	healthDialTimeout        = 1 * time.Second
+	healthReadHeaderTimeout  = 5 * time.Second
+	healthMetricsContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+	healthSourceTagName = "{{.Broker.MessageTagName}}"
+	healthSkipTagName   = "@skip"
+	healthInvalidSource = "?"
+)
+
+var workerMetrics = new(WorkerMetrics)
+
+// WorkerMetrics counts the messages processed by the worker, it is fed
+// by EventLog.
+type WorkerMetrics struct {
+	processed int64
+	failed    int64
+	inFlight  int64
+}
+
+func (m *WorkerMetrics) OnProcessMessage() {
+	atomic.AddInt64(&m.inFlight, 1)
+}
+
+func (m *WorkerMetrics) OnProcessMessageComplete() {
+	atomic.AddInt64(&m.inFlight, -1)
+	atomic.AddInt64(&m.processed, 1)
+}
+
+func (m *WorkerMetrics) OnError() {
+	atomic.AddInt64(&m.failed, 1)
+}
+
+// HealthServer is the side-car HTTP listener serves /healthz, /readyz
+// and /metrics.
+type HealthServer struct {
+	conf    *Config
+	sources []string
+	server  *http.Server
+	ready   int32
+}
+
+func NewHealthServer(conf *Config, messageManager interface{}) *HealthServer {
+	s := &HealthServer{
+		conf:    conf,
+		sources: messageSources(messageManager),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", s.healthz)
+	mux.HandleFunc("/readyz", s.readyz)
+	mux.HandleFunc("/metrics", s.metrics)
+
+	s.server = &http.Server{
+		Addr:              conf.HealthAddress,
+		Handler:           mux,
+		ReadHeaderTimeout: healthReadHeaderTimeout,
+	}
+	return s
+}
+
+func (s *HealthServer) Start() {
+	if len(s.server.Addr) == 0 {
+		defaultLogger.Printf("HealthServer is disabled, HealthAddress is not specified")
+		return
+	}
+
+	listener, err := net.Listen("tcp", s.server.Addr)
+	if err != nil {
+		defaultLogger.Printf("starting HealthServer error: %+v", err)
+		return
+	}
+	defaultLogger.Printf("HealthServer listening on %s", listener.Addr())
+
+	go func() {
+		err := s.server.Serve(listener)
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			defaultLogger.Printf("HealthServer error: %+v", err)
+		}
+	}()
+	atomic.StoreInt32(&s.ready, 1)
+}
+
+func (s *HealthServer) Shutdown(ctx context.Context) error {
+	atomic.StoreInt32(&s.ready, 0)
+	return s.server.Shutdown(ctx)
+}
+
+// healthz reports the process is alive.
+func (s *HealthServer) healthz(w http.ResponseWriter, r *http.Request) {
+	fmt.Fprintln(w, "ok")
+}
+
+// readyz reports the worker is started and its consumer has joined the
+// broker.
+func (s *HealthServer) readyz(w http.ResponseWriter, r *http.Request) {
+	if atomic.LoadInt32(&s.ready) == 0 {
+		http.Error(w, "worker is not started", http.StatusServiceUnavailable)
+		return
+	}
+	if err := checkConsumer(s.conf, s.sources); err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	fmt.Fprintln(w, "ok")
+}
+
+// metrics reports the message counts in Prometheus text format.
+func (s *HealthServer) metrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", healthMetricsContentType)
+
+	fmt.Fprintln(w, "# HELP worker_messages_processed_total The total number of the processed messages.")
+	fmt.Fprintln(w, "# TYPE worker_messages_processed_total counter")
+	fmt.Fprintf(w, "worker_messages_processed_total %d\n", atomic.LoadInt64(&workerMetrics.processed))
+	fmt.Fprintln(w, "# HELP worker_messages_failed_total The total number of the failed messages.")
+	fmt.Fprintln(w, "# TYPE worker_messages_failed_total counter")
+	fmt.Fprintf(w, "worker_messages_failed_total %d\n", atomic.LoadInt64(&workerMetrics.failed))
+	fmt.Fprintln(w, "# HELP worker_messages_in_flight The number of the messages in processing.")
+	fmt.Fprintln(w, "# TYPE worker_messages_in_flight gauge")
+	fmt.Fprintf(w, "worker_messages_in_flight %d\n", atomic.LoadInt64(&workerMetrics.inFlight))
+}
+
+// messageSources returns the sources, e.g: topics or streams, declared by
+// the MessageManager tags.
+func messageSources(messageManager interface{}) []string {
+	rv := reflect.Indirect(reflect.ValueOf(messageManager))
+	if rv.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var sources []string
+	for i := 0; i < rv.NumField(); i++ {
+		tag := rv.Type().Field(i).Tag
+		if val, ok := tag.Lookup(healthSkipTagName); ok {
+			if len(val) == 0 || val == "on" {
+				continue
+			}
+		}
+
+		source := tag.Get(healthSourceTagName)
+		if len(source) == 0 || source == healthInvalidSource {
+			continue
+		}
+		sources = append(sources, source)
+	}
+	return sources
+}
+
+// dialBroker returns nil if any of the broker addresses is reachable.
+func dialBroker(addresses []string) error {
+	var lastErr error = errors.New("no broker address")
+	for _, addr := range addresses {
+		conn, err := net.DialTimeout("tcp", addr, healthDialTimeout)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		conn.Close()
+		return nil
+	}
+	return fmt.Errorf("broker is unreachable: %v", lastErr)
+}
+`
+
+	FILE_INTERNAL_HEALTH_BROKER_GO          = path.Join("internal", "healthBroker.go")
+	FILE_INTERNAL_HEALTH_BROKER_GO_TEMPLATE = `{{template "BrokerHealth" .}}`
+
+	FILE_INTERNAL_PRODUCER_GO          = path.Join("internal", "producer.go")
+	FILE_INTERNAL_PRODUCER_GO_TEMPLATE = `{{template "BrokerProducer" .}}`
+
+	FILE_TESTING_GO          = path.Join("testing", "testing.go")
+	FILE_TESTING_GO_TEMPLATE = `{{template "BrokerTesting" .}}`
+
+	FILE_OBSERVER_DEAD_LETTER_OBSERVER_GO          = path.Join("observer", "deadLetterObserver.go")
+	FILE_OBSERVER_DEAD_LETTER_OBSERVER_GO_TEMPLATE = `package observer
+
+import (
+	"context"
+	"log"
+
+	. "{{.AppModuleName}}/internal"
+)
+
+var _ DeadLetterObserver = new(DeadLetterMessageObserver)
+
+type DeadLetterMessageObserver struct {
+	logger *log.Logger
+}
+
+// Init implements DeadLetterObserver.
+func (obs *DeadLetterMessageObserver) Init(sp *ServiceProvider) {
+	obs.logger = sp.Logger()
+}
+
+// OnRetry implements DeadLetterObserver.
+func (obs *DeadLetterMessageObserver) OnRetry(ctx context.Context, letter *DeadLetter) {
+	obs.logger.Printf("message %s from %s failed (attempts: %d): %s", letter.ID, letter.Source, letter.Attempts, letter.Error)
+}
+
+// OnDeadLetter implements DeadLetterObserver.
+func (obs *DeadLetterMessageObserver) OnDeadLetter(ctx context.Context, letter *DeadLetter) {
+	obs.logger.Printf("message %s from %s is dead-lettered (attempts: %d): %s", letter.ID, letter.Source, letter.Attempts, letter.Error)
+}
+
+// OnDeadLetterError implements DeadLetterObserver.
+func (obs *DeadLetterMessageObserver) OnDeadLetterError(ctx context.Context, letter *DeadLetter, err error) {
+	obs.logger.Printf("message %s from %s cannot be dead-lettered: %v", letter.ID, letter.Source, err)
+}
+`
+)
+
+type AppMetadata struct {
+	RuntimeVersion string
+	AppExeName     string
+	AppModuleName  string
+	Broker         Broker
+	DeadLetter     bool
+	Health         bool
+	Producer       bool
+}
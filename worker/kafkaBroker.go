@@ -0,0 +1,207 @@
+package main
+
+import "strings"
+
+var _ TestingBroker = new(KafkaBroker)
+
+func init() {
+	registerBroker(new(KafkaBroker))
+}
+
+type KafkaBroker struct{}
+
+func (*KafkaBroker) Name() string           { return "kafka" }
+func (*KafkaBroker) PackagePath() string    { return "github.com/Bofry/worker-kafka" }
+func (*KafkaBroker) PackageAlias() string   { return "kafka" }
+func (*KafkaBroker) Imports() []string      { return []string{"time"} }
+func (*KafkaBroker) MessageTagName() string { return "topic" }
+
+func (*KafkaBroker) EnvTemplate() string {
+	return `KAFKA_BROKERS=127.0.0.1:9092
+`
+}
+
+func (*KafkaBroker) EnvSampleTemplate() string {
+	return `KAFKA_BROKERS=127.0.0.1:9092,127.0.0.2:9092
+`
+}
+
+func (*KafkaBroker) ConfigYamlTemplate() string {
+	return `KafkaConsumerGroup: {{.AppModuleName}}
+KafkaClientID: {{.AppExeName}}
+KafkaAutoOffsetReset: earliest
+KafkaPollingTimeout: 100ms
+KafkaAllowAutoCreateTopics: false
+`
+}
+
+func (*KafkaBroker) ConfigLocalYamlTemplate() string {
+	return `KafkaAllowAutoCreateTopics: true
+`
+}
+
+func (*KafkaBroker) ConfigFieldsTemplate() string {
+	return strings.ReplaceAll(`		// kafka
+		KafkaBrokers               []string      ”env:"*KAFKA_BROKERS"       yaml:"-"”
+		KafkaConsumerGroup         string        ”env:"-"                    yaml:"KafkaConsumerGroup"”
+		KafkaClientID              string        ”env:"-"                    yaml:"KafkaClientID"”
+		KafkaAutoOffsetReset       string        ”env:"-"                    yaml:"KafkaAutoOffsetReset"”
+		KafkaPollingTimeout        time.Duration ”env:"-"                    yaml:"KafkaPollingTimeout"”
+		KafkaAllowAutoCreateTopics bool          ”env:"-"                    yaml:"KafkaAllowAutoCreateTopics"”
+`, "”", "`")
+}
+
+func (*KafkaBroker) HostInitTemplate() string {
+	return `	h.Brokers = conf.KafkaBrokers
+	h.ConsumerGroup = conf.KafkaConsumerGroup
+	h.ClientID = conf.KafkaClientID
+	h.AutoOffsetReset = conf.KafkaAutoOffsetReset
+	h.PollingTimeout = conf.KafkaPollingTimeout
+	h.AllowAutoCreateTopics = conf.KafkaAllowAutoCreateTopics
+`
+}
+
+func (*KafkaBroker) MessageManagerTemplate() string {
+	return strings.ReplaceAll(`	// *XxxMessageHandler     ”topic:"XxxTopic"”
+	// *InvalidMessageHandler ”topic:"?"”
+`, "”", "`")
+}
+
+func (*KafkaBroker) TestingTemplate() string {
+	return `package testing
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Bofry/trace"
+	kafka "github.com/Bofry/worker-kafka"
+)
+
+var (
+	_ kafka.MessageDelegate = new(MessageRecorder)
+
+	configureTracerProviderOnce sync.Once
+)
+
+// NewContext returns a fake *kafka.Context carrying a noop tracer.
+func NewContext() *kafka.Context {
+	configureTracerProviderOnce.Do(func() {
+		tp, _ := trace.NoopProvider()
+		trace.SetTracerProvider(tp)
+	})
+
+	return &kafka.Context{
+		Context: context.Background(),
+	}
+}
+
+// MessageBuilder builds the fake *kafka.Message.
+type MessageBuilder struct {
+	topic     string
+	partition int32
+	offset    int64
+	key       []byte
+	value     []byte
+}
+
+func NewMessage(topic string) *MessageBuilder {
+	return &MessageBuilder{
+		topic: topic,
+	}
+}
+
+func (b *MessageBuilder) Partition(partition int32) *MessageBuilder {
+	b.partition = partition
+	return b
+}
+
+func (b *MessageBuilder) Offset(offset int64) *MessageBuilder {
+	b.offset = offset
+	return b
+}
+
+func (b *MessageBuilder) Key(key []byte) *MessageBuilder {
+	b.key = key
+	return b
+}
+
+func (b *MessageBuilder) Value(value []byte) *MessageBuilder {
+	b.value = value
+	return b
+}
+
+func (b *MessageBuilder) JSON(v interface{}) *MessageBuilder {
+	value, err := json.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	b.value = value
+	return b
+}
+
+// Build returns the message and the recorder of its responses.
+func (b *MessageBuilder) Build() (*kafka.Message, *MessageRecorder) {
+	recorder := new(MessageRecorder)
+
+	return &kafka.Message{
+		Topic:     b.topic,
+		Partition: b.partition,
+		Offset:    b.offset,
+		Key:       b.key,
+		Value:     b.value,
+		Timestamp: time.Now(),
+		Delegate:  recorder,
+	}, recorder
+}
+
+// MessageRecorder records the Commit() calls of the message.
+type MessageRecorder struct {
+	mutex     sync.Mutex
+	committed int
+}
+
+// OnCommit implements kafka.MessageDelegate.
+func (r *MessageRecorder) OnCommit(msg *kafka.Message) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.committed++
+}
+
+func (r *MessageRecorder) AssertCommitted(t testing.TB) {
+	t.Helper()
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.committed == 0 {
+		t.Errorf("message should be committed")
+	}
+}
+
+func (r *MessageRecorder) AssertNotCommitted(t testing.TB) {
+	t.Helper()
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.committed > 0 {
+		t.Errorf("message should not be committed, but committed %d time(s)", r.committed)
+	}
+}
+`
+}
+
+func (*KafkaBroker) HealthTemplate() string {
+	return `package internal
+
+// checkConsumer returns nil if any of the kafka brokers is reachable. The
+// consumer group membership cannot be queried without the kafka admin
+// client, so the probe does not tell whether the consumer has joined.
+func checkConsumer(conf *Config, topics []string) error {
+	return dialBroker(conf.KafkaBrokers)
+}
+`
+}
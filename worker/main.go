@@ -40,6 +40,25 @@ var (
 		FILE_LOAD_ENV_BAT:                 FILE_LOAD_ENV_BAT_TEMPLATE,
 		FILE_DOCKERFILE:                   FILE_DOCKERFILE_TEMPLATE,
 	}
+
+	__DEAD_LETTER_FILE_TEMPLATES = map[string]string{
+		FILE_INTERNAL_DEAD_LETTER_GO:          FILE_INTERNAL_DEAD_LETTER_GO_TEMPLATE,
+		FILE_INTERNAL_DEAD_LETTER_PRODUCER_GO: FILE_INTERNAL_DEAD_LETTER_PRODUCER_GO_TEMPLATE,
+		FILE_OBSERVER_DEAD_LETTER_OBSERVER_GO: FILE_OBSERVER_DEAD_LETTER_OBSERVER_GO_TEMPLATE,
+	}
+
+	__HEALTH_FILE_TEMPLATES = map[string]string{
+		FILE_INTERNAL_HEALTH_GO:        FILE_INTERNAL_HEALTH_GO_TEMPLATE,
+		FILE_INTERNAL_HEALTH_BROKER_GO: FILE_INTERNAL_HEALTH_BROKER_GO_TEMPLATE,
+	}
+
+	__PRODUCER_FILE_TEMPLATES = map[string]string{
+		FILE_INTERNAL_PRODUCER_GO: FILE_INTERNAL_PRODUCER_GO_TEMPLATE,
+	}
+
+	__TESTING_FILE_TEMPLATES = map[string]string{
+		FILE_TESTING_GO: FILE_TESTING_GO_TEMPLATE,
+	}
 )
 
 func main() {
@@ -53,52 +72,81 @@ func main() {
 	case "init":
 		var (
 			moduleName string
+			version    string
+			broker     Broker
+			deadLetter bool
+			health     bool
+			producer   bool
 
 			err error
 		)
 
-		if len(os.Args) > 2 {
-			var pos int = 2
+		var pos int = 2
+		if len(os.Args) > pos {
 			argv = os.Args[pos]
-
-			if strings.HasPrefix(argv, "-") {
-				moduleName, err = getModuleName()
-				if err != nil {
-					throw(err.Error())
-					exit(1)
-				}
-			} else {
+			if !strings.HasPrefix(argv, "-") {
 				moduleName = argv
-				moduleName, err = initModule(moduleName)
-				if err != nil {
-					throw(err.Error())
-					exit(1)
-				}
 				pos++
 			}
+		}
 
-			for len(os.Args) > pos {
-				argv = os.Args[pos]
-				pos++
-				switch argv {
-				case "-v":
-					if len(os.Args) > pos {
-						// run go get -u -v github.com/Bofry/worker-nsq@<version>
-						argv = os.Args[pos]
-						pos++
-						err = executeCommand("go", "get", "-u", "-v", "github.com/Bofry/worker-nsq@"+argv)
-						if err != nil {
-							throw(err.Error())
-							exit(1)
-						}
+		for len(os.Args) > pos {
+			argv = os.Args[pos]
+			pos++
+			switch argv {
+			case "-b", "--broker":
+				if len(os.Args) > pos {
+					argv = os.Args[pos]
+					pos++
+					broker, err = lookupBroker(argv)
+					if err != nil {
+						throw(err.Error())
+						exit(1)
 					}
-				default:
-					throw(fmt.Sprintf("unknown flag '%s'\n", argv))
-					exit(1)
 				}
+			case "-v":
+				if len(os.Args) > pos {
+					version = os.Args[pos]
+					pos++
+				}
+			case "--dead-letter":
+				deadLetter = true
+			case "--health":
+				health = true
+			case "--producer":
+				producer = true
+			default:
+				throw(fmt.Sprintf("unknown flag '%s'\n", argv))
+				exit(1)
 			}
+		}
+
+		if broker == nil {
+			throw(fmt.Sprintf("missing flag '--broker', should be one of %s\n", strings.Join(getBrokerNames(), "|")))
+			exit(1)
+		}
+		if deadLetter && !supportDeadLetter(broker) {
+			throw(fmt.Sprintf("broker '%s' does not support flag '--dead-letter'\n", broker.Name()))
+			exit(1)
+		}
+		if producer && !supportProducer(broker) {
+			throw(fmt.Sprintf("broker '%s' does not support flag '--producer'\n", broker.Name()))
+			exit(1)
+		}
+
+		if len(moduleName) > 0 {
+			moduleName, err = initModule(moduleName)
 		} else {
 			moduleName, err = getModuleName()
+		}
+		if err != nil {
+			throw(err.Error())
+			exit(1)
+		}
+
+		if len(version) > 0 {
+			// run go get -u -v github.com/Bofry/worker-xxx@<version>
+			err = executeCommand("go", "get", "-u", "-v", broker.PackagePath()+"@"+version)
 			if err != nil {
 				throw(err.Error())
 				exit(1)
@@ -114,12 +162,40 @@ func main() {
 			RuntimeVersion: runtimeVersion,
 			AppModuleName:  moduleName,
 			AppExeName:     extractAppExeName(moduleName),
+			Broker:         broker,
+			DeadLetter:     deadLetter,
+			Health:         health,
+			Producer:       producer,
 		}
 		err = initProject(&metadata)
 		if err != nil {
 			throw(err.Error())
 			exit(1)
 		}
+	case "stream":
+		err := runStreamCommand(os.Args[2:])
+		if err != nil {
+			throw(err.Error())
+			exit(1)
+		}
+	case "topics":
+		err := runTopicsCommand(os.Args[2:])
+		if err != nil {
+			throw(err.Error())
+			exit(1)
+		}
+	case "channels":
+		err := runChannelsCommand(os.Args[2:])
+		if err != nil {
+			throw(err.Error())
+			exit(1)
+		}
+	case "publish":
+		err := runPublishCommand(os.Args[2:])
+		if err != nil {
+			throw(err.Error())
+			exit(1)
+		}
 	case "help", "-h", "--help":
 		showUsage()
 		exit(0)
@@ -148,15 +224,19 @@ func exit(code int) {
 }
 
 func showUsage() {
-	fmt.Print(`Usage: worker-nsq COMMAND [ARGS...] [OPTIONS...]
+	fmt.Printf(`Usage: worker COMMAND [ARGS...] [OPTIONS...]
 
 COMMANDS:
-  init        create new worker-nsq project
+  init        create new worker project
+  stream      manage the redis streams of the worker project
+  topics      manage the nsq topics of the worker project
+  channels    manage the nsq channels of the worker project
+  publish     publish messages to the streams or topics of the worker project
   help        show this usage
 
 
 init USAGE:
-  worker-nsq init [MODULE_NAME] [OPTIONS...]
+  worker init [MODULE_NAME] --broker BROKER [OPTIONS...]
 
 init ARGS:
   MODULE_NAME   the go module name for the application.
@@ -164,9 +244,29 @@ init ARGS:
 				      to apply current working directory name.
 
 init OPTIONS:
-  -v VERSION    the worker-nsq version.
-
-`)
+  -b, --broker BROKER   the message broker of the worker, should be one of
+                        %s.
+  -v VERSION            the worker package version of the broker.
+  --dead-letter         publish the failed messages to the dead-letter queue
+                        instead of crashing the worker, only for nsq|redis.
+  --health              serve /healthz, /readyz and /metrics on HealthAddress.
+  --producer            add the message producer to ServiceProvider, only for
+                        nsq|redis.
+
+stream USAGE:
+  worker stream COMMAND STREAM [OPTIONS...]
+  see 'worker stream help' for details.
+
+topics/channels USAGE:
+  worker topics COMMAND [TOPIC...] [OPTIONS...]
+  worker channels COMMAND [TOPIC...] [OPTIONS...]
+  see 'worker topics help' for details.
+
+publish USAGE:
+  worker publish [SOURCE] [OPTIONS...]
+  see 'worker publish help' for details.
+
+`, strings.Join(getBrokerNames(), "|"))
 }
 
 func executeCommand(name string, args ...string) error {
@@ -250,6 +350,34 @@ func generateFiles(metadata *AppMetadata) error {
 			return err
 		}
 	}
+	if metadata.DeadLetter {
+		for filename, template := range __DEAD_LETTER_FILE_TEMPLATES {
+			if err := generateFile(filename, template, metadata); err != nil {
+				return err
+			}
+		}
+	}
+	if metadata.Health {
+		for filename, template := range __HEALTH_FILE_TEMPLATES {
+			if err := generateFile(filename, template, metadata); err != nil {
+				return err
+			}
+		}
+	}
+	if metadata.Producer {
+		for filename, template := range __PRODUCER_FILE_TEMPLATES {
+			if err := generateFile(filename, template, metadata); err != nil {
+				return err
+			}
+		}
+	}
+	if supportTesting(metadata.Broker) {
+		for filename, template := range __TESTING_FILE_TEMPLATES {
+			if err := generateFile(filename, template, metadata); err != nil {
+				return err
+			}
+		}
+	}
 	return nil
 }
 
@@ -271,7 +399,7 @@ func generateFile(filename string, pattern string, metadata *AppMetadata) error
 			return err
 		}
 
-		tmpl, err := template.New(filename).Parse(pattern)
+		tmpl, err := parseTemplate(filename, pattern, metadata.Broker)
 		if err != nil {
 			return err
 		}
@@ -289,6 +417,21 @@ func generateFile(filename string, pattern string, metadata *AppMetadata) error
 	return nil
 }
 
+func parseTemplate(name string, pattern string, broker Broker) (*template.Template, error) {
+	tmpl, err := template.New(name).Parse(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	for name, fragment := range brokerTemplates(broker) {
+		_, err = tmpl.New(name).Parse(fragment)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return tmpl, nil
+}
+
 func getModuleName() (string, error) {
 	goModBytes, err := ioutil.ReadFile("go.mod")
 	if err != nil {
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"reflect"
+	"strconv"
+)
+
+const (
+	MESSAGE_MANAGER_TYPE_NAME string = "MessageManager"
+	MESSAGE_MANAGER_FILE_NAME string = "app.go"
+
+	TAG_SKIP_OPT_NAME      string = "@skip"
+	MESSAGE_INVALID_SOURCE string = "?"
+)
+
+// lookupMessageManagerSources resolves the message sources, e.g: topics or
+// streams, declared by the tagName tag of MessageManager fields in gofile.
+func lookupMessageManagerSources(gofile string, tagName string) ([]string, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, gofile, nil, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		sources []string
+		visited = make(map[string]bool)
+	)
+
+	for _, node := range f.Decls {
+		genDecl, ok := node.(*ast.GenDecl)
+		if !ok {
+			continue
+		}
+		for _, spec := range genDecl.Specs {
+			typeSpec, ok := spec.(*ast.TypeSpec)
+			if !ok || typeSpec.Name.Name != MESSAGE_MANAGER_TYPE_NAME {
+				continue
+			}
+			structType, ok := typeSpec.Type.(*ast.StructType)
+			if !ok {
+				continue
+			}
+
+			for _, field := range structType.Fields.List {
+				if field.Tag == nil || field.Tag.Kind != token.STRING {
+					continue
+				}
+				tagLiteral, err := strconv.Unquote(field.Tag.Value)
+				if err != nil {
+					tagLiteral = field.Tag.Value
+				}
+				tag := reflect.StructTag(tagLiteral)
+
+				// has @skip
+				if val, ok := tag.Lookup(TAG_SKIP_OPT_NAME); ok {
+					if len(val) == 0 || val == "on" {
+						continue
+					}
+				}
+
+				source := tag.Get(tagName)
+				if len(source) == 0 || source == MESSAGE_INVALID_SOURCE {
+					continue
+				}
+				if !visited[source] {
+					visited[source] = true
+					sources = append(sources, source)
+				}
+			}
+		}
+	}
+	return sources, nil
+}
+
+// lookupProjectBroker resolves the broker by the worker package imported in
+// gofile, e.g: github.com/Bofry/worker-nsq.
+func lookupProjectBroker(gofile string) (Broker, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, gofile, nil, parser.ImportsOnly)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, spec := range f.Imports {
+		importPath, err := strconv.Unquote(spec.Path.Value)
+		if err != nil {
+			continue
+		}
+		for _, name := range getBrokerNames() {
+			broker := __BROKERS[name]
+			if broker.PackagePath() == importPath {
+				return broker, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("cannot find the worker package imported in '%s'", gofile)
+}
@@ -0,0 +1,592 @@
+package main
+
+import "strings"
+
+var (
+	_ DeadLetterBroker = new(NsqBroker)
+	_ TestingBroker    = new(NsqBroker)
+	_ ProducerBroker   = new(NsqBroker)
+)
+
+func init() {
+	registerBroker(new(NsqBroker))
+}
+
+type NsqBroker struct{}
+
+func (*NsqBroker) Name() string           { return "nsq" }
+func (*NsqBroker) PackagePath() string    { return "github.com/Bofry/worker-nsq" }
+func (*NsqBroker) PackageAlias() string   { return "nsq" }
+func (*NsqBroker) Imports() []string      { return []string{"time"} }
+func (*NsqBroker) MessageTagName() string { return NSQ_MESSAGE_TAG_NAME }
+
+func (*NsqBroker) EnvTemplate() string {
+	return `NSQLOOKUPD_ADDRESS=nsqlookupd://127.0.0.1:4160,127.0.0.2:4161
+NSQD_ADDRESS=nsqd://127.0.0.1:4150,127.0.0.2:4150
+NSQD_SERVERS=127.0.0.1:4150,127.0.0.2:4150
+
+`
+}
+
+func (*NsqBroker) EnvSampleTemplate() string {
+	return `NSQLOOKUPD_ADDRESS=nsqlookupd://127.0.0.1:4160,127.0.0.2:4161
+NSQD_ADDRESS=nsqd://127.0.0.1:4150,127.0.0.2:4150
+NSQD_SERVERS=127.0.0.1:4150,127.0.0.2:4150
+
+`
+}
+
+func (*NsqBroker) ConfigYamlTemplate() string {
+	return `NsqChannel: {{.AppModuleName}}
+NsqHandlerConcurrency: 3
+`
+}
+
+func (*NsqBroker) ConfigLocalYamlTemplate() string {
+	return `NsqHandlerConcurrency: 2
+`
+}
+
+func (*NsqBroker) ConfigFieldsTemplate() string {
+	return strings.ReplaceAll(`		// nsq
+		NsqAddress            string ”env:"*NSQLOOKUPD_ADDRESS"   yaml:"-"”
+		NsqChannel            string ”env:"-"                     yaml:"NsqChannel"”
+		NsqHandlerConcurrency int    ”env:"-"                     yaml:"NsqHandlerConcurrency"”
+`, "”", "`")
+}
+
+func (*NsqBroker) HostInitTemplate() string {
+	return `	config := nsq.NewConfig()
+	{
+		config.LookupdPollInterval = time.Second * 3
+		config.DefaultRequeueDelay = 0
+		config.MaxBackoffDuration = time.Millisecond * 50
+		config.LowRdyIdleTimeout = time.Second * 1
+		config.RDYRedistributeInterval = time.Millisecond * 20
+	}
+
+	h.NsqAddress = conf.NsqAddress
+	h.Channel = conf.NsqChannel
+	h.HandlerConcurrency = conf.NsqHandlerConcurrency
+	h.Config = config
+`
+}
+
+func (*NsqBroker) MessageManagerTemplate() string {
+	return strings.ReplaceAll(`	// *XxxMessageHandler     ”topic:"XxxStream"”
+	// *InvalidMessageHandler ”topic:"?"”
+`, "”", "`")
+}
+
+func (*NsqBroker) HealthTemplate() string {
+	return strings.ReplaceAll(`package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+)
+
+type nsqLookupdNodes struct {
+	Producers []struct {
+		BroadcastAddress string ”json:"broadcast_address"”
+		HttpPort         int    ”json:"http_port"”
+	} ”json:"producers"”
+	// the nsqlookupd before v1.0 wraps the response with data
+	Data *nsqLookupdNodes ”json:"data"”
+}
+
+type nsqdStats struct {
+	Topics []struct {
+		TopicName string ”json:"topic_name"”
+		Channels  []struct {
+			ChannelName string ”json:"channel_name"”
+			Clients     []struct {
+				Hostname string ”json:"hostname"”
+			} ”json:"clients"”
+		} ”json:"channels"”
+	} ”json:"topics"”
+	// the nsqd before v1.0 wraps the response with data
+	Data *nsqdStats ”json:"data"”
+}
+
+// checkConsumer returns nil if the consumer of this host has subscribed
+// NsqChannel of all the topics, by the stats of the nsqd nodes registered on
+// nsqlookupd. The go-nsq consumer identifies itself by os.Hostname().
+func checkConsumer(conf *Config, topics []string) error {
+	hostname, err := os.Hostname()
+	if err != nil {
+		return err
+	}
+
+	nodes, err := lookupNsqdNodes(conf)
+	if err != nil {
+		return err
+	}
+
+	subscribed := make(map[string]bool)
+	for _, node := range nodes {
+		var stats nsqdStats
+		err := getNsqJson("http://"+node+"/stats?format=json&channel="+url.QueryEscape(conf.NsqChannel), &stats)
+		if err != nil {
+			return err
+		}
+		if stats.Data != nil {
+			stats = *stats.Data
+		}
+
+		for _, topic := range stats.Topics {
+			for _, channel := range topic.Channels {
+				if channel.ChannelName != conf.NsqChannel {
+					continue
+				}
+				for _, client := range channel.Clients {
+					if client.Hostname == hostname {
+						subscribed[topic.TopicName] = true
+					}
+				}
+			}
+		}
+	}
+
+	for _, topic := range topics {
+		if !subscribed[topic] {
+			return fmt.Errorf("consumer has not subscribed channel '%s' of topic '%s'", conf.NsqChannel, topic)
+		}
+	}
+	return nil
+}
+
+// lookupNsqdNodes returns the nsqd HTTP addresses registered on nsqlookupd.
+func lookupNsqdNodes(conf *Config) ([]string, error) {
+	var lastErr error = fmt.Errorf("no nsqlookupd address")
+	for _, addr := range nsqLookupdAddresses(conf) {
+		var nodes nsqLookupdNodes
+		if err := getNsqJson("http://"+addr+"/nodes", &nodes); err != nil {
+			lastErr = err
+			continue
+		}
+		if nodes.Data != nil {
+			nodes = *nodes.Data
+		}
+
+		addresses := make([]string, 0, len(nodes.Producers))
+		for _, producer := range nodes.Producers {
+			addresses = append(addresses, producer.BroadcastAddress+":"+strconv.Itoa(producer.HttpPort))
+		}
+		return addresses, nil
+	}
+	return nil, fmt.Errorf("nsqlookupd is unreachable: %v", lastErr)
+}
+
+// nsqLookupdAddresses returns the nsqlookupd HTTP addresses.
+func nsqLookupdAddresses(conf *Config) []string {
+	// e.g: nsqlookupd://127.0.0.1:4161,127.0.0.2:4161
+	address := conf.NsqAddress
+	if i := strings.Index(address, "://"); i >= 0 {
+		address = address[i+3:]
+	}
+	return strings.Split(address, ",")
+}
+
+func getNsqJson(url string, v interface{}) error {
+	client := http.Client{
+		Timeout: healthDialTimeout,
+	}
+	resp, err := client.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+`, "”", "`")
+}
+
+func (*NsqBroker) DeadLetterConfigYamlTemplate() string {
+	return `DeadLetterTopic: {{.AppExeName}}-dlq
+DeadLetterMaxAttempts: 3
+`
+}
+
+func (*NsqBroker) DeadLetterConfigFieldsTemplate() string {
+	// the dead-letter producer shares the producer of --producer if any
+	return strings.ReplaceAll(`		// dead-letter
+{{- if .Producer}}
+		DeadLetterTopic       string ”env:"-"   yaml:"DeadLetterTopic"”
+		DeadLetterMaxAttempts int    ”env:"-"   yaml:"DeadLetterMaxAttempts"”
+{{- else}}
+		DeadLetterNsqdServers []string ”env:"*NSQD_SERVERS"   yaml:"-"”
+		DeadLetterTopic       string   ”env:"-"               yaml:"DeadLetterTopic"”
+		DeadLetterMaxAttempts int      ”env:"-"               yaml:"DeadLetterMaxAttempts"”
+{{- end}}
+`, "”", "`")
+}
+
+func (*NsqBroker) DeadLetterProducerTemplate() string {
+	return `package internal
+
+import (
+	"encoding/json"
+{{- if not .Producer}}
+	"errors"
+{{- end}}
+
+	nsq "github.com/Bofry/worker-nsq"
+{{- if not .Producer}}
+	gonsq "github.com/nsqio/go-nsq"
+{{- end}}
+)
+
+type deadLetterProducer struct {
+	topic    string
+{{- if .Producer}}
+	producer *Producer
+{{- else}}
+	producer *gonsq.Producer
+{{- end}}
+}
+
+func newDeadLetterProducer(conf *Config, sp *ServiceProvider) (*deadLetterProducer, error) {
+{{- if .Producer}}
+	// share the producer of ServiceProvider, which closes it
+	return &deadLetterProducer{
+		topic:    conf.DeadLetterTopic,
+		producer: sp.Producer,
+	}, nil
+{{- else}}
+	if len(conf.DeadLetterNsqdServers) == 0 {
+		return nil, errors.New("missing NSQD_SERVERS for dead-letter producer")
+	}
+
+	producer, err := gonsq.NewProducer(conf.DeadLetterNsqdServers[0], gonsq.NewConfig())
+	if err != nil {
+		return nil, err
+	}
+	return &deadLetterProducer{
+		topic:    conf.DeadLetterTopic,
+		producer: producer,
+	}, nil
+{{- end}}
+}
+
+func (p *deadLetterProducer) Publish(letter *DeadLetter) error {
+	body, err := json.Marshal(letter)
+	if err != nil {
+		return err
+	}
+{{- if .Producer}}
+	return p.producer.publish(p.topic, body)
+{{- else}}
+	return p.producer.Publish(p.topic, body)
+{{- end}}
+}
+
+func (p *deadLetterProducer) Close() {
+{{- if .Producer}}
+	// the shared producer is closed by ServiceProvider.Producer
+{{- else}}
+	p.producer.Stop()
+{{- end}}
+}
+
+func newDeadLetter(message *nsq.Message) *DeadLetter {
+	return &DeadLetter{
+		ID:       messageID(message),
+		Source:   message.Topic,
+		Payload:  string(message.Body),
+		Attempts: int(message.Attempts),
+	}
+}
+
+func messageID(message *nsq.Message) string {
+	return string(message.ID[:])
+}
+
+func retryMessage(message *nsq.Message) {
+	message.Requeue(-1)
+}
+
+func discardMessage(message *nsq.Message) {
+	message.Finish()
+}
+`
+}
+
+func (*NsqBroker) TestingTemplate() string {
+	return `package testing
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Bofry/trace"
+	nsq "github.com/Bofry/worker-nsq"
+	gonsq "github.com/nsqio/go-nsq"
+)
+
+var (
+	_ gonsq.MessageDelegate = new(MessageRecorder)
+
+	configureTracerProviderOnce sync.Once
+)
+
+// NewContext returns a fake *nsq.Context carrying a noop tracer.
+func NewContext() *nsq.Context {
+	configureTracerProviderOnce.Do(func() {
+		tp, _ := trace.NoopProvider()
+		trace.SetTracerProvider(tp)
+	})
+
+	return &nsq.Context{
+		Context: context.Background(),
+	}
+}
+
+// MessageBuilder builds the fake *nsq.Message.
+type MessageBuilder struct {
+	topic    string
+	body     []byte
+	attempts uint16
+}
+
+func NewMessage(topic string) *MessageBuilder {
+	return &MessageBuilder{
+		topic:    topic,
+		attempts: 1,
+	}
+}
+
+func (b *MessageBuilder) Body(body []byte) *MessageBuilder {
+	b.body = body
+	return b
+}
+
+func (b *MessageBuilder) JSON(v interface{}) *MessageBuilder {
+	body, err := json.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	b.body = body
+	return b
+}
+
+func (b *MessageBuilder) Attempts(attempts uint16) *MessageBuilder {
+	b.attempts = attempts
+	return b
+}
+
+// Build returns the message and the recorder of its responses.
+func (b *MessageBuilder) Build() (*nsq.Message, *MessageRecorder) {
+	var id gonsq.MessageID
+	copy(id[:], time.Now().Format("150405.000000000"))
+
+	recorder := new(MessageRecorder)
+
+	m := gonsq.NewMessage(id, b.body)
+	m.Attempts = b.attempts
+	m.Timestamp = time.Now().UnixNano()
+	m.Delegate = recorder
+
+	return &nsq.Message{
+		Message: m,
+		Topic:   b.topic,
+	}, recorder
+}
+
+// MessageRecorder records the Finish(), Requeue() and Touch() calls of
+// the message.
+type MessageRecorder struct {
+	mutex        sync.Mutex
+	finished     int
+	requeued     int
+	touched      int
+	requeueDelay time.Duration
+}
+
+// OnFinish implements gonsq.MessageDelegate.
+func (r *MessageRecorder) OnFinish(m *gonsq.Message) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.finished++
+}
+
+// OnRequeue implements gonsq.MessageDelegate.
+func (r *MessageRecorder) OnRequeue(m *gonsq.Message, delay time.Duration, backoff bool) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.requeued++
+	r.requeueDelay = delay
+}
+
+// OnTouch implements gonsq.MessageDelegate.
+func (r *MessageRecorder) OnTouch(m *gonsq.Message) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.touched++
+}
+
+func (r *MessageRecorder) AssertFinished(t testing.TB) {
+	t.Helper()
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.finished == 0 {
+		t.Errorf("message should be finished")
+	}
+}
+
+func (r *MessageRecorder) AssertRequeued(t testing.TB) {
+	t.Helper()
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.requeued == 0 {
+		t.Errorf("message should be requeued")
+	}
+}
+
+func (r *MessageRecorder) AssertNotRequeued(t testing.TB) {
+	t.Helper()
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.requeued > 0 {
+		t.Errorf("message should not be requeued, but requeued %d time(s) with delay %v", r.requeued, r.requeueDelay)
+	}
+}
+
+func (r *MessageRecorder) AssertTouched(t testing.TB) {
+	t.Helper()
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.touched == 0 {
+		t.Errorf("message should be touched")
+	}
+}
+`
+}
+
+func (*NsqBroker) ProducerConfigFieldsTemplate() string {
+	return strings.ReplaceAll(`
+		// producer
+		ProducerNsqdServers []string ”env:"*NSQD_SERVERS"   yaml:"-"”
+`, "”", "`")
+}
+
+func (*NsqBroker) ProducerTemplate() string {
+	return strings.ReplaceAll(`package internal
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	gonsq "github.com/nsqio/go-nsq"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// ProducerMessage is the envelope of the message published by Producer.
+// The nsq message has no header, so the tracing context is carried by Header.
+type ProducerMessage struct {
+	Header map[string]string ”json:"header,omitempty"”
+	Body   json.RawMessage   ”json:"body"”
+}
+
+// Producer publishes the messages to the nsq topics. A message is published
+// to the first nsqd of NSQD_SERVERS, and to the next ones in order if it fails.
+type Producer struct {
+	producers  []*gonsq.Producer
+	propagator func() propagation.TextMapPropagator
+}
+
+func NewProducer(conf *Config, propagator func() propagation.TextMapPropagator) (*Producer, error) {
+	if len(conf.ProducerNsqdServers) == 0 {
+		return nil, errors.New("missing NSQD_SERVERS for producer")
+	}
+
+	config := gonsq.NewConfig()
+	producers := make([]*gonsq.Producer, 0, len(conf.ProducerNsqdServers))
+	for _, addr := range conf.ProducerNsqdServers {
+		producer, err := gonsq.NewProducer(addr, config)
+		if err != nil {
+			for _, producer := range producers {
+				producer.Stop()
+			}
+			return nil, err
+		}
+		producers = append(producers, producer)
+	}
+	return &Producer{
+		producers:  producers,
+		propagator: propagator,
+	}, nil
+}
+
+// Publish publishes v to the topic with the tracing context of ctx. v is
+// encoded as JSON and wrapped in the ProducerMessage envelope, so the
+// consumers should decode the message body by DecodeProducerMessage().
+func (p *Producer) Publish(ctx context.Context, topic string, v interface{}) error {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	carrier := make(propagation.MapCarrier)
+	p.propagator().Inject(ctx, carrier)
+
+	message, err := json.Marshal(&ProducerMessage{
+		Header: carrier,
+		Body:   body,
+	})
+	if err != nil {
+		return err
+	}
+	return p.publish(topic, message)
+}
+
+func (p *Producer) Close() {
+	for _, producer := range p.producers {
+		producer.Stop()
+	}
+}
+
+func (p *Producer) publish(topic string, body []byte) error {
+	var err error
+	for _, producer := range p.producers {
+		err = producer.Publish(topic, body)
+		if err == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+// DecodeProducerMessage decodes the body of the message published by
+// Producer to v, and returns ctx with the propagated tracing context.
+func DecodeProducerMessage(ctx context.Context, propagator propagation.TextMapPropagator, body []byte, v interface{}) (context.Context, error) {
+	var message ProducerMessage
+	if err := json.Unmarshal(body, &message); err != nil {
+		return ctx, err
+	}
+
+	ctx = propagator.Extract(ctx, propagation.MapCarrier(message.Header))
+	return ctx, json.Unmarshal(message.Body, v)
+}
+`, "”", "`")
+}
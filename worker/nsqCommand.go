@@ -0,0 +1,505 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+const (
+	NSQLOOKUPD_ADDRESS_ENV_NAME string        = "NSQLOOKUPD_ADDRESS"
+	NSQD_SERVERS_ENV_NAME       string        = "NSQD_SERVERS"
+	NSQ_CHANNEL_CONFIG_NAME     string        = "NsqChannel"
+	NSQ_MESSAGE_TAG_NAME        string        = "topic"
+	NSQ_HTTP_TIMEOUT            time.Duration = 10 * time.Second
+)
+
+var (
+	nsqHttpClient = &http.Client{Timeout: NSQ_HTTP_TIMEOUT}
+)
+
+type nsqCommandOptions struct {
+	nsqd     []string
+	lookupd  []string
+	channel  string
+	file     string
+	unpause  bool
+	all      bool
+	declared []string
+}
+
+func runTopicsCommand(args []string) error {
+	if len(args) == 0 {
+		showNsqUsage()
+		return errors.New("missing topics command")
+	}
+
+	var (
+		command = args[0]
+		opt     nsqCommandOptions
+	)
+
+	switch command {
+	case "list", "create", "delete", "pause":
+	case "help", "-h", "--help":
+		showNsqUsage()
+		return nil
+	default:
+		showNsqUsage()
+		return fmt.Errorf("unknown topics command '%s'", command)
+	}
+
+	fs := opt.flagSet("topics " + command)
+	if command == "pause" {
+		fs.BoolVar(&opt.unpause, "unpause", false, "unpause the topics instead")
+	}
+	if command == "delete" || command == "pause" {
+		fs.BoolVar(&opt.all, "all", false, "apply to all the topics declared in MessageManager")
+	}
+	topics, err := parseFlags(fs, args[1:])
+	if err != nil {
+		return err
+	}
+	if err = opt.resolve(); err != nil {
+		return err
+	}
+
+	switch command {
+	case "create":
+		// use the topics declared in MessageManager if no topic specified
+		topics, err = opt.resolveTopics(topics, true)
+	case "delete", "pause":
+		// the destructive commands require the topics explicitly
+		topics, err = opt.resolveTopics(topics, opt.all)
+	}
+	if err != nil {
+		return err
+	}
+
+	switch command {
+	case "list":
+		return nsqListTopics(&opt)
+	case "create":
+		return nsqTopicAction(&opt, "create", topics)
+	case "delete":
+		err := nsqTopicAction(&opt, "delete", topics)
+		if err != nil {
+			return err
+		}
+		// tombstone the topics on nsqlookupd as well
+		for _, lookupd := range opt.lookupd {
+			for _, topic := range topics {
+				if err := nsqPost(lookupd, "/topic/delete", url.Values{"topic": {topic}}); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	case "pause":
+		if opt.unpause {
+			return nsqTopicAction(&opt, "unpause", topics)
+		}
+		return nsqTopicAction(&opt, "pause", topics)
+	}
+	return nil
+}
+
+func runChannelsCommand(args []string) error {
+	if len(args) == 0 {
+		showNsqUsage()
+		return errors.New("missing channels command")
+	}
+
+	var (
+		command = args[0]
+		opt     nsqCommandOptions
+	)
+
+	switch command {
+	case "list", "empty":
+	case "help", "-h", "--help":
+		showNsqUsage()
+		return nil
+	default:
+		showNsqUsage()
+		return fmt.Errorf("unknown channels command '%s'", command)
+	}
+
+	fs := opt.flagSet("channels " + command)
+	fs.StringVar(&opt.channel, "channel", "", "the channel, default is NsqChannel")
+	if command == "empty" {
+		fs.BoolVar(&opt.all, "all", false, "apply to all the topics declared in MessageManager")
+	}
+	topics, err := parseFlags(fs, args[1:])
+	if err != nil {
+		return err
+	}
+	if err = opt.resolve(); err != nil {
+		return err
+	}
+
+	// the destructive commands require the topics explicitly
+	topics, err = opt.resolveTopics(topics, command == "list" || opt.all)
+	if err != nil {
+		return err
+	}
+
+	switch command {
+	case "list":
+		return nsqListChannels(&opt, topics)
+	case "empty":
+		if len(opt.channel) == 0 {
+			return fmt.Errorf("cannot find %s in config.yaml, use -channel instead", NSQ_CHANNEL_CONFIG_NAME)
+		}
+		for _, nsqd := range opt.nsqd {
+			for _, topic := range topics {
+				err := nsqPost(nsqd, "/channel/empty", url.Values{"topic": {topic}, "channel": {opt.channel}})
+				if err != nil {
+					return err
+				}
+				fmt.Fprintf(stdout, "channel '%s' of topic '%s' emptied on %s\n", opt.channel, topic, nsqd)
+			}
+		}
+	}
+	return nil
+}
+
+func (opt *nsqCommandOptions) flagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.Func("nsqd", "the nsqd HTTP address, default is derived from NSQD_SERVERS", func(s string) error {
+		opt.nsqd = append(opt.nsqd, splitList(s)...)
+		return nil
+	})
+	fs.Func("lookupd", "the nsqlookupd HTTP address, default is NSQLOOKUPD_ADDRESS", func(s string) error {
+		opt.lookupd = append(opt.lookupd, splitList(s)...)
+		return nil
+	})
+	fs.StringVar(&opt.file, "file", MESSAGE_MANAGER_FILE_NAME, "the go file declares MessageManager")
+	return fs
+}
+
+// resolveTopics returns the specified topics, or the topics declared in
+// MessageManager if no topic specified and useDeclared is true.
+func (opt *nsqCommandOptions) resolveTopics(topics []string, useDeclared bool) ([]string, error) {
+	if len(topics) > 0 {
+		if opt.all {
+			return nil, errors.New("cannot specify TOPIC with -all")
+		}
+		return topics, nil
+	}
+	if !useDeclared {
+		return nil, fmt.Errorf("no topic specified, use -all for all the topics declared in %s", MESSAGE_MANAGER_TYPE_NAME)
+	}
+	if len(opt.declared) == 0 {
+		return nil, fmt.Errorf("no topic specified and no topic declared in %s of '%s'", MESSAGE_MANAGER_TYPE_NAME, opt.file)
+	}
+	return opt.declared, nil
+}
+
+// resolve fills the options which are not specified from project settings.
+func (opt *nsqCommandOptions) resolve() error {
+	conf, err := loadProjectConfig(".")
+	if err != nil {
+		return err
+	}
+
+	if len(opt.lookupd) == 0 {
+		// e.g: nsqlookupd://127.0.0.1:4161,127.0.0.2:4161
+		address := conf.Env(NSQLOOKUPD_ADDRESS_ENV_NAME)
+		if i := strings.Index(address, "://"); i >= 0 {
+			address = address[i+3:]
+		}
+		opt.lookupd = splitList(address)
+	}
+	if len(opt.nsqd) == 0 {
+		for _, server := range splitList(conf.Env(NSQD_SERVERS_ENV_NAME)) {
+			addr, err := nsqdHttpAddress(server)
+			if err != nil {
+				return err
+			}
+			opt.nsqd = append(opt.nsqd, addr)
+		}
+	}
+	if len(opt.channel) == 0 {
+		opt.channel = conf.Yaml(NSQ_CHANNEL_CONFIG_NAME)
+	}
+	if len(opt.lookupd) == 0 && len(opt.nsqd) == 0 {
+		return fmt.Errorf("cannot find %s or %s in .env or environment variables, use -lookupd or -nsqd instead",
+			NSQLOOKUPD_ADDRESS_ENV_NAME,
+			NSQD_SERVERS_ENV_NAME)
+	}
+
+	if _, err := os.Stat(opt.file); err == nil {
+		opt.declared, err = lookupMessageManagerSources(opt.file, NSQ_MESSAGE_TAG_NAME)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// nsqdHttpAddress converts the nsqd TCP address to HTTP address following
+// the nsqd default ports, i.e: 4150 for TCP and 4151 for HTTP.
+func nsqdHttpAddress(tcpAddress string) (string, error) {
+	host, port, err := net.SplitHostPort(tcpAddress)
+	if err != nil {
+		return "", err
+	}
+	n, err := strconv.Atoi(port)
+	if err != nil {
+		return "", fmt.Errorf("invalid nsqd address '%s'", tcpAddress)
+	}
+	return net.JoinHostPort(host, strconv.Itoa(n+1)), nil
+}
+
+func nsqListTopics(opt *nsqCommandOptions) error {
+	var (
+		topics = make(map[string]bool)
+		err    error
+	)
+
+	if len(opt.lookupd) > 0 {
+		for _, lookupd := range opt.lookupd {
+			var reply struct {
+				Topics []string `json:"topics"`
+			}
+			if err = nsqGet(lookupd, "/topics", nil, &reply); err != nil {
+				return err
+			}
+			for _, topic := range reply.Topics {
+				topics[topic] = true
+			}
+		}
+	} else {
+		for _, nsqd := range opt.nsqd {
+			var reply struct {
+				Topics []struct {
+					TopicName string `json:"topic_name"`
+				} `json:"topics"`
+			}
+			if err = nsqGet(nsqd, "/stats", url.Values{"format": {"json"}}, &reply); err != nil {
+				return err
+			}
+			for _, topic := range reply.Topics {
+				topics[topic.TopicName] = true
+			}
+		}
+	}
+
+	var (
+		names    = make([]string, 0, len(topics))
+		declared = make(map[string]bool)
+	)
+	for _, topic := range opt.declared {
+		declared[topic] = true
+		if _, ok := topics[topic]; !ok {
+			topics[topic] = false
+		}
+	}
+	for topic := range topics {
+		names = append(names, topic)
+	}
+	sort.Strings(names)
+
+	w := tabwriter.NewWriter(stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "TOPIC\tPROVISIONED\tDECLARED")
+	for _, name := range names {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", name, yesOrNo(topics[name]), yesOrNo(declared[name]))
+	}
+	return w.Flush()
+}
+
+func nsqListChannels(opt *nsqCommandOptions, topics []string) error {
+	if len(opt.lookupd) == 0 {
+		return fmt.Errorf("cannot find %s in .env or environment variables, use -lookupd instead", NSQLOOKUPD_ADDRESS_ENV_NAME)
+	}
+
+	w := tabwriter.NewWriter(stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "TOPIC\tCHANNEL")
+	for _, topic := range topics {
+		channels := make(map[string]bool)
+		for _, lookupd := range opt.lookupd {
+			var reply struct {
+				Channels []string `json:"channels"`
+			}
+			if err := nsqGet(lookupd, "/channels", url.Values{"topic": {topic}}, &reply); err != nil {
+				return err
+			}
+			for _, channel := range reply.Channels {
+				channels[channel] = true
+			}
+		}
+
+		names := make([]string, 0, len(channels))
+		for channel := range channels {
+			names = append(names, channel)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Fprintf(w, "%s\t%s\n", topic, name)
+		}
+	}
+	return w.Flush()
+}
+
+func nsqTopicAction(opt *nsqCommandOptions, action string, topics []string) error {
+	if len(opt.nsqd) == 0 {
+		return fmt.Errorf("cannot find %s in .env or environment variables, use -nsqd instead", NSQD_SERVERS_ENV_NAME)
+	}
+
+	for _, nsqd := range opt.nsqd {
+		for _, topic := range topics {
+			err := nsqPost(nsqd, "/topic/"+action, url.Values{"topic": {topic}})
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(stdout, "topic '%s' %s on %s\n", topic, pastTense(action), nsqd)
+		}
+	}
+	return nil
+}
+
+func nsqGet(addr string, path string, query url.Values, v interface{}) error {
+	req, err := http.NewRequest(http.MethodGet, nsqUrl(addr, path, query), nil)
+	if err != nil {
+		return err
+	}
+	body, err := nsqDo(req)
+	if err != nil {
+		return err
+	}
+
+	// NOTE: the legacy nsqlookupd wraps the response as
+	// {"status_code":200,"status_txt":"OK","data":{...}}
+	var wrapped struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err = json.Unmarshal(body, &wrapped); err == nil && len(wrapped.Data) > 0 {
+		body = wrapped.Data
+	}
+	return json.Unmarshal(body, v)
+}
+
+func nsqPost(addr string, path string, query url.Values) error {
+	req, err := http.NewRequest(http.MethodPost, nsqUrl(addr, path, query), nil)
+	if err != nil {
+		return err
+	}
+	_, err = nsqDo(req)
+	return err
+}
+
+func nsqPublish(addr string, topic string, body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, nsqUrl(addr, "/pub", url.Values{"topic": {topic}}), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	_, err = nsqDo(req)
+	return err
+}
+
+func nsqDo(req *http.Request) ([]byte, error) {
+	// NOTE: ask for the unwrapped response of nsqd and nsqlookupd v1.0+
+	req.Header.Set("Accept", "application/vnd.nsq; version=1.0")
+
+	resp, err := nsqHttpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s %s got %d %s", req.Method, req.URL, resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return body, nil
+}
+
+func nsqUrl(addr string, path string, query url.Values) string {
+	u := url.URL{
+		Scheme:   "http",
+		Host:     addr,
+		Path:     path,
+		RawQuery: query.Encode(),
+	}
+	if strings.Contains(addr, "://") {
+		if base, err := url.Parse(addr); err == nil {
+			u.Scheme = base.Scheme
+			u.Host = base.Host
+		}
+	}
+	return u.String()
+}
+
+func pastTense(action string) string {
+	switch action {
+	case "create":
+		return "created"
+	case "delete":
+		return "deleted"
+	case "pause":
+		return "paused"
+	case "unpause":
+		return "unpaused"
+	}
+	return action
+}
+
+func yesOrNo(v bool) string {
+	if v {
+		return "yes"
+	}
+	return "no"
+}
+
+func showNsqUsage() {
+	fmt.Print(`topics USAGE:
+  worker topics COMMAND [TOPIC...] [OPTIONS...]
+
+topics COMMANDS:
+  list      list the topics registered on nsqlookupd and declared in
+            MessageManager.
+  create    create the topics on nsqd.
+  delete    delete the topics from nsqd and nsqlookupd.
+  pause     pause the topics on nsqd, use -unpause to resume.
+
+channels USAGE:
+  worker channels COMMAND [TOPIC...] [OPTIONS...]
+
+channels COMMANDS:
+  list      list the channels of the topics registered on nsqlookupd.
+  empty     empty the channel of the topics on nsqd.
+
+  NOTE: The topics declared by the 'topic' tags of MessageManager are used
+        if no TOPIC specified, except 'topics delete', 'topics pause' and
+        'channels empty' which require TOPIC or -all. The nsqlookupd and
+        nsqd addresses are read from NSQLOOKUPD_ADDRESS and NSQD_SERVERS in
+        .env, the nsqd HTTP port is the TCP port plus one; the channel is
+        read from NsqChannel in config.yaml of the current project.
+
+OPTIONS:
+  -nsqd ADDR        the nsqd HTTP addresses, separated by comma.
+  -lookupd ADDR     the nsqlookupd HTTP addresses, separated by comma.
+  -file FILE        the go file declares MessageManager, default is app.go.
+  -channel NAME     the channel for 'channels empty'.
+  -unpause          unpause the topics for 'topics pause'.
+  -all              all the topics declared in MessageManager for
+                    'topics delete', 'topics pause' and 'channels empty'.
+
+`)
+}
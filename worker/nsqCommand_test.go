@@ -0,0 +1,230 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+var (
+	_FILE_NSQ_ENV = `Environment=local
+NSQLOOKUPD_ADDRESS=nsqlookupd://%s
+NSQD_SERVERS=127.0.0.1:4150
+`
+	_FILE_NSQ_CONFIG_YAML = `
+NsqChannel: demo-channel
+`
+	_FILE_NSQ_CONFIG_LOCAL_YAML = `
+NsqHandlerConcurrency: 2
+`
+	_FILE_NSQ_APP_GO = `package main
+
+type MessageManager struct {
+	GettingStartedHandler *GettingStartedHandler ”topic:"gettingStartedTopic"”
+	OrderHandler          *OrderHandler          ”topic:"orderTopic"”
+	InvalidHandler        *InvalidHandler        ”topic:"?"”
+	DisabledHandler       *DisabledHandler       ”topic:"disabledTopic" @skip:"on"”
+}
+`
+)
+
+// fakeNsqServer records the received requests and replies the canned
+// responses keyed by URL path.
+type fakeNsqServer struct {
+	*httptest.Server
+	replies map[string]string
+
+	mutex    sync.Mutex
+	requests []string
+}
+
+func startFakeNsqServer(t *testing.T, replies map[string]string) *fakeNsqServer {
+	server := &fakeNsqServer{
+		replies: replies,
+	}
+	server.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		server.mutex.Lock()
+		server.requests = append(server.requests, r.Method+" "+r.URL.RequestURI())
+		server.mutex.Unlock()
+
+		fmt.Fprint(w, server.replies[r.URL.Path])
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func (s *fakeNsqServer) Addr() string {
+	return strings.TrimPrefix(s.URL, "http://")
+}
+
+func (s *fakeNsqServer) Requests() []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	requests := s.requests
+	s.requests = nil
+	return requests
+}
+
+func TestNsqCommand(t *testing.T) {
+	lookupd := startFakeNsqServer(t, map[string]string{
+		"/topics":   `{"topics":["orderTopic","legacyTopic"]}`,
+		"/channels": `{"status_code":200,"status_txt":"OK","data":{"channels":["demo-channel","other-channel"]}}`,
+	})
+	nsqd := startFakeNsqServer(t, map[string]string{
+		"/topic/create": "OK",
+		"/topic/pause":  "OK",
+	})
+
+	tmp := t.TempDir()
+	workdir, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.Chdir(tmp)
+	defer os.Chdir(workdir)
+
+	assertNoError(t,
+		os.WriteFile(path.Join(tmp, FILE_ENV), []byte(fmt.Sprintf(_FILE_NSQ_ENV, lookupd.Addr())), 0644),
+		os.WriteFile(path.Join(tmp, FILE_CONFIG_YAML), []byte(_FILE_NSQ_CONFIG_YAML), 0644),
+		os.WriteFile(path.Join(tmp, FILE_CONFIG_LOCAL_YAML), []byte(_FILE_NSQ_CONFIG_LOCAL_YAML), 0644),
+		os.WriteFile(path.Join(tmp, MESSAGE_MANAGER_FILE_NAME), []byte(strings.ReplaceAll(_FILE_NSQ_APP_GO, "”", "`")), 0644),
+	)
+
+	var buf bytes.Buffer
+	stdout = &buf
+	defer func() {
+		stdout = os.Stdout
+	}()
+
+	cases := []struct {
+		run              func([]string) error
+		args             []string
+		server           *fakeNsqServer
+		expectedRequests []string
+		expectedOutput   string
+	}{
+		{
+			run:              runTopicsCommand,
+			args:             []string{"list"},
+			server:           lookupd,
+			expectedRequests: []string{"GET /topics"},
+			expectedOutput: "TOPIC                PROVISIONED  DECLARED\n" +
+				"gettingStartedTopic  no           yes\n" +
+				"legacyTopic          yes          no\n" +
+				"orderTopic           yes          yes\n",
+		},
+		{
+			run:    runTopicsCommand,
+			args:   []string{"create", "-nsqd", nsqd.Addr()},
+			server: nsqd,
+			expectedRequests: []string{
+				"POST /topic/create?topic=gettingStartedTopic",
+				"POST /topic/create?topic=orderTopic",
+			},
+			expectedOutput: fmt.Sprintf("topic 'gettingStartedTopic' created on %[1]s\n"+
+				"topic 'orderTopic' created on %[1]s\n", nsqd.Addr()),
+		},
+		{
+			run:              runTopicsCommand,
+			args:             []string{"pause", "orderTopic", "-nsqd", nsqd.Addr()},
+			server:           nsqd,
+			expectedRequests: []string{"POST /topic/pause?topic=orderTopic"},
+			expectedOutput:   fmt.Sprintf("topic 'orderTopic' paused on %s\n", nsqd.Addr()),
+		},
+		{
+			run:    runTopicsCommand,
+			args:   []string{"pause", "-all", "-nsqd", nsqd.Addr()},
+			server: nsqd,
+			expectedRequests: []string{
+				"POST /topic/pause?topic=gettingStartedTopic",
+				"POST /topic/pause?topic=orderTopic",
+			},
+			expectedOutput: fmt.Sprintf("topic 'gettingStartedTopic' paused on %[1]s\n"+
+				"topic 'orderTopic' paused on %[1]s\n", nsqd.Addr()),
+		},
+		{
+			run:              runChannelsCommand,
+			args:             []string{"list", "orderTopic"},
+			server:           lookupd,
+			expectedRequests: []string{"GET /channels?topic=orderTopic"},
+			expectedOutput: "TOPIC       CHANNEL\n" +
+				"orderTopic  demo-channel\n" +
+				"orderTopic  other-channel\n",
+		},
+	}
+
+	for _, c := range cases {
+		buf.Reset()
+		err := c.run(c.args)
+		if err != nil {
+			t.Fatalf("%v: %v", c.args, err)
+		}
+
+		requests := c.server.Requests()
+		if !reflect.DeepEqual(requests, c.expectedRequests) {
+			t.Errorf("%v: requests expect %v, got %v", c.args, c.expectedRequests, requests)
+		}
+		if buf.String() != c.expectedOutput {
+			t.Errorf("%v: output expect:\n%s\ngot:\n%s\n", c.args, c.expectedOutput, buf.String())
+		}
+	}
+}
+
+func TestNsqCommand_RequireTopics(t *testing.T) {
+	server := startFakeNsqServer(t, nil)
+
+	tmp := t.TempDir()
+	workdir, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.Chdir(tmp)
+	defer os.Chdir(workdir)
+
+	assertNoError(t,
+		os.WriteFile(path.Join(tmp, FILE_ENV), []byte(fmt.Sprintf(_FILE_NSQ_ENV, server.Addr())), 0644),
+		os.WriteFile(path.Join(tmp, FILE_CONFIG_YAML), []byte(_FILE_NSQ_CONFIG_YAML), 0644),
+		os.WriteFile(path.Join(tmp, MESSAGE_MANAGER_FILE_NAME), []byte(strings.ReplaceAll(_FILE_NSQ_APP_GO, "”", "`")), 0644),
+	)
+
+	cases := []struct {
+		run  func([]string) error
+		args []string
+	}{
+		{runTopicsCommand, []string{"delete", "-nsqd", server.Addr()}},
+		{runTopicsCommand, []string{"pause", "-nsqd", server.Addr()}},
+		{runTopicsCommand, []string{"delete", "orderTopic", "-all", "-nsqd", server.Addr()}},
+		{runChannelsCommand, []string{"empty", "-nsqd", server.Addr()}},
+	}
+
+	for _, c := range cases {
+		if err := c.run(c.args); err == nil {
+			t.Errorf("%v: should get error", c.args)
+		}
+	}
+	if requests := server.Requests(); len(requests) != 0 {
+		t.Errorf("expect no request, got %v", requests)
+	}
+}
+
+func TestNsqdHttpAddress(t *testing.T) {
+	addr, err := nsqdHttpAddress("127.0.0.1:4150")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if addr != "127.0.0.1:4151" {
+		t.Errorf("expect 127.0.0.1:4151, got %s", addr)
+	}
+
+	if _, err = nsqdHttpAddress("127.0.0.1"); err == nil {
+		t.Errorf("should get error when port is missing")
+	}
+}
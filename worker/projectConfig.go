@@ -0,0 +1,127 @@
+package main
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	ENVIRONMENT_VAR_NAME string = "Environment"
+)
+
+// ProjectConfig holds the settings of a generated worker project, layered
+// the same way as the generated app.go does:
+//
+//	config.yaml < config.${Environment}.yaml < .env < environment variables
+type ProjectConfig struct {
+	dir  string
+	env  map[string]string
+	yaml map[string]string
+}
+
+func loadProjectConfig(dir string) (*ProjectConfig, error) {
+	conf := &ProjectConfig{
+		dir:  dir,
+		env:  make(map[string]string),
+		yaml: make(map[string]string),
+	}
+
+	// .env
+	if err := conf.loadEnvFile(filepath.Join(dir, FILE_ENV)); err != nil {
+		return nil, err
+	}
+
+	// config.yaml and config.${Environment}.yaml
+	if err := conf.loadYamlFile(filepath.Join(dir, FILE_CONFIG_YAML)); err != nil {
+		return nil, err
+	}
+	if environment := conf.Env(ENVIRONMENT_VAR_NAME); len(environment) > 0 {
+		filename := filepath.Join(dir, "config."+environment+".yaml")
+		if err := conf.loadYamlFile(filename); err != nil {
+			return nil, err
+		}
+	}
+	return conf, nil
+}
+
+// Env returns the environment variable specified name. The process
+// environment variables take precedence over the .env file.
+func (conf *ProjectConfig) Env(name string) string {
+	if v, ok := os.LookupEnv(name); ok {
+		return v
+	}
+	return conf.env[name]
+}
+
+// Yaml returns the top-level yaml setting specified name.
+func (conf *ProjectConfig) Yaml(name string) string {
+	return conf.yaml[name]
+}
+
+func (conf *ProjectConfig) loadEnvFile(filename string) error {
+	return readLines(filename, func(line string) {
+		line = strings.TrimSpace(line)
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			return
+		}
+		if k, v, ok := strings.Cut(line, "="); ok {
+			conf.env[strings.TrimSpace(k)] = unquote(strings.TrimSpace(v))
+		}
+	})
+}
+
+// loadYamlFile reads the top-level scalar settings of the yaml file. Nested
+// mappings and sequences are ignored since the generated config files are
+// flat.
+func (conf *ProjectConfig) loadYamlFile(filename string) error {
+	return readLines(filename, func(line string) {
+		if len(line) == 0 || line[0] == ' ' || line[0] == '\t' || line[0] == '#' || line[0] == '-' {
+			return
+		}
+		if k, v, ok := strings.Cut(line, ":"); ok {
+			if i := strings.Index(v, " #"); i >= 0 {
+				v = v[:i]
+			}
+			conf.yaml[strings.TrimSpace(k)] = unquote(strings.TrimSpace(v))
+		}
+	})
+}
+
+func readLines(filename string, fn func(line string)) error {
+	file, err := os.Open(filename)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fn(strings.TrimRight(scanner.Text(), "\r"))
+	}
+	return scanner.Err()
+}
+
+func unquote(s string) string {
+	if len(s) >= 2 {
+		if (s[0] == '"' && s[len(s)-1] == '"') || (s[0] == '\'' && s[len(s)-1] == '\'') {
+			return s[1 : len(s)-1]
+		}
+	}
+	return s
+}
+
+func splitList(s string) []string {
+	var list []string
+	for _, v := range strings.Split(s, ",") {
+		v = strings.TrimSpace(v)
+		if len(v) > 0 {
+			list = append(list, v)
+		}
+	}
+	return list
+}
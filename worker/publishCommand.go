@@ -0,0 +1,373 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+var (
+	stdin io.Reader = os.Stdin
+)
+
+type publishCommandOptions struct {
+	broker string
+	file   string
+	input  string
+	list   bool
+	replay bool
+	force  bool
+
+	// nsq
+	nsqd string
+	// redis
+	server string
+	db     int
+}
+
+// publishMessage is the message read from the input, the payload is the
+// nsq message body or the JSON object of redis stream fields.
+type publishMessage struct {
+	source  string
+	payload []byte
+}
+
+// messagePublisher publishes the messages to the broker of the project.
+type messagePublisher interface {
+	Publish(message *publishMessage) (string, error)
+	Close() error
+}
+
+func runPublishCommand(args []string) error {
+	var opt publishCommandOptions
+
+	fs := flag.NewFlagSet("publish", flag.ContinueOnError)
+	fs.StringVar(&opt.broker, "broker", "", "the broker, default is resolved from the worker package imported in app.go")
+	fs.StringVar(&opt.file, "file", MESSAGE_MANAGER_FILE_NAME, "the go file declares MessageManager")
+	fs.StringVar(&opt.input, "in", "-", "the JSON or NDJSON file contains the messages, '-' for stdin")
+	fs.BoolVar(&opt.list, "list", false, "list the streams or topics declared in MessageManager")
+	fs.BoolVar(&opt.replay, "replay", false, "replay the dead-letters captured from the dead-letter queue")
+	fs.BoolVar(&opt.force, "force", false, "publish to the stream or topic which is not declared in MessageManager")
+	fs.StringVar(&opt.nsqd, "nsqd", "", "the nsqd HTTP address, default is derived from NSQD_SERVERS")
+	fs.StringVar(&opt.server, "server", "", "the redis server address, default is the first address of REDIS_SERVER")
+	fs.IntVar(&opt.db, "db", 0, "the redis database, default is RedisDB")
+
+	if len(args) > 0 {
+		switch args[0] {
+		case "help", "-h", "--help":
+			showPublishUsage()
+			return nil
+		}
+	}
+
+	positional, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) > 1 {
+		return errors.New("publish accepts at most one SOURCE argument")
+	}
+
+	broker, err := opt.resolveBroker()
+	if err != nil {
+		return err
+	}
+
+	var declared []string
+	if _, err := os.Stat(opt.file); err == nil {
+		declared, err = lookupMessageManagerSources(opt.file, broker.MessageTagName())
+		if err != nil {
+			return err
+		}
+	}
+
+	if opt.list {
+		fmt.Fprintln(stdout, strings.ToUpper(broker.MessageTagName()))
+		for _, source := range declared {
+			fmt.Fprintln(stdout, source)
+		}
+		return nil
+	}
+
+	var source string
+	if len(positional) == 1 {
+		source = positional[0]
+	} else if !opt.replay {
+		return fmt.Errorf("publish requires the %s argument, see 'worker publish -list'", strings.ToUpper(broker.MessageTagName()))
+	}
+
+	messages, err := opt.readMessages(source)
+	if err != nil {
+		return err
+	}
+	if !opt.force {
+		for _, message := range messages {
+			if !containsString(declared, message.source) {
+				return fmt.Errorf("%s '%s' is not declared in %s of '%s', use -force instead",
+					broker.MessageTagName(),
+					message.source,
+					MESSAGE_MANAGER_TYPE_NAME,
+					opt.file)
+			}
+		}
+	}
+
+	conf, err := loadProjectConfig(".")
+	if err != nil {
+		return err
+	}
+	publisher, err := opt.createPublisher(fs, broker, conf)
+	if err != nil {
+		return err
+	}
+	defer publisher.Close()
+
+	for _, message := range messages {
+		id, err := publisher.Publish(message)
+		if err != nil {
+			return err
+		}
+		if len(id) > 0 {
+			fmt.Fprintf(stdout, "published %s to '%s'\n", id, message.source)
+		} else {
+			fmt.Fprintf(stdout, "published to '%s'\n", message.source)
+		}
+	}
+	fmt.Fprintf(stdout, "%d message(s) published\n", len(messages))
+	return nil
+}
+
+func (opt *publishCommandOptions) resolveBroker() (Broker, error) {
+	if len(opt.broker) > 0 {
+		return lookupBroker(opt.broker)
+	}
+	broker, err := lookupProjectBroker(opt.file)
+	if err != nil {
+		return nil, fmt.Errorf("%v, use -broker instead", err)
+	}
+	return broker, nil
+}
+
+func (opt *publishCommandOptions) createPublisher(fs *flag.FlagSet, broker Broker, conf *ProjectConfig) (messagePublisher, error) {
+	switch broker.(type) {
+	case *NsqBroker:
+		addr := opt.nsqd
+		if len(addr) == 0 {
+			servers := splitList(conf.Env(NSQD_SERVERS_ENV_NAME))
+			if len(servers) == 0 {
+				return nil, fmt.Errorf("cannot find %s in .env or environment variables, use -nsqd instead", NSQD_SERVERS_ENV_NAME)
+			}
+			var err error
+			addr, err = nsqdHttpAddress(servers[0])
+			if err != nil {
+				return nil, err
+			}
+		}
+		return &nsqPublisher{addr: addr}, nil
+	case *RedisBroker:
+		streamOpt := streamCommandOptions{
+			server: opt.server,
+			db:     opt.db,
+		}
+		if err := streamOpt.resolve(fs, conf); err != nil {
+			return nil, err
+		}
+		client, err := dialRedis(streamOpt.server, streamOpt.db)
+		if err != nil {
+			return nil, err
+		}
+		return &redisPublisher{client: client}, nil
+	}
+	return nil, fmt.Errorf("publish does not support broker '%s'", broker.Name())
+}
+
+func (opt *publishCommandOptions) readMessages(source string) ([]*publishMessage, error) {
+	var reader io.Reader = stdin
+	if opt.input != "-" {
+		file, err := os.Open(opt.input)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		reader = file
+	}
+
+	values, err := readJsonValues(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	messages := make([]*publishMessage, 0, len(values))
+	for _, value := range values {
+		message := &publishMessage{
+			source: source,
+		}
+		if opt.replay {
+			// the dead-letter published by the --dead-letter option
+			var letter struct {
+				Source  string `json:"source"`
+				Payload string `json:"payload"`
+			}
+			if err := json.Unmarshal(value, &letter); err != nil {
+				return nil, fmt.Errorf("invalid dead-letter %s: %v", value, err)
+			}
+			if len(message.source) == 0 {
+				message.source = letter.Source
+			}
+			message.payload = []byte(letter.Payload)
+		} else {
+			// the JSON string is published as is, others are published as JSON
+			var text string
+			if err := json.Unmarshal(value, &text); err == nil {
+				message.payload = []byte(text)
+			} else {
+				var buf bytes.Buffer
+				if err := json.Compact(&buf, value); err != nil {
+					return nil, err
+				}
+				message.payload = buf.Bytes()
+			}
+		}
+		if len(message.source) == 0 {
+			return nil, fmt.Errorf("cannot resolve the source of message %s", value)
+		}
+		messages = append(messages, message)
+	}
+	return messages, nil
+}
+
+// readJsonValues reads a JSON array, a single JSON value or NDJSON.
+func readJsonValues(reader io.Reader) ([]json.RawMessage, error) {
+	var (
+		values  []json.RawMessage
+		decoder = json.NewDecoder(bufio.NewReader(reader))
+	)
+
+	for {
+		var value json.RawMessage
+		err := decoder.Decode(&value)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if first := bytes.TrimSpace(value); len(first) > 0 && first[0] == '[' {
+			var array []json.RawMessage
+			if err := json.Unmarshal(value, &array); err != nil {
+				return nil, err
+			}
+			values = append(values, array...)
+			continue
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
+
+type nsqPublisher struct {
+	addr string
+}
+
+func (p *nsqPublisher) Publish(message *publishMessage) (string, error) {
+	return "", nsqPublish(p.addr, message.source, message.payload)
+}
+
+func (p *nsqPublisher) Close() error {
+	return nil
+}
+
+type redisPublisher struct {
+	client *RedisClient
+}
+
+func (p *redisPublisher) Publish(message *publishMessage) (string, error) {
+	var fields map[string]interface{}
+
+	decoder := json.NewDecoder(bytes.NewReader(message.payload))
+	decoder.UseNumber()
+	if err := decoder.Decode(&fields); err != nil {
+		return "", fmt.Errorf("redis stream message should be a JSON object, got %s", message.payload)
+	}
+
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	args := []string{"XADD", message.source, "*"}
+	for _, key := range keys {
+		var value string
+		switch v := fields[key].(type) {
+		case string:
+			value = v
+		case json.Number:
+			value = v.String()
+		default:
+			buf, err := json.Marshal(v)
+			if err != nil {
+				return "", err
+			}
+			value = string(buf)
+		}
+		args = append(args, key, value)
+	}
+
+	reply, err := p.client.Do(args...)
+	if err != nil {
+		return "", err
+	}
+	return redisString(reply), nil
+}
+
+func (p *redisPublisher) Close() error {
+	return p.client.Close()
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func showPublishUsage() {
+	fmt.Print(`publish USAGE:
+  worker publish [SOURCE] [OPTIONS...]
+
+  Publish the messages read from a JSON array, JSON values or NDJSON to
+  the stream (redis) or topic (nsq) SOURCE. The SOURCE should be declared
+  by the tags of MessageManager. A JSON string is published as is, other
+  values are published as JSON; a redis message should be a JSON object
+  of the stream fields.
+
+  NOTE: The broker is resolved from the worker package imported in app.go.
+        The nsqd address is read from NSQD_SERVERS in .env; the redis
+        server and database are read from REDIS_SERVER in .env and RedisDB
+        in config.yaml of the current project.
+
+publish OPTIONS:
+  -list             list the streams or topics declared in MessageManager.
+  -in FILE          the file contains the messages, default is stdin.
+  -replay           replay the dead-letters captured from the dead-letter
+                    queue to their source, or to SOURCE if specified.
+  -force            publish even if SOURCE is not declared.
+  -file FILE        the go file declares MessageManager, default is app.go.
+  -broker BROKER    the broker, should be one of nsq|redis.
+  -nsqd ADDR        the nsqd HTTP address.
+  -server ADDR      the redis server address.
+  -db DB            the redis database.
+
+`)
+}
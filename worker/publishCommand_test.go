@@ -0,0 +1,172 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var (
+	_FILE_PUBLISH_REDIS_APP_GO = `package main
+
+import (
+	redis "github.com/Bofry/worker-redis"
+)
+
+type MessageManager struct {
+	GettingStartedHandler *GettingStartedHandler ”stream:"gettingStartedStream" offset:"$"”
+	InvalidHandler        *InvalidHandler        ”stream:"?"”
+}
+`
+	_FILE_PUBLISH_NSQ_APP_GO = `package main
+
+import (
+	nsq "github.com/Bofry/worker-nsq"
+)
+
+type MessageManager struct {
+	GettingStartedHandler *GettingStartedHandler ”topic:"gettingStartedTopic"”
+}
+`
+)
+
+func TestPublishCommand_Redis(t *testing.T) {
+	server := startFakeRedisServer(t, map[string]string{
+		"SELECT": "+OK\r\n",
+		"XADD":   "$15\r\n1700000000000-0\r\n",
+	})
+
+	tmp := t.TempDir()
+	workdir, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.Chdir(tmp)
+	defer os.Chdir(workdir)
+
+	assertNoError(t,
+		os.WriteFile(path.Join(tmp, FILE_ENV), []byte(fmt.Sprintf(_FILE_STREAM_ENV, server.listener.Addr().String())), 0644),
+		os.WriteFile(path.Join(tmp, FILE_CONFIG_YAML), []byte(_FILE_STREAM_CONFIG_YAML), 0644),
+		os.WriteFile(path.Join(tmp, FILE_CONFIG_LOCAL_YAML), []byte(_FILE_STREAM_CONFIG_LOCAL_YAML), 0644),
+		os.WriteFile(path.Join(tmp, MESSAGE_MANAGER_FILE_NAME), []byte(strings.ReplaceAll(_FILE_PUBLISH_REDIS_APP_GO, "”", "`")), 0644),
+	)
+
+	var buf bytes.Buffer
+	stdout = &buf
+	defer func() {
+		stdout = os.Stdout
+		stdin = os.Stdin
+	}()
+
+	// list
+	err = runPublishCommand([]string{"-list"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedOutput := "STREAM\ngettingStartedStream\n"
+	if buf.String() != expectedOutput {
+		t.Errorf("output expect:\n%s\ngot:\n%s\n", expectedOutput, buf.String())
+	}
+
+	// publish NDJSON
+	buf.Reset()
+	stdin = strings.NewReader(`{"name":"luffy","age":19}` + "\n" + `{"name":"zoro","tags":["sword"]}` + "\n")
+	err = runPublishCommand([]string{"gettingStartedStream"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	commands := server.Commands()
+	expectedCommands := [][]string{
+		{"SELECT", "3"},
+		{"XADD", "gettingStartedStream", "*", "age", "19", "name", "luffy"},
+		{"XADD", "gettingStartedStream", "*", "name", "zoro", "tags", `["sword"]`},
+	}
+	if !reflect.DeepEqual(commands, expectedCommands) {
+		t.Errorf("commands expect %v, got %v", expectedCommands, commands)
+	}
+	expectedOutput = "published 1700000000000-0 to 'gettingStartedStream'\n" +
+		"published 1700000000000-0 to 'gettingStartedStream'\n" +
+		"2 message(s) published\n"
+	if buf.String() != expectedOutput {
+		t.Errorf("output expect:\n%s\ngot:\n%s\n", expectedOutput, buf.String())
+	}
+
+	// undeclared stream
+	stdin = strings.NewReader(`{"name":"luffy"}`)
+	err = runPublishCommand([]string{"unknownStream"})
+	if err == nil {
+		t.Errorf("should get error when the stream is not declared")
+	}
+}
+
+func TestPublishCommand_Nsq(t *testing.T) {
+	nsqd := startFakeNsqServer(t, map[string]string{
+		"/pub": "OK",
+	})
+
+	tmp := t.TempDir()
+	workdir, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.Chdir(tmp)
+	defer os.Chdir(workdir)
+
+	assertNoError(t,
+		os.WriteFile(path.Join(tmp, MESSAGE_MANAGER_FILE_NAME), []byte(strings.ReplaceAll(_FILE_PUBLISH_NSQ_APP_GO, "”", "`")), 0644),
+		os.WriteFile(path.Join(tmp, "letters.json"), []byte(`[
+	{"id":"0a1b","source":"gettingStartedTopic","payload":"{\"name\":\"luffy\"}","attempts":3,"error":"boom"}
+]`), 0644),
+	)
+
+	var buf bytes.Buffer
+	stdout = &buf
+	defer func() {
+		stdout = os.Stdout
+	}()
+
+	err = runPublishCommand([]string{"-replay", "-in", "letters.json", "-nsqd", nsqd.Addr()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	requests := nsqd.Requests()
+	expectedRequests := []string{"POST /pub?topic=gettingStartedTopic"}
+	if !reflect.DeepEqual(requests, expectedRequests) {
+		t.Errorf("requests expect %v, got %v", expectedRequests, requests)
+	}
+	expectedOutput := "published to 'gettingStartedTopic'\n" +
+		"1 message(s) published\n"
+	if buf.String() != expectedOutput {
+		t.Errorf("output expect:\n%s\ngot:\n%s\n", expectedOutput, buf.String())
+	}
+}
+
+func TestReadJsonValues(t *testing.T) {
+	cases := []struct {
+		input    string
+		expected []string
+	}{
+		{`{"a":1}`, []string{`{"a":1}`}},
+		{"{\"a\":1}\n{\"a\":2}\n", []string{`{"a":1}`, `{"a":2}`}},
+		{`[{"a":1}, "text"]`, []string{`{"a":1}`, `"text"`}},
+		{``, nil},
+	}
+
+	for _, c := range cases {
+		values, err := readJsonValues(strings.NewReader(c.input))
+		if err != nil {
+			t.Fatalf("%q: %v", c.input, err)
+		}
+		var got []string
+		for _, v := range values {
+			got = append(got, string(v))
+		}
+		if !reflect.DeepEqual(got, c.expected) {
+			t.Errorf("%q: expect %v, got %v", c.input, c.expected, got)
+		}
+	}
+}
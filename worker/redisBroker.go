@@ -0,0 +1,533 @@
+package main
+
+import "strings"
+
+var (
+	_ DeadLetterBroker = new(RedisBroker)
+	_ TestingBroker    = new(RedisBroker)
+	_ ProducerBroker   = new(RedisBroker)
+)
+
+func init() {
+	registerBroker(new(RedisBroker))
+}
+
+type RedisBroker struct{}
+
+func (*RedisBroker) Name() string           { return "redis" }
+func (*RedisBroker) PackagePath() string    { return "github.com/Bofry/worker-redis" }
+func (*RedisBroker) PackageAlias() string   { return "redis" }
+func (*RedisBroker) Imports() []string      { return []string{"time"} }
+func (*RedisBroker) MessageTagName() string { return "stream" }
+
+func (*RedisBroker) EnvTemplate() string {
+	return `REDIS_SERVER=127.0.0.1:6379
+`
+}
+
+func (*RedisBroker) EnvSampleTemplate() string {
+	return `REDIS_SERVER=127.0.0.1:6379,127.0.0.2:6379
+`
+}
+
+func (*RedisBroker) ConfigYamlTemplate() string {
+	return `RedisDB: 0
+RedisConsumerGroup: default
+RedisConsumerName: {{.AppModuleName}}
+RedisMaxInFlight: 8
+RedisMaxPollingTimeout: 10ms
+RedisClaimMinIdleTime: 30s
+RedisIdlingTimeout: 150ms
+RedisClaimSensitivity: 2
+RedisClaimOccurrenceRate: 2
+`
+}
+
+func (*RedisBroker) ConfigLocalYamlTemplate() string {
+	return `RedisDB: 0
+`
+}
+
+func (*RedisBroker) ConfigFieldsTemplate() string {
+	return strings.ReplaceAll(`		// redis
+		RedisAddresses           []string      ”env:"*REDIS_SERVER"        yaml:"-"”
+		RedisDB                  int           ”env:"-"                    yaml:"RedisDB"”
+		RedisConsumerGroup       string        ”env:"-"                    yaml:"RedisConsumerGroup"”
+		RedisConsumerName        string        ”env:"-"                    yaml:"RedisConsumerName"”
+		RedisMaxInFlight         int64         ”env:"-"                    yaml:"RedisMaxInFlight"”
+		RedisMaxPollingTimeout   time.Duration ”env:"-"                    yaml:"RedisMaxPollingTimeout"”
+		RedisClaimMinIdleTime    time.Duration ”env:"-"                    yaml:"RedisClaimMinIdleTime"”
+		RedisIdlingTimeout       time.Duration ”env:"-"                    yaml:"RedisIdlingTimeout"”
+		RedisClaimSensitivity    int           ”env:"-"                    yaml:"RedisClaimSensitivity"”
+		RedisClaimOccurrenceRate int32         ”env:"-"                    yaml:"RedisClaimOccurrenceRate"”
+`, "”", "`")
+}
+
+func (*RedisBroker) HostInitTemplate() string {
+	return `	h.RedisOption = &redis.UniversalOptions{
+		Addrs: conf.RedisAddresses,
+		DB:    conf.RedisDB,
+	}
+	h.ConsumerGroup = conf.RedisConsumerGroup
+	h.ConsumerName = conf.RedisConsumerName
+	h.MaxInFlight = conf.RedisMaxInFlight
+	h.MaxPollingTimeout = conf.RedisMaxPollingTimeout
+	h.ClaimMinIdleTime = conf.RedisClaimMinIdleTime
+	h.IdlingTimeout = conf.RedisIdlingTimeout
+	h.ClaimSensitivity = conf.RedisClaimSensitivity
+	h.ClaimOccurrenceRate = conf.RedisClaimOccurrenceRate
+	h.AllowCreateGroup = true
+`
+}
+
+func (*RedisBroker) MessageManagerTemplate() string {
+	return strings.ReplaceAll(`	// *XxxMessageHandler     ”stream:"XxxStream"   offset:"$"   @ExpandEnv:"off"”
+	// *InvalidMessageHandler ”stream:"?"”
+`, "”", "`")
+}
+
+func (*RedisBroker) HealthTemplate() string {
+	return `package internal
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"time"
+)
+
+// checkConsumer returns nil if RedisConsumerName has joined
+// RedisConsumerGroup of all the streams, by XINFO CONSUMERS.
+func checkConsumer(conf *Config, streams []string) error {
+	conn, err := dialRedisHealth(conf)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	reader := bufio.NewReader(conn)
+	for _, stream := range streams {
+		reply, err := doRedisHealth(conn, reader, "XINFO", "CONSUMERS", stream, conf.RedisConsumerGroup)
+		if err != nil {
+			return fmt.Errorf("consumer group '%s' of stream '%s': %v", conf.RedisConsumerGroup, stream, err)
+		}
+
+		consumers, _ := reply.([]interface{})
+		if !hasRedisConsumer(consumers, conf.RedisConsumerName) {
+			return fmt.Errorf("consumer '%s' has not joined consumer group '%s' of stream '%s'", conf.RedisConsumerName, conf.RedisConsumerGroup, stream)
+		}
+	}
+	return nil
+}
+
+func dialRedisHealth(conf *Config) (net.Conn, error) {
+	var lastErr error = errors.New("no redis address")
+	for _, addr := range conf.RedisAddresses {
+		conn, err := net.DialTimeout("tcp", addr, healthDialTimeout)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		conn.SetDeadline(time.Now().Add(healthDialTimeout))
+
+		if conf.RedisDB != 0 {
+			_, err = doRedisHealth(conn, bufio.NewReader(conn), "SELECT", strconv.Itoa(conf.RedisDB))
+			if err != nil {
+				conn.Close()
+				return nil, err
+			}
+		}
+		return conn, nil
+	}
+	return nil, fmt.Errorf("redis is unreachable: %v", lastErr)
+}
+
+// hasRedisConsumer reports whether the XINFO CONSUMERS reply contains the
+// consumer name.
+func hasRedisConsumer(consumers []interface{}, name string) bool {
+	for _, consumer := range consumers {
+		fields, _ := consumer.([]interface{})
+		for i := 0; i+1 < len(fields); i += 2 {
+			if fields[i] == "name" && fields[i+1] == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// doRedisHealth sends the command in RESP and returns the reply, which is
+// one of string, int64, []interface{} or nil.
+func doRedisHealth(conn net.Conn, reader *bufio.Reader, args ...string) (interface{}, error) {
+	buf := []byte("*" + strconv.Itoa(len(args)) + "\r\n")
+	for _, arg := range args {
+		buf = append(buf, "$"+strconv.Itoa(len(arg))+"\r\n"+arg+"\r\n"...)
+	}
+	if _, err := conn.Write(buf); err != nil {
+		return nil, err
+	}
+	return readRedisHealthReply(reader)
+}
+
+func readRedisHealthReply(reader *bufio.Reader) (interface{}, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if len(line) < 3 {
+		return nil, fmt.Errorf("invalid redis reply %q", line)
+	}
+	line = line[:len(line)-2]
+
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, errors.New(line[1:])
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		data := make([]byte, n+2)
+		if _, err = io.ReadFull(reader, data); err != nil {
+			return nil, err
+		}
+		return string(data[:n]), nil
+	case '*':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		values := make([]interface{}, n)
+		for i := range values {
+			if values[i], err = readRedisHealthReply(reader); err != nil {
+				return nil, err
+			}
+		}
+		return values, nil
+	}
+	return nil, fmt.Errorf("invalid redis reply %q", line)
+}
+`
+}
+
+func (*RedisBroker) DeadLetterConfigYamlTemplate() string {
+	return `DeadLetterStream: {{.AppExeName}}-dlq
+DeadLetterMaxAttempts: 3
+`
+}
+
+func (*RedisBroker) DeadLetterConfigFieldsTemplate() string {
+	return strings.ReplaceAll(`		// dead-letter
+		DeadLetterStream      string ”env:"-"   yaml:"DeadLetterStream"”
+		DeadLetterMaxAttempts int    ”env:"-"   yaml:"DeadLetterMaxAttempts"”
+`, "”", "`")
+}
+
+func (*RedisBroker) DeadLetterProducerTemplate() string {
+	return `package internal
+
+import (
+	"encoding/json"
+	"time"
+
+	libredis "github.com/Bofry/lib-redis-stream"
+	redis "github.com/Bofry/worker-redis"
+)
+
+type deadLetterProducer struct {
+	stream   string
+	producer *libredis.Producer
+}
+
+func newDeadLetterProducer(conf *Config, sp *ServiceProvider) (*deadLetterProducer, error) {
+{{- if .Producer}}
+	// share the producer of ServiceProvider, which closes it
+	return &deadLetterProducer{
+		stream:   conf.DeadLetterStream,
+		producer: sp.Producer.producer,
+	}, nil
+{{- else}}
+	producer, err := libredis.NewProducer(&libredis.ProducerConfig{
+		UniversalOptions: &libredis.UniversalOptions{
+			Addrs: conf.RedisAddresses,
+			DB:    conf.RedisDB,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &deadLetterProducer{
+		stream:   conf.DeadLetterStream,
+		producer: producer,
+	}, nil
+{{- end}}
+}
+
+func (p *deadLetterProducer) Publish(letter *DeadLetter) error {
+	_, err := p.producer.Write(p.stream, libredis.AutoIncrement, map[string]interface{}{
+		"id":        letter.ID,
+		"source":    letter.Source,
+		"payload":   letter.Payload,
+		"attempts":  letter.Attempts,
+		"error":     letter.Error,
+		"timestamp": letter.Timestamp.Format(time.RFC3339Nano),
+	})
+	return err
+}
+
+func (p *deadLetterProducer) Close() {
+{{- if .Producer}}
+	// the shared producer is closed by ServiceProvider.Producer
+{{- else}}
+	p.producer.Close()
+{{- end}}
+}
+
+func newDeadLetter(message *redis.Message) *DeadLetter {
+	payload, _ := json.Marshal(message.Values)
+	return &DeadLetter{
+		ID:      messageID(message),
+		Source:  message.Stream,
+		Payload: string(payload),
+	}
+}
+
+func messageID(message *redis.Message) string {
+	return message.ID
+}
+
+func retryMessage(message *redis.Message) {
+	// keep the message pending, it will be claimed again after
+	// RedisClaimMinIdleTime
+}
+
+func discardMessage(message *redis.Message) {
+	message.Ack()
+	message.Del()
+}
+`
+}
+
+func (*RedisBroker) TestingTemplate() string {
+	return `package testing
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Bofry/trace"
+	redis "github.com/Bofry/worker-redis"
+)
+
+var (
+	_ redis.MessageDelegate = new(MessageRecorder)
+
+	configureTracerProviderOnce sync.Once
+	sequence                    int64
+)
+
+// NewContext returns a fake *redis.Context carrying a noop tracer.
+func NewContext() *redis.Context {
+	configureTracerProviderOnce.Do(func() {
+		tp, _ := trace.NoopProvider()
+		trace.SetTracerProvider(tp)
+	})
+
+	return &redis.Context{
+		Context: context.Background(),
+	}
+}
+
+// MessageBuilder builds the fake *redis.Message.
+type MessageBuilder struct {
+	stream string
+	group  string
+	id     string
+	values map[string]interface{}
+}
+
+func NewMessage(stream string) *MessageBuilder {
+	return &MessageBuilder{
+		stream: stream,
+		group:  "testing",
+		values: make(map[string]interface{}),
+	}
+}
+
+func (b *MessageBuilder) ID(id string) *MessageBuilder {
+	b.id = id
+	return b
+}
+
+func (b *MessageBuilder) ConsumerGroup(group string) *MessageBuilder {
+	b.group = group
+	return b
+}
+
+func (b *MessageBuilder) Value(name string, value interface{}) *MessageBuilder {
+	b.values[name] = value
+	return b
+}
+
+func (b *MessageBuilder) Values(values map[string]interface{}) *MessageBuilder {
+	for name, value := range values {
+		b.values[name] = value
+	}
+	return b
+}
+
+// Build returns the message and the recorder of its responses.
+func (b *MessageBuilder) Build() (*redis.Message, *MessageRecorder) {
+	id := b.id
+	if len(id) == 0 {
+		id = fmt.Sprintf("%d-%d", time.Now().UnixMilli(), atomic.AddInt64(&sequence, 1))
+	}
+
+	recorder := new(MessageRecorder)
+
+	return &redis.Message{
+		XMessage: &redis.XMessage{
+			ID:     id,
+			Values: b.values,
+		},
+		ConsumerGroup: b.group,
+		Stream:        b.stream,
+		Delegate:      recorder,
+	}, recorder
+}
+
+// MessageRecorder records the Ack() and Del() calls of the message.
+type MessageRecorder struct {
+	mutex   sync.Mutex
+	acked   int
+	deleted int
+}
+
+// OnAck implements redis.MessageDelegate.
+func (r *MessageRecorder) OnAck(msg *redis.Message) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.acked++
+}
+
+// OnDel implements redis.MessageDelegate.
+func (r *MessageRecorder) OnDel(msg *redis.Message) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.deleted++
+}
+
+func (r *MessageRecorder) AssertAcked(t testing.TB) {
+	t.Helper()
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.acked == 0 {
+		t.Errorf("message should be acked")
+	}
+}
+
+func (r *MessageRecorder) AssertNotAcked(t testing.TB) {
+	t.Helper()
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.acked > 0 {
+		t.Errorf("message should not be acked, but acked %d time(s)", r.acked)
+	}
+}
+
+func (r *MessageRecorder) AssertDeleted(t testing.TB) {
+	t.Helper()
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.deleted == 0 {
+		t.Errorf("message should be deleted")
+	}
+}
+
+func (r *MessageRecorder) AssertNotDeleted(t testing.TB) {
+	t.Helper()
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.deleted > 0 {
+		t.Errorf("message should not be deleted, but deleted %d time(s)", r.deleted)
+	}
+}
+`
+}
+
+func (*RedisBroker) ProducerConfigFieldsTemplate() string {
+	// the producer shares RedisAddresses and RedisDB
+	return ``
+}
+
+func (*RedisBroker) ProducerTemplate() string {
+	return `package internal
+
+import (
+	"context"
+
+	libredis "github.com/Bofry/lib-redis-stream"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// Producer publishes the messages to the redis streams.
+type Producer struct {
+	producer   *libredis.Producer
+	propagator func() propagation.TextMapPropagator
+}
+
+func NewProducer(conf *Config, propagator func() propagation.TextMapPropagator) (*Producer, error) {
+	producer, err := libredis.NewProducer(&libredis.ProducerConfig{
+		UniversalOptions: &libredis.UniversalOptions{
+			Addrs: conf.RedisAddresses,
+			DB:    conf.RedisDB,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &Producer{
+		producer:   producer,
+		propagator: propagator,
+	}, nil
+}
+
+// Publish appends the values to the stream with the tracing context of ctx,
+// which is carried by the extra stream fields, e.g: traceparent. It returns
+// the id of the new entry.
+func (p *Producer) Publish(ctx context.Context, stream string, values map[string]interface{}) (string, error) {
+	carrier := make(propagation.MapCarrier)
+	p.propagator().Inject(ctx, carrier)
+
+	fields := make(map[string]interface{}, len(values)+len(carrier))
+	for k, v := range values {
+		fields[k] = v
+	}
+	for k, v := range carrier {
+		fields[k] = v
+	}
+	return p.producer.Write(stream, libredis.AutoIncrement, fields)
+}
+
+func (p *Producer) Close() {
+	p.producer.Close()
+}
+`
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"time"
+)
+
+const (
+	REDIS_DIAL_TIMEOUT time.Duration = 5 * time.Second
+)
+
+// RedisError represents an error reply of redis server.
+type RedisError string
+
+func (e RedisError) Error() string { return string(e) }
+
+// RedisClient is a minimal RESP client for the administrative commands of
+// the worker tool.
+type RedisClient struct {
+	conn   net.Conn
+	reader *bufio.Reader
+	writer *bufio.Writer
+}
+
+func dialRedis(addr string, db int) (*RedisClient, error) {
+	conn, err := net.DialTimeout("tcp", addr, REDIS_DIAL_TIMEOUT)
+	if err != nil {
+		return nil, err
+	}
+
+	client := &RedisClient{
+		conn:   conn,
+		reader: bufio.NewReader(conn),
+		writer: bufio.NewWriter(conn),
+	}
+
+	if db != 0 {
+		if _, err = client.Do("SELECT", strconv.Itoa(db)); err != nil {
+			client.Close()
+			return nil, err
+		}
+	}
+	return client, nil
+}
+
+func (c *RedisClient) Close() error {
+	return c.conn.Close()
+}
+
+// Do sends the command and returns the reply. The reply is one of string,
+// int64, []interface{}, nil or RedisError.
+func (c *RedisClient) Do(args ...string) (interface{}, error) {
+	fmt.Fprintf(c.writer, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(c.writer, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	if err := c.writer.Flush(); err != nil {
+		return nil, err
+	}
+
+	reply, err := readRedisReply(c.reader)
+	if err != nil {
+		return nil, err
+	}
+	if e, ok := reply.(RedisError); ok {
+		return nil, e
+	}
+	return reply, nil
+}
+
+func readRedisReply(reader *bufio.Reader) (interface{}, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if len(line) < 3 || line[len(line)-2] != '\r' {
+		return nil, fmt.Errorf("invalid redis reply %q", line)
+	}
+	line = line[:len(line)-2]
+
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return RedisError(line[1:]), nil
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		size, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, err
+		}
+		if size < 0 {
+			return nil, nil
+		}
+		buf := make([]byte, size+2)
+		if _, err = io.ReadFull(reader, buf); err != nil {
+			return nil, err
+		}
+		return string(buf[:size]), nil
+	case '*':
+		size, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, err
+		}
+		if size < 0 {
+			return nil, nil
+		}
+		array := make([]interface{}, size)
+		for i := 0; i < size; i++ {
+			array[i], err = readRedisReply(reader)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return array, nil
+	}
+	return nil, fmt.Errorf("unsupported redis reply type %q", line[0])
+}
+
+func redisString(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case nil:
+		return ""
+	}
+	return fmt.Sprint(v)
+}
+
+func redisArray(v interface{}) ([]interface{}, error) {
+	if v == nil {
+		return nil, nil
+	}
+	array, ok := v.([]interface{})
+	if !ok {
+		return nil, errors.New("unexpected redis reply, should be an array")
+	}
+	return array, nil
+}
+
+// redisMap converts the flat key-value array reply, e.g: XINFO GROUPS, to map.
+func redisMap(v interface{}) (map[string]interface{}, error) {
+	array, err := redisArray(v)
+	if err != nil {
+		return nil, err
+	}
+	m := make(map[string]interface{}, len(array)/2)
+	for i := 0; i+1 < len(array); i += 2 {
+		m[redisString(array[i])] = array[i+1]
+	}
+	return m, nil
+}
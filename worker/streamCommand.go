@@ -0,0 +1,409 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+const (
+	REDIS_SERVER_ENV_NAME                 string = "REDIS_SERVER"
+	REDIS_DB_CONFIG_NAME                  string = "RedisDB"
+	REDIS_CONSUMER_GROUP_CONFIG_NAME      string = "RedisConsumerGroup"
+	REDIS_CONSUMER_NAME_CONFIG_NAME       string = "RedisConsumerName"
+	REDIS_CLAIM_MIN_IDLE_TIME_CONFIG_NAME string = "RedisClaimMinIdleTime"
+
+	DEFAULT_REDIS_CLAIM_MIN_IDLE_TIME time.Duration = 30 * time.Second
+)
+
+var (
+	stdout io.Writer = os.Stdout
+)
+
+type streamCommandOptions struct {
+	server   string
+	db       int
+	group    string
+	consumer string
+	minIdle  time.Duration
+	count    int64
+	maxlen   int64
+	approx   bool
+	id       string
+	mkstream bool
+}
+
+func runStreamCommand(args []string) error {
+	if len(args) == 0 {
+		showStreamUsage()
+		return errors.New("missing stream command")
+	}
+
+	var (
+		command = args[0]
+		opt     streamCommandOptions
+	)
+
+	conf, err := loadProjectConfig(".")
+	if err != nil {
+		return err
+	}
+
+	fs := flag.NewFlagSet("stream "+command, flag.ContinueOnError)
+	fs.StringVar(&opt.server, "server", "", "the redis server address, default is the first address of REDIS_SERVER")
+	fs.IntVar(&opt.db, "db", 0, "the redis database, default is RedisDB")
+	fs.StringVar(&opt.group, "group", "", "the consumer group, default is RedisConsumerGroup")
+
+	switch command {
+	case "groups", "trim", "create-group", "pending", "claim":
+	case "help", "-h", "--help":
+		showStreamUsage()
+		return nil
+	default:
+		showStreamUsage()
+		return fmt.Errorf("unknown stream command '%s'", command)
+	}
+
+	switch command {
+	case "pending":
+		fs.Int64Var(&opt.count, "count", 0, "list the pending messages up to the count instead of the summary")
+	case "claim":
+		fs.StringVar(&opt.consumer, "consumer", "", "the consumer claims the messages, default is RedisConsumerName")
+		fs.DurationVar(&opt.minIdle, "min-idle", 0, "the minimum idle time of the messages to claim, default is RedisClaimMinIdleTime")
+		fs.Int64Var(&opt.count, "count", 100, "the maximum number of the messages to claim")
+	case "trim":
+		fs.Int64Var(&opt.maxlen, "maxlen", -1, "the maximum length of the stream")
+		fs.BoolVar(&opt.approx, "approx", false, "trim the stream approximately with '~'")
+	case "create-group":
+		fs.StringVar(&opt.id, "id", "$", "the last delivered id of the new consumer group")
+		fs.BoolVar(&opt.mkstream, "mkstream", false, "create the stream if it does not exist")
+	}
+
+	positional, err := parseFlags(fs, args[1:])
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return fmt.Errorf("stream %s requires exactly one STREAM argument", command)
+	}
+	stream := positional[0]
+
+	if err = opt.resolve(fs, conf); err != nil {
+		return err
+	}
+
+	client, err := dialRedis(opt.server, opt.db)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	switch command {
+	case "groups":
+		return streamGroups(client, stream)
+	case "pending":
+		return streamPending(client, stream, &opt)
+	case "claim":
+		return streamClaim(client, stream, &opt)
+	case "trim":
+		return streamTrim(client, stream, &opt)
+	case "create-group":
+		return streamCreateGroup(client, stream, &opt)
+	}
+	return nil
+}
+
+// resolve fills the options which are not specified from project settings.
+func (opt *streamCommandOptions) resolve(fs *flag.FlagSet, conf *ProjectConfig) error {
+	var specified = make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) {
+		specified[f.Name] = true
+	})
+
+	if len(opt.server) == 0 {
+		servers := splitList(conf.Env(REDIS_SERVER_ENV_NAME))
+		if len(servers) == 0 {
+			return fmt.Errorf("cannot find %s in .env or environment variables, use -server instead", REDIS_SERVER_ENV_NAME)
+		}
+		opt.server = servers[0]
+	}
+	if !specified["db"] {
+		if v := conf.Yaml(REDIS_DB_CONFIG_NAME); len(v) > 0 {
+			db, err := strconv.Atoi(v)
+			if err != nil {
+				return fmt.Errorf("invalid %s '%s'", REDIS_DB_CONFIG_NAME, v)
+			}
+			opt.db = db
+		}
+	}
+	if len(opt.group) == 0 {
+		opt.group = conf.Yaml(REDIS_CONSUMER_GROUP_CONFIG_NAME)
+	}
+	if fs.Lookup("consumer") != nil && len(opt.consumer) == 0 {
+		opt.consumer = conf.Yaml(REDIS_CONSUMER_NAME_CONFIG_NAME)
+	}
+	if fs.Lookup("min-idle") != nil && !specified["min-idle"] {
+		opt.minIdle = DEFAULT_REDIS_CLAIM_MIN_IDLE_TIME
+		if v := conf.Yaml(REDIS_CLAIM_MIN_IDLE_TIME_CONFIG_NAME); len(v) > 0 {
+			d, err := time.ParseDuration(v)
+			if err != nil {
+				return fmt.Errorf("invalid %s '%s'", REDIS_CLAIM_MIN_IDLE_TIME_CONFIG_NAME, v)
+			}
+			opt.minIdle = d
+		}
+	}
+	return nil
+}
+
+func streamGroups(client *RedisClient, stream string) error {
+	reply, err := client.Do("XINFO", "GROUPS", stream)
+	if err != nil {
+		return err
+	}
+	groups, err := redisArray(reply)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "GROUP\tCONSUMERS\tPENDING\tLAST-DELIVERED-ID")
+	for _, v := range groups {
+		group, err := redisMap(v)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n",
+			redisString(group["name"]),
+			redisString(group["consumers"]),
+			redisString(group["pending"]),
+			redisString(group["last-delivered-id"]))
+	}
+	return w.Flush()
+}
+
+func streamPending(client *RedisClient, stream string, opt *streamCommandOptions) error {
+	if err := opt.requireGroup(); err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(stdout, 0, 4, 2, ' ', 0)
+
+	// list the pending messages
+	if opt.count > 0 {
+		reply, err := client.Do("XPENDING", stream, opt.group, "-", "+", strconv.FormatInt(opt.count, 10))
+		if err != nil {
+			return err
+		}
+		messages, err := redisArray(reply)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintln(w, "ID\tCONSUMER\tIDLE\tDELIVERIES")
+		for _, v := range messages {
+			message, err := redisArray(v)
+			if err != nil {
+				return err
+			}
+			if len(message) < 4 {
+				continue
+			}
+			idle, _ := message[2].(int64)
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n",
+				redisString(message[0]),
+				redisString(message[1]),
+				time.Duration(idle)*time.Millisecond,
+				redisString(message[3]))
+		}
+		return w.Flush()
+	}
+
+	// summary
+	reply, err := client.Do("XPENDING", stream, opt.group)
+	if err != nil {
+		return err
+	}
+	summary, err := redisArray(reply)
+	if err != nil {
+		return err
+	}
+	if len(summary) < 4 {
+		return errors.New("unexpected XPENDING reply")
+	}
+
+	fmt.Fprintf(stdout, "pending: %s  lowest: %s  highest: %s\n",
+		redisString(summary[0]),
+		redisString(summary[1]),
+		redisString(summary[2]))
+
+	consumers, err := redisArray(summary[3])
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(w, "CONSUMER\tPENDING")
+	for _, v := range consumers {
+		consumer, err := redisArray(v)
+		if err != nil {
+			return err
+		}
+		if len(consumer) < 2 {
+			continue
+		}
+		fmt.Fprintf(w, "%s\t%s\n", redisString(consumer[0]), redisString(consumer[1]))
+	}
+	return w.Flush()
+}
+
+func streamClaim(client *RedisClient, stream string, opt *streamCommandOptions) error {
+	if err := opt.requireGroup(); err != nil {
+		return err
+	}
+	if len(opt.consumer) == 0 {
+		return fmt.Errorf("cannot find %s in config.yaml, use -consumer instead", REDIS_CONSUMER_NAME_CONFIG_NAME)
+	}
+
+	var (
+		cursor  = "0-0"
+		claimed int64
+	)
+	for claimed < opt.count {
+		reply, err := client.Do("XAUTOCLAIM", stream, opt.group, opt.consumer,
+			strconv.FormatInt(opt.minIdle.Milliseconds(), 10),
+			cursor,
+			"COUNT", strconv.FormatInt(opt.count-claimed, 10),
+			"JUSTID")
+		if err != nil {
+			return err
+		}
+		result, err := redisArray(reply)
+		if err != nil {
+			return err
+		}
+		if len(result) < 2 {
+			return errors.New("unexpected XAUTOCLAIM reply")
+		}
+
+		ids, err := redisArray(result[1])
+		if err != nil {
+			return err
+		}
+		for _, id := range ids {
+			fmt.Fprintf(stdout, "claimed %s\n", redisString(id))
+		}
+		claimed += int64(len(ids))
+
+		cursor = redisString(result[0])
+		if cursor == "0-0" {
+			break
+		}
+	}
+	fmt.Fprintf(stdout, "%d message(s) claimed by '%s'\n", claimed, opt.consumer)
+	return nil
+}
+
+func streamTrim(client *RedisClient, stream string, opt *streamCommandOptions) error {
+	if opt.maxlen < 0 {
+		return errors.New("stream trim requires -maxlen")
+	}
+
+	args := []string{"XTRIM", stream, "MAXLEN"}
+	if opt.approx {
+		args = append(args, "~")
+	}
+	args = append(args, strconv.FormatInt(opt.maxlen, 10))
+
+	reply, err := client.Do(args...)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "%s entries trimmed\n", redisString(reply))
+	return nil
+}
+
+func streamCreateGroup(client *RedisClient, stream string, opt *streamCommandOptions) error {
+	if err := opt.requireGroup(); err != nil {
+		return err
+	}
+
+	args := []string{"XGROUP", "CREATE", stream, opt.group, opt.id}
+	if opt.mkstream {
+		args = append(args, "MKSTREAM")
+	}
+
+	_, err := client.Do(args...)
+	if err != nil {
+		if strings.HasPrefix(err.Error(), "BUSYGROUP") {
+			fmt.Fprintf(stdout, "group '%s' already exists on '%s'\n", opt.group, stream)
+			return nil
+		}
+		return err
+	}
+	fmt.Fprintf(stdout, "group '%s' created on '%s'\n", opt.group, stream)
+	return nil
+}
+
+func (opt *streamCommandOptions) requireGroup() error {
+	if len(opt.group) == 0 {
+		return fmt.Errorf("cannot find %s in config.yaml, use -group instead", REDIS_CONSUMER_GROUP_CONFIG_NAME)
+	}
+	return nil
+}
+
+// parseFlags parses the flags which are interspersed with positional arguments.
+func parseFlags(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			break
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+	return positional, nil
+}
+
+func showStreamUsage() {
+	fmt.Print(`stream USAGE:
+  worker stream COMMAND STREAM [OPTIONS...]
+
+  NOTE: The redis server, database, consumer group and consumer name are
+        read from REDIS_SERVER in .env and RedisDB, RedisConsumerGroup,
+        RedisConsumerName in config.yaml of the current project.
+
+stream COMMANDS:
+  groups          list the consumer groups of the stream.
+  pending         show the pending messages of the consumer group.
+  claim           claim the idle pending messages to the consumer.
+  trim            trim the stream to the specified length.
+  create-group    create the consumer group on the stream.
+
+stream OPTIONS:
+  -server ADDR    the redis server address.
+  -db DB          the redis database.
+  -group GROUP    the consumer group.
+
+  pending:
+    -count N          list the pending messages up to N.
+  claim:
+    -consumer NAME    the consumer claims the messages.
+    -min-idle TIME    the minimum idle time of the messages, e.g: 30s.
+    -count N          the maximum number of messages to claim.
+  trim:
+    -maxlen N         the maximum length of the stream.
+    -approx           trim the stream approximately.
+  create-group:
+    -id ID            the last delivered id, default is '$'.
+    -mkstream         create the stream if it does not exist.
+
+`)
+}
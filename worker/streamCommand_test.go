@@ -0,0 +1,210 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"net"
+	"os"
+	"path"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+var (
+	_FILE_STREAM_ENV = `Environment=local
+REDIS_SERVER=%s,127.0.0.2:6379
+`
+	_FILE_STREAM_CONFIG_YAML = `
+RedisDB: 0
+RedisConsumerGroup: demo-group
+RedisConsumerName: demo-consumer
+RedisClaimMinIdleTime: 30s
+`
+	_FILE_STREAM_CONFIG_LOCAL_YAML = `
+RedisDB: 3
+`
+)
+
+// fakeRedisServer replies the canned RESP replies keyed by command name and
+// records the received commands.
+type fakeRedisServer struct {
+	listener net.Listener
+	replies  map[string]string
+
+	mutex    sync.Mutex
+	commands [][]string
+}
+
+func startFakeRedisServer(t *testing.T, replies map[string]string) *fakeRedisServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := &fakeRedisServer{
+		listener: listener,
+		replies:  replies,
+	}
+	t.Cleanup(func() {
+		listener.Close()
+	})
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go server.serve(conn)
+		}
+	}()
+	return server
+}
+
+func (s *fakeRedisServer) serve(conn net.Conn) {
+	defer conn.Close()
+
+	reader := bufio.NewReader(conn)
+	for {
+		reply, err := readRedisReply(reader)
+		if err != nil {
+			return
+		}
+		array, _ := reply.([]interface{})
+		command := make([]string, len(array))
+		for i, v := range array {
+			command[i] = redisString(v)
+		}
+
+		s.mutex.Lock()
+		s.commands = append(s.commands, command)
+		s.mutex.Unlock()
+
+		resp, ok := s.replies[strings.ToUpper(command[0])]
+		if !ok {
+			resp = "-ERR unknown command\r\n"
+		}
+		fmt.Fprint(conn, resp)
+	}
+}
+
+func (s *fakeRedisServer) Commands() [][]string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.commands
+}
+
+func TestStreamCommand(t *testing.T) {
+	server := startFakeRedisServer(t, map[string]string{
+		"SELECT": "+OK\r\n",
+		"XINFO": "*1\r\n" +
+			"*8\r\n" +
+			"$4\r\nname\r\n$10\r\ndemo-group\r\n" +
+			"$9\r\nconsumers\r\n:2\r\n" +
+			"$7\r\npending\r\n:5\r\n" +
+			"$17\r\nlast-delivered-id\r\n$15\r\n1700000000000-0\r\n",
+		"XPENDING": "*4\r\n" +
+			":5\r\n" +
+			"$15\r\n1700000000000-0\r\n" +
+			"$15\r\n1700000000004-0\r\n" +
+			"*1\r\n*2\r\n$13\r\ndemo-consumer\r\n$1\r\n5\r\n",
+		"XAUTOCLAIM": "*3\r\n" +
+			"$3\r\n0-0\r\n" +
+			"*2\r\n$15\r\n1700000000000-0\r\n$15\r\n1700000000001-0\r\n" +
+			"*0\r\n",
+		"XTRIM":  ":7\r\n",
+		"XGROUP": "-BUSYGROUP Consumer Group name already exists\r\n",
+	})
+
+	tmp := t.TempDir()
+	workdir, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.Chdir(tmp)
+	defer os.Chdir(workdir)
+
+	assertNoError(t,
+		os.WriteFile(path.Join(tmp, FILE_ENV), []byte(fmt.Sprintf(_FILE_STREAM_ENV, server.listener.Addr().String())), 0644),
+		os.WriteFile(path.Join(tmp, FILE_CONFIG_YAML), []byte(_FILE_STREAM_CONFIG_YAML), 0644),
+		os.WriteFile(path.Join(tmp, FILE_CONFIG_LOCAL_YAML), []byte(_FILE_STREAM_CONFIG_LOCAL_YAML), 0644),
+	)
+
+	var buf bytes.Buffer
+	stdout = &buf
+	defer func() {
+		stdout = os.Stdout
+	}()
+
+	cases := []struct {
+		args            []string
+		expectedCommand []string
+		expectedOutput  string
+	}{
+		{
+			args:            []string{"groups", "demo-stream"},
+			expectedCommand: []string{"XINFO", "GROUPS", "demo-stream"},
+			expectedOutput: "GROUP       CONSUMERS  PENDING  LAST-DELIVERED-ID\n" +
+				"demo-group  2          5        1700000000000-0\n",
+		},
+		{
+			args:            []string{"pending", "demo-stream"},
+			expectedCommand: []string{"XPENDING", "demo-stream", "demo-group"},
+			expectedOutput: "pending: 5  lowest: 1700000000000-0  highest: 1700000000004-0\n" +
+				"CONSUMER       PENDING\n" +
+				"demo-consumer  5\n",
+		},
+		{
+			args:            []string{"claim", "demo-stream", "-count", "10"},
+			expectedCommand: []string{"XAUTOCLAIM", "demo-stream", "demo-group", "demo-consumer", "30000", "0-0", "COUNT", "10", "JUSTID"},
+			expectedOutput: "claimed 1700000000000-0\n" +
+				"claimed 1700000000001-0\n" +
+				"2 message(s) claimed by 'demo-consumer'\n",
+		},
+		{
+			args:            []string{"trim", "-maxlen", "1000", "demo-stream", "-approx"},
+			expectedCommand: []string{"XTRIM", "demo-stream", "MAXLEN", "~", "1000"},
+			expectedOutput:  "7 entries trimmed\n",
+		},
+		{
+			args:            []string{"create-group", "demo-stream", "-group", "other-group", "-mkstream"},
+			expectedCommand: []string{"XGROUP", "CREATE", "demo-stream", "other-group", "$", "MKSTREAM"},
+			expectedOutput:  "group 'other-group' already exists on 'demo-stream'\n",
+		},
+	}
+
+	for _, c := range cases {
+		buf.Reset()
+		err := runStreamCommand(c.args)
+		if err != nil {
+			t.Fatalf("stream %v: %v", c.args, err)
+		}
+
+		commands := server.Commands()
+		// SELECT 3 from config.local.yaml should be sent first
+		if !reflect.DeepEqual(commands[len(commands)-2], []string{"SELECT", "3"}) {
+			t.Errorf("stream %v: expect SELECT 3, got %v", c.args, commands[len(commands)-2])
+		}
+		if !reflect.DeepEqual(commands[len(commands)-1], c.expectedCommand) {
+			t.Errorf("stream %v: command expect %v, got %v", c.args, c.expectedCommand, commands[len(commands)-1])
+		}
+		if buf.String() != c.expectedOutput {
+			t.Errorf("stream %v: output expect:\n%s\ngot:\n%s\n", c.args, c.expectedOutput, buf.String())
+		}
+	}
+
+	// missing STREAM argument
+	if err := runStreamCommand([]string{"groups"}); err == nil {
+		t.Errorf("should get error when STREAM argument is missing")
+	}
+}
+
+func assertNoError(t *testing.T, err ...error) {
+	for _, e := range err {
+		if e != nil {
+			t.Fatal(e)
+		}
+	}
+}